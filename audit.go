@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// signingKey decodes AUDIT_SIGNING_KEY: a base64 ed25519 seed, or a full
+// private key. It returns nil when the key is not configured.
+func signingKey(cfg config) (ed25519.PrivateKey, error) {
+	if cfg.AuditSigningKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.AuditSigningKey)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("AUDIT_SIGNING_KEY: %w", err)
+	case len(key) == ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case len(key) == ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	}
+	return nil, fmt.Errorf("AUDIT_SIGNING_KEY: want a %d-byte ed25519 seed or %d-byte private key, got %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+}
+
+// publicKey decodes AUDIT_PUBLIC_KEY, falling back to the public half of
+// AUDIT_SIGNING_KEY, so that auditors can verify checkpoints without being
+// able to sign them.
+func publicKey(cfg config) (ed25519.PublicKey, error) {
+	if cfg.AuditPublicKey == "" {
+		private, err := signingKey(cfg)
+		if private == nil || err != nil {
+			return nil, err
+		}
+		return private.Public().(ed25519.PublicKey), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.AuditPublicKey)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("AUDIT_PUBLIC_KEY: %w", err)
+	case len(key) != ed25519.PublicKeySize:
+		return nil, fmt.Errorf("AUDIT_PUBLIC_KEY: want a %d-byte ed25519 public key, got %d bytes", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// checkpointAudit signs the head of the audit log, once right away and then
+// every interval until ctx is done.
+func checkpointAudit(ctx context.Context, empDB database.EmployeeDB, key ed25519.PrivateKey, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkpoint, err := empDB.CheckpointAudit(ctx, key)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Failed to checkpoint the audit log: %v", err)
+		case err == nil:
+			log.Printf("Signed audit checkpoint %d at entry %d", checkpoint.ID, checkpoint.EntryID)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runVerifyAudit implements the verify-audit subcommand: it walks the audit
+// chain and fails on the first broken link.
+func runVerifyAudit(ctx context.Context, cfg config) error {
+	key, err := publicKey(cfg)
+	if err != nil {
+		return err
+	}
+	// Verifying must not change the database it checks.
+	cfg.MigrateOnStart = false
+	empDB, closeDB, err := openEmployeeDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	v, err := database.VerifyAuditChain(ctx, empDB, key)
+	if err != nil {
+		return fmt.Errorf("audit chain broken: %w", err)
+	}
+	fmt.Printf("Verified %d audit entries and %d signed checkpoints.\n", v.Entries, v.Checkpoints)
+	if v.Unchained > 0 {
+		fmt.Printf("%d entries predate the chain and carry no hash.\n", v.Unchained)
+	}
+	if v.Unanchored > 0 {
+		fmt.Printf("%d entries follow the last checkpoint and are not signed yet.\n", v.Unanchored)
+	}
+	if v.Head != "" {
+		fmt.Printf("Head: %s\n", v.Head)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"sync"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -9,6 +10,40 @@ import (
 type config struct {
 	DbURL string `env:"DB_URL,required,notEmpty"`
 	Port  string `env:"PORT" envDefault:"8080"`
+	// GRPCPort serves the gRPC EmployeeService alongside the REST API.
+	// Callers authenticate with GRPCToken as a bearer token, and only they
+	// may name the actor of a request.
+	GRPCPort  string `env:"GRPC_PORT" envDefault:"9090"`
+	GRPCToken string `env:"GRPC_TOKEN"`
+
+	MigrateOnStart bool          `env:"MIGRATE_ON_START" envDefault:"true"`
+	QueryTimeout   time.Duration `env:"QUERY_TIMEOUT" envDefault:"5s"`
+	CursorSecret   string        `env:"CURSOR_SECRET"`
+	Admins         []string      `env:"ADMINS"`
+
+	// Deleted employees are purged once they have been deleted for
+	// PurgeAfter. Zero keeps them forever.
+	PurgeAfter    time.Duration `env:"PURGE_AFTER" envDefault:"720h"`
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`
+
+	// The head of the audit log is signed with AuditSigningKey every
+	// CheckpointInterval; AuditPublicKey verifies the signatures.
+	AuditSigningKey    string        `env:"AUDIT_SIGNING_KEY"`
+	AuditPublicKey     string        `env:"AUDIT_PUBLIC_KEY"`
+	CheckpointInterval time.Duration `env:"AUDIT_CHECKPOINT_INTERVAL" envDefault:"1h"`
+
+	// SCIMToken is the bearer token the identity provider authenticates to
+	// /scim/v2 with; without one the authenticating proxy must. Users it
+	// creates without a salary get SCIMDefaultSalary, in
+	// SCIMDefaultCurrency, or are refused if it is empty.
+	SCIMToken           string `env:"SCIM_TOKEN"`
+	SCIMDefaultSalary   string `env:"SCIM_DEFAULT_SALARY"`
+	SCIMDefaultCurrency string `env:"SCIM_DEFAULT_CURRENCY" envDefault:"USD"`
+
+	// Scheduled salary changes are stored and audited within
+	// SalaryApplyInterval of their date; reads show them from the date on.
+	// Zero leaves them to another instance.
+	SalaryApplyInterval time.Duration `env:"SALARY_APPLY_INTERVAL" envDefault:"1h"`
 }
 
 var (
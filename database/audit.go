@@ -0,0 +1,229 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditActions are the actions recorded in the audit log.
+var AuditActions = []string{AuditCreate, AuditUpdate, AuditDelete, AuditRestore, AuditPurge}
+
+// AuditFields are the employee fields an audit entry can record changes to.
+// band_override is not a field but the reason a salary outside the band of
+// the position was allowed, recorded on the write that set it.
+var AuditFields = []string{"name", "position", "position_id", "salary", "currency", "department_id", "manager_id", "external_id", "user_name", "deleted_at", "deleted_by", "band_override"}
+
+// AuditEntry records one write to an employee: who made it, on behalf of
+// which request, and how each field changed.
+//
+// Hash chains the entry to the one before it, so that editing or removing
+// an entry breaks the chain; see VerifyAuditChain. Entries written before
+// the chain existed have no hash.
+type AuditEntry struct {
+	ID         int64
+	EmployeeID int
+	Action     string
+	Actor      string
+	RequestID  string
+	Changes    map[string]Change
+	At         time.Time
+	Hash       string
+}
+
+// Change is the value of a field before and after a write. An empty From or
+// To means the field had no value, as before a create or after a purge.
+type Change struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// AuditQuery selects audit entries. Zero-valued filters match every entry.
+type AuditQuery struct {
+	EmployeeID int
+	Actor      string
+	Action     string
+	RequestID  string
+	// Field matches the entries that changed it, one of AuditFields.
+	Field string
+	// Since and Until bound the time of the entries; Since is inclusive
+	// and Until exclusive.
+	Since time.Time
+	Until time.Time
+
+	// After continues a listing from the entry with this ID. Entries are
+	// listed oldest first, Limit at a time.
+	After int64
+	Limit int
+}
+
+// AuditPage is one page of audit entries.
+type AuditPage struct {
+	Entries []AuditEntry
+	// More reports whether further entries follow the page.
+	More bool
+}
+
+// Validate reports a query that cannot be run.
+func (q AuditQuery) Validate() error {
+	if q.Action != "" && !slices.Contains(AuditActions, q.Action) {
+		return fmt.Errorf("%w: unknown audit action %q", ErrInvalidQuery, q.Action)
+	}
+	if q.Field != "" && !slices.Contains(AuditFields, q.Field) {
+		return fmt.Errorf("%w: unknown audit field %q", ErrInvalidQuery, q.Field)
+	}
+	if q.Limit <= 0 {
+		return fmt.Errorf("%w: the limit must be positive", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// filters renders the filters of q as conditions with $n placeholders.
+func (q AuditQuery) filters() ([]string, []any) {
+	var conds []string
+	var args []any
+	arg := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if q.EmployeeID != 0 {
+		arg(`employee_id = $%d`, q.EmployeeID)
+	}
+	if q.Actor != "" {
+		arg(`actor = $%d`, q.Actor)
+	}
+	if q.Action != "" {
+		arg(`action = $%d`, q.Action)
+	}
+	if q.RequestID != "" {
+		arg(`request_id = $%d`, q.RequestID)
+	}
+	if q.Field != "" {
+		// Keys are the only quoted strings followed by a colon in the
+		// JSON of the changes, as quotes inside values are escaped.
+		arg(`changes LIKE $%d ESCAPE '\'`, `%"`+escapeLike(q.Field)+`":%`)
+	}
+	if !q.Since.IsZero() {
+		arg(`created_at >= $%d`, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		arg(`created_at < $%d`, q.Until.UTC())
+	}
+	if q.After != 0 {
+		arg(`id > $%d`, q.After)
+	}
+	return conds, args
+}
+
+// matches is the in-memory equivalent of AuditQuery.filters.
+func (q AuditQuery) matches(e AuditEntry) bool {
+	_, changed := e.Changes[q.Field]
+	switch {
+	case q.EmployeeID != 0 && e.EmployeeID != q.EmployeeID,
+		q.Actor != "" && e.Actor != q.Actor,
+		q.Action != "" && e.Action != q.Action,
+		q.RequestID != "" && e.RequestID != q.RequestID,
+		q.Field != "" && !changed,
+		!q.Since.IsZero() && e.At.Before(q.Since),
+		!q.Until.IsZero() && !e.At.Before(q.Until),
+		e.ID <= q.After:
+		return false
+	}
+	return true
+}
+
+// trim drops the extra entry read to fill More.
+func (p *AuditPage) trim(q AuditQuery) {
+	if len(p.Entries) > q.Limit {
+		p.Entries = p.Entries[:q.Limit]
+		p.More = true
+	}
+}
+
+// newAuditEntry describes a write that turned employee before into after,
+// made with ctx. before is nil for a create, and after for a purge.
+func newAuditEntry(ctx context.Context, action string, before, after *Employee) AuditEntry {
+	entry := AuditEntry{
+		EmployeeID: cmp.Or(after, before).ID,
+		Action:     action,
+		Actor:      ActorFrom(ctx),
+		RequestID:  RequestIDFrom(ctx),
+		Changes:    make(map[string]Change),
+		At:         now(),
+	}
+	from, to := auditValues(before), auditValues(after)
+	for _, field := range AuditFields {
+		if from[field] != to[field] {
+			entry.Changes[field] = Change{From: from[field], To: to[field]}
+		}
+	}
+	if reason := BandOverrideFrom(ctx); reason != "" {
+		entry.Changes["band_override"] = Change{To: reason}
+	}
+	return entry
+}
+
+// auditValues renders the audited fields of e as text.
+func auditValues(e *Employee) map[string]string {
+	if e == nil {
+		return nil
+	}
+	values := map[string]string{
+		"name":       e.Name,
+		"position":   e.Position,
+		"salary":     e.Salary.String(),
+		"currency":   e.Salary.Currency,
+		"deleted_by": e.DeletedBy,
+	}
+	if e.DepartmentID != 0 {
+		values["department_id"] = strconv.Itoa(e.DepartmentID)
+	}
+	if e.ManagerID != 0 {
+		values["manager_id"] = strconv.Itoa(e.ManagerID)
+	}
+	if e.PositionID != 0 {
+		values["position_id"] = strconv.Itoa(e.PositionID)
+	}
+	if e.ExternalID != "" {
+		values["external_id"] = e.ExternalID
+	}
+	if e.UserName != "" {
+		values["user_name"] = e.UserName
+	}
+	if e.DeletedAt != nil {
+		values["deleted_at"] = e.DeletedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return values
+}
+
+// now is the current time as stored by the database: Postgres keeps
+// timestamps to the microsecond.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// auditColumns are the columns scanned by scanAuditEntry, in order.
+const auditColumns = `id, employee_id, action, actor, request_id, changes, created_at, hash`
+
+func scanAuditEntry(row interface{ Scan(...any) error }, entry *AuditEntry) error {
+	var changes string
+	err := row.Scan(&entry.ID, &entry.EmployeeID, &entry.Action, &entry.Actor, &entry.RequestID, &changes, &entry.At, &entry.Hash)
+	if err != nil {
+		return err
+	}
+	entry.At = entry.At.UTC()
+	return json.Unmarshal([]byte(changes), &entry.Changes)
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuditCheckpoint is a signature over the hash of an audit entry. As every
+// hash covers the entries before it, a valid checkpoint vouches for the whole
+// chain up to EntryID: rewriting it would take the signing key.
+type AuditCheckpoint struct {
+	ID        int64
+	EntryID   int64
+	Hash      string
+	Signature []byte
+	At        time.Time
+}
+
+// signedMessage is what the signature of c covers.
+func (c AuditCheckpoint) signedMessage() []byte {
+	return fmt.Appendf(nil, "employeemanager audit checkpoint\n%d\n%s\n%s", c.EntryID, c.Hash, c.At.UTC().Format(time.RFC3339Nano))
+}
+
+// newAuditCheckpoint signs the hash of the entry with the given ID.
+func newAuditCheckpoint(entryID int64, hash string, key ed25519.PrivateKey) AuditCheckpoint {
+	c := AuditCheckpoint{EntryID: entryID, Hash: hash, At: now()}
+	c.Signature = ed25519.Sign(key, c.signedMessage())
+	return c
+}
+
+// chainHash is the hash of entry chained to prev, the hash of the entry
+// before it, or empty for the first entry. The ID of the entry is left out
+// as it is only known once the entry is stored; its place in the chain
+// fixes its order instead.
+func chainHash(prev string, entry AuditEntry) string {
+	// A JSON array keeps the fields apart whatever they contain, and maps
+	// are encoded with sorted keys.
+	payload, _ := json.Marshal([]any{
+		prev,
+		entry.EmployeeID,
+		entry.Action,
+		entry.Actor,
+		entry.RequestID,
+		entry.Changes,
+		entry.At.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// BrokenLinkError reports the first audit entry that does not chain up.
+type BrokenLinkError struct {
+	EntryID int64
+	Reason  string
+}
+
+func (e *BrokenLinkError) Error() string {
+	return fmt.Sprintf("audit entry %d: %s", e.EntryID, e.Reason)
+}
+
+// AuditVerification summarises an intact audit chain.
+type AuditVerification struct {
+	// Entries counts the chained entries. Unchained counts those written
+	// before the chain existed, which carry no hash and come first.
+	Entries   int
+	Unchained int
+	// Checkpoints counts the checkpoints, all with valid signatures.
+	// Unanchored counts the entries after the last of them: they chain
+	// up, but nothing signed vouches for them yet.
+	Checkpoints int
+	Unanchored  int
+	// Head is the hash of the last entry.
+	Head string
+}
+
+// verifyPageSize is the number of entries VerifyAuditChain reads at a time.
+const verifyPageSize = 1000
+
+// VerifyAuditChain walks the audit log of edb from the first entry and
+// checks that every entry hashes to its stored hash given the one before it,
+// and that every checkpoint is signed by key and matches the entry it
+// names. The first entry that fails either check is reported as a
+// *BrokenLinkError. key may be nil while there are no checkpoints.
+func VerifyAuditChain(ctx context.Context, edb EmployeeDB, key ed25519.PublicKey) (AuditVerification, error) {
+	var v AuditVerification
+	checkpoints, err := edb.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return v, err
+	}
+	if len(checkpoints) > 0 && len(key) != ed25519.PublicKeySize {
+		return v, errors.New("verifying audit checkpoints needs an ed25519 public key")
+	}
+	pending := make(map[int64][]AuditCheckpoint)
+	for _, c := range checkpoints {
+		pending[c.EntryID] = append(pending[c.EntryID], c)
+	}
+
+	q := AuditQuery{Limit: verifyPageSize}
+	for {
+		page, err := edb.ListAuditEntries(ctx, q)
+		if err != nil {
+			return v, err
+		}
+		for _, entry := range page.Entries {
+			switch {
+			case entry.Hash == "" && v.Entries == 0:
+				v.Unchained++
+				continue
+			case entry.Hash == "":
+				return v, &BrokenLinkError{EntryID: entry.ID, Reason: "the entry has no hash but follows chained entries"}
+			case entry.Hash != chainHash(v.Head, entry):
+				return v, &BrokenLinkError{EntryID: entry.ID, Reason: "the hash does not match the entry and the one before it"}
+			}
+			v.Head = entry.Hash
+			v.Entries++
+			v.Unanchored++
+			for _, c := range pending[entry.ID] {
+				switch {
+				case c.Hash != entry.Hash:
+					return v, &BrokenLinkError{EntryID: entry.ID, Reason: fmt.Sprintf("the hash differs from checkpoint %d", c.ID)}
+				case !ed25519.Verify(key, c.signedMessage(), c.Signature):
+					return v, &BrokenLinkError{EntryID: entry.ID, Reason: fmt.Sprintf("checkpoint %d has an invalid signature", c.ID)}
+				}
+				v.Checkpoints++
+				v.Unanchored = 0
+			}
+			delete(pending, entry.ID)
+		}
+		if !page.More {
+			break
+		}
+		q.After = page.Entries[len(page.Entries)-1].ID
+	}
+
+	// Checkpoints of entries that were never reached point at entries
+	// removed from the log.
+	for _, c := range checkpoints {
+		if _, missing := pending[c.EntryID]; missing {
+			return v, &BrokenLinkError{EntryID: c.EntryID, Reason: fmt.Sprintf("the entry signed by checkpoint %d is missing", c.ID)}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestVerifyAuditChain(t *testing.T) {
+	ctx := context.Background()
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+	// Each test starts from a log of four entries, checkpointed at the third,
+	// and tampers with it behind the back of the EmployeeDB.
+	tests := []struct {
+		name      string
+		tamper    func(t *testing.T, db *sql.DB)
+		wantEntry int64
+	}{
+		{
+			name:   "intact",
+			tamper: func(t *testing.T, db *sql.DB) {},
+		},
+		{
+			name: "edited entry",
+			tamper: func(t *testing.T, db *sql.DB) {
+				exec(t, db, `UPDATE audit_log SET changes='{"salary":{"from":"50000.00","to":"99000.00"}}' WHERE id=2`)
+			},
+			wantEntry: 2,
+		},
+		{
+			name: "removed entry",
+			tamper: func(t *testing.T, db *sql.DB) {
+				exec(t, db, `DELETE FROM audit_log WHERE id=2`)
+			},
+			wantEntry: 3,
+		},
+		{
+			name: "rehashed chain",
+			tamper: func(t *testing.T, db *sql.DB) {
+				exec(t, db, `UPDATE audit_log SET actor='mallory' WHERE id=2`)
+				rehash(t, db)
+			},
+			wantEntry: 3,
+		},
+		{
+			name: "removed checkpointed entries",
+			tamper: func(t *testing.T, db *sql.DB) {
+				exec(t, db, `DELETE FROM audit_log WHERE id>=3`)
+			},
+			wantEntry: 3,
+		},
+		{
+			name: "unchained entry after the chain",
+			tamper: func(t *testing.T, db *sql.DB) {
+				exec(t, db, `INSERT INTO audit_log (employee_id, action, actor, request_id, changes, created_at) VALUES (1, 'update', '', '', '{}', '2024-01-01T00:00:00Z')`)
+			},
+			wantEntry: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, edb := newAuditTestDB(t)
+			writeAuditEntries(t, edb, 3)
+			if _, err := edb.CheckpointAudit(ctx, key); err != nil {
+				t.Fatal(err)
+			}
+			writeAuditEntries(t, edb, 1)
+			exec(t, db, `DROP TRIGGER audit_log_no_update`)
+			exec(t, db, `DROP TRIGGER audit_log_no_delete`)
+			tt.tamper(t, db)
+
+			v, err := VerifyAuditChain(ctx, edb, key.Public().(ed25519.PublicKey))
+			var broken *BrokenLinkError
+			switch {
+			case tt.wantEntry == 0 && err != nil:
+				t.Errorf("VerifyAuditChain() error = %v", err)
+			case tt.wantEntry == 0 && (v.Entries != 4 || v.Checkpoints != 1 || v.Unanchored != 1):
+				t.Errorf("VerifyAuditChain() = %+v, want 4 entries, 1 checkpoint and 1 unanchored entry", v)
+			case tt.wantEntry != 0 && (!errors.As(err, &broken) || broken.EntryID != tt.wantEntry):
+				t.Errorf("VerifyAuditChain() error = %v, want a broken link at entry %d", err, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainUnchainedEntries(t *testing.T) {
+	db, edb := newAuditTestDB(t)
+	// Entries written before the chain existed have no hash.
+	exec(t, db, `INSERT INTO audit_log (employee_id, action, actor, request_id, changes, created_at) VALUES (1, 'create', '', '', '{}', '2024-01-01T00:00:00Z')`)
+	writeAuditEntries(t, edb, 2)
+
+	v, err := VerifyAuditChain(context.Background(), edb, nil)
+	if err != nil || v.Unchained != 1 || v.Entries != 2 {
+		t.Errorf("VerifyAuditChain() = %+v, %v, want 1 unchained and 2 chained entries", v, err)
+	}
+}
+
+func newAuditTestDB(t *testing.T) (*sql.DB, EmployeeDB) {
+	t.Helper()
+	db, dialect, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrateUp(db, dialect); err != nil {
+		t.Fatal(err)
+	}
+	return db, NewSQLiteEmployee(db)
+}
+
+// writeAuditEntries writes n audit entries: a create followed by raises.
+func writeAuditEntries(t *testing.T, edb EmployeeDB, n int) {
+	t.Helper()
+	ctx := context.Background()
+	page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emp Employee
+	if len(page.Employees) == 0 {
+		if emp, err = edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)}); err != nil {
+			t.Fatal(err)
+		}
+		n--
+	} else {
+		emp = page.Employees[0]
+	}
+	for range n {
+		salary := emp.Salary
+		salary.Amount += 100000
+		if emp, err = edb.PatchEmployee(ctx, emp.ID, EmployeeChanges{Salary: &salary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// rehash recomputes every hash of the audit log, as someone covering up an
+// edit without the signing key would.
+func rehash(t *testing.T, db *sql.DB) {
+	t.Helper()
+	edb := NewSQLiteEmployee(db)
+	page, err := edb.ListAuditEntries(context.Background(), AuditQuery{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := ""
+	for _, entry := range page.Entries {
+		prev = chainHash(prev, entry)
+		exec(t, db, `UPDATE audit_log SET hash=$1 WHERE id=$2`, prev, entry.ID)
+	}
+}
+
+func exec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
@@ -0,0 +1,1362 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runConformance checks the behaviour every EmployeeDB implementation must
+// share. newDB must return an empty store.
+func runConformance(t *testing.T, newDB func(t *testing.T) EmployeeDB) {
+	ctx := context.Background()
+
+	t.Run("create assigns sequential ids", func(t *testing.T) {
+		edb := newDB(t)
+		first, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		if err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		second, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Manager", Salary: usd(60000)})
+		if err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		if first.ID != 1 || second.ID != 2 {
+			t.Errorf("CreateEmployee() ids = %d, %d, want 1, 2", first.ID, second.ID)
+		}
+	})
+
+	t.Run("get returns created employee", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		got, err := edb.GetEmployeeByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetEmployeeByID() error = %v", err)
+		}
+		if got != created {
+			t.Errorf("GetEmployeeByID() = %+v, want %+v", got, created)
+		}
+	})
+
+	t.Run("get missing employee", func(t *testing.T) {
+		edb := newDB(t)
+		_, err := edb.GetEmployeeByID(ctx, 42)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEmployeeByID() error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("get by ids skips missing and deleted employees", func(t *testing.T) {
+		edb := newDB(t)
+		for _, name := range []string{"John Doe", "Jane Doe", "Max Mustermann"} {
+			if _, err := edb.CreateEmployee(ctx, Employee{Name: name, Position: "Engineer", Salary: usd(50000)}); err != nil {
+				t.Fatalf("CreateEmployee() error = %v", err)
+			}
+		}
+		if err := edb.DeleteEmployee(ctx, 2, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		got, err := edb.GetEmployeesByIDs(ctx, []int{3, 42, 2, 1, 3})
+		if err != nil {
+			t.Fatalf("GetEmployeesByIDs() error = %v", err)
+		}
+		var names []string
+		for _, employee := range got {
+			names = append(names, employee.Name)
+		}
+		if fmt.Sprint(names) != "[John Doe Max Mustermann]" {
+			t.Errorf("GetEmployeesByIDs() = %v, want [John Doe Max Mustermann]", names)
+		}
+	})
+
+	t.Run("update replaces fields", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		want := created
+		want.Position = "Manager"
+		want.Salary = usd(70000)
+		updated, err := edb.UpdateEmployee(ctx, want)
+		if err != nil {
+			t.Fatalf("UpdateEmployee() error = %v", err)
+		}
+		want.Version = 2
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); updated != want || got != want {
+			t.Errorf("UpdateEmployee() = %+v, stored %+v, want %+v", updated, got, want)
+		}
+	})
+
+	t.Run("update missing employee", func(t *testing.T) {
+		edb := newDB(t)
+		_, err := edb.UpdateEmployee(ctx, Employee{ID: 42, Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateEmployee() error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("patch writes only changed fields", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		position := "Manager"
+		got, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Position: &position})
+		if err != nil {
+			t.Fatalf("PatchEmployee() error = %v", err)
+		}
+		want := created
+		want.Position = position
+		want.Version = 2
+		if got != want {
+			t.Errorf("PatchEmployee() = %+v, want %+v", got, want)
+		}
+		salary := Money{Amount: 6000000, Currency: "JPY"}
+		got, _ = edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Salary: &salary})
+		want.Salary = salary
+		want.Version = 3
+		if stored, _ := edb.GetEmployeeByID(ctx, created.ID); got != want || stored != want {
+			t.Errorf("PatchEmployee() = %+v, stored %+v, want %+v", got, stored, want)
+		}
+		if got, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{}); err != nil || got != want {
+			t.Errorf("PatchEmployee() without changes = %+v, %v, want %+v", got, err, want)
+		}
+	})
+
+	t.Run("patch missing employee", func(t *testing.T) {
+		edb := newDB(t)
+		name := "Jane Doe"
+		if _, err := edb.PatchEmployee(ctx, 42, EmployeeChanges{Name: &name}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("PatchEmployee() error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := edb.PatchEmployee(ctx, 42, EmployeeChanges{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("PatchEmployee() without changes error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("delete hides employee until restored", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		if err := edb.DeleteEmployee(WithActor(ctx, "jane"), created.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		if _, err := edb.GetEmployeeByID(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEmployeeByID() after delete error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := edb.UpdateEmployee(ctx, created); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateEmployee() after delete error = %v, want %v", err, ErrNotFound)
+		}
+		if err := edb.DeleteEmployee(ctx, created.ID, 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteEmployee() error = %v, want %v", err, ErrNotFound)
+		}
+		if page, _ := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10}); len(page.Employees) != 0 || page.Total != 0 {
+			t.Errorf("ListEmployees() after delete = %+v, want no employees", page)
+		}
+		page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, IncludeDeleted: true})
+		if err != nil || len(page.Employees) != 1 {
+			t.Fatalf("ListEmployees() with deleted = %+v, %v, want the deleted employee", page, err)
+		}
+		if deleted := page.Employees[0]; deleted.DeletedAt == nil || deleted.DeletedBy != "jane" || deleted.Version != 2 {
+			t.Errorf("ListEmployees() with deleted = %+v, want it deleted by jane at version 2", deleted)
+		}
+
+		restored, err := edb.RestoreEmployee(ctx, created.ID)
+		want := created
+		want.Version = 3
+		if err != nil || restored != want {
+			t.Errorf("RestoreEmployee() = %+v, %v, want %+v", restored, err, want)
+		}
+		if got, err := edb.GetEmployeeByID(ctx, created.ID); err != nil || got != want {
+			t.Errorf("GetEmployeeByID() after restore = %+v, %v, want %+v", got, err, want)
+		}
+		if _, err := edb.RestoreEmployee(ctx, created.ID); !errors.Is(err, ErrConflict) {
+			t.Errorf("RestoreEmployee() of an employee that is not deleted error = %v, want %v", err, ErrConflict)
+		}
+		if _, err := edb.RestoreEmployee(ctx, 42); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreEmployee() of a missing employee error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("create and patch can delete and restore", func(t *testing.T) {
+		edb := newDB(t)
+		jane := WithActor(ctx, "jane")
+		deletedAt := time.Now()
+		created, err := edb.CreateEmployee(jane, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000), UserName: "john", DeletedAt: &deletedAt})
+		if err != nil {
+			t.Fatalf("CreateEmployee() deleted error = %v", err)
+		}
+		if created.DeletedAt == nil || created.DeletedBy != "jane" || created.Version != 2 {
+			t.Errorf("CreateEmployee() deleted = %+v, want it deleted by jane at version 2", created)
+		}
+		if _, err := edb.GetEmployeeByID(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEmployeeByID() of an employee created deleted error = %v, want %v", err, ErrNotFound)
+		}
+
+		// Changes are written to an employee that stays deleted.
+		position, deleted := "Manager", true
+		patched, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Position: &position, Deleted: &deleted, Version: created.Version})
+		if err != nil || patched.Position != position || patched.DeletedAt == nil || patched.Version != 3 {
+			t.Errorf("PatchEmployee() of a deleted employee = %+v, %v, want it deleted as a manager at version 3", patched, err)
+		}
+
+		// A failed step leaves the employee as it was.
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Engineer", Salary: usd(50000), UserName: "jane"}); err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		taken, restore := "jane", false
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{UserName: &taken, Deleted: &restore}); !errors.Is(err, ErrConflict) {
+			t.Errorf("PatchEmployee() to a taken user name error = %v, want %v", err, ErrConflict)
+		}
+		if _, err := edb.GetEmployeeByID(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetEmployeeByID() after a failed restore error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Deleted: &restore, Version: 2}); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("PatchEmployee() of a stale version error = %v, want %v", err, ErrVersionMismatch)
+		}
+
+		name := "John Smith"
+		restored, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Name: &name, Deleted: &restore})
+		if err != nil || restored.Name != name || restored.DeletedAt != nil || restored.Version != 5 {
+			t.Errorf("PatchEmployee() restoring = %+v, %v, want it restored and renamed at version 5", restored, err)
+		}
+		if got, err := edb.GetEmployeeByID(ctx, created.ID); err != nil || got != restored {
+			t.Errorf("GetEmployeeByID() after restore = %+v, %v, want %+v", got, err, restored)
+		}
+
+		if _, err := edb.PatchEmployee(ctx, 2, EmployeeChanges{ManagerID: &created.ID}); err != nil {
+			t.Fatalf("PatchEmployee() error = %v", err)
+		}
+		none := ""
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{UserName: &none, Deleted: &deleted}); !errors.Is(err, ErrHasReports) {
+			t.Errorf("PatchEmployee() deleting a manager error = %v, want %v", err, ErrHasReports)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); got.UserName != "john" {
+			t.Errorf("GetEmployeeByID() after a failed delete = %+v, want the user name kept", got)
+		}
+	})
+
+	t.Run("reads employees as of a past time", func(t *testing.T) {
+		edb := newDB(t)
+		// Versions are kept to the microsecond, so every write is given a
+		// moment of its own.
+		moment := func() time.Time {
+			time.Sleep(time.Millisecond)
+			at := time.Now()
+			time.Sleep(time.Millisecond)
+			return at
+		}
+		beforeHire := moment()
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		other, _ := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Engineer", Salary: usd(60000)})
+		hired := moment()
+		position := "Manager"
+		promoted, _ := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Position: &position})
+		afterPromotion := moment()
+		if err := edb.DeleteEmployee(ctx, created.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		afterDelete := moment()
+
+		for _, tt := range []struct {
+			at   time.Time
+			want Employee
+		}{
+			{beforeHire, Employee{}},
+			{hired, created},
+			{afterPromotion, promoted},
+			{afterDelete, Employee{}},
+		} {
+			got, err := edb.GetEmployeeAsOf(ctx, created.ID, tt.at)
+			switch {
+			case tt.want.ID == 0 && !errors.Is(err, ErrNotFound):
+				t.Errorf("GetEmployeeAsOf(%v) = %+v, %v, want %v", tt.at, got, err, ErrNotFound)
+			case tt.want.ID != 0 && (err != nil || got != tt.want):
+				t.Errorf("GetEmployeeAsOf(%v) = %+v, %v, want %+v", tt.at, got, err, tt.want)
+			}
+		}
+
+		page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: hired, Position: "engineer"})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{created.ID, other.ID}) || page.Total != 2 {
+			t.Errorf("ListEmployees() as of the hire = %+v, %v, want both engineers", page, err)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: afterPromotion, Position: "engineer"})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{other.ID}) {
+			t.Errorf("ListEmployees() as of the promotion = %+v, %v, want the other engineer", page, err)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{PerPage: 1, AsOf: afterPromotion, After: &Employee{ID: created.ID}, SkipTotal: true})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{other.ID}) {
+			t.Errorf("ListEmployees() by keyset as of the promotion = %+v, %v, want the other employee", page, err)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: afterDelete, IncludeDeleted: true})
+		if err != nil || len(page.Employees) != 2 || page.Employees[0].DeletedAt == nil {
+			t.Errorf("ListEmployees() with deleted as of the delete = %+v, %v, want the deleted employee", page, err)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: beforeHire})
+		if err != nil || len(page.Employees) != 0 || page.Total != 0 {
+			t.Errorf("ListEmployees() before the hire = %+v, %v, want no employees", page, err)
+		}
+	})
+
+	t.Run("purge removes employees deleted before the cutoff", func(t *testing.T) {
+		edb := newDB(t)
+		kept, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		deleted, _ := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Manager", Salary: usd(60000)})
+		time.Sleep(time.Millisecond)
+		hired := time.Now()
+		time.Sleep(time.Millisecond)
+		if err := edb.DeleteEmployee(ctx, deleted.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		if purged, err := edb.PurgeEmployees(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+			t.Errorf("PurgeEmployees() before the retention window = %d, %v, want 0", purged, err)
+		}
+		if purged, err := edb.PurgeEmployees(ctx, time.Now().Add(time.Hour)); err != nil || purged != 1 {
+			t.Errorf("PurgeEmployees() = %d, %v, want 1", purged, err)
+		}
+		if _, err := edb.RestoreEmployee(ctx, deleted.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreEmployee() after purge error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := edb.GetEmployeeByID(ctx, kept.ID); err != nil {
+			t.Errorf("GetEmployeeByID() of an employee that was not deleted error = %v", err)
+		}
+
+		// Reads as of the time the employee existed still find it.
+		if got, err := edb.GetEmployeeAsOf(ctx, deleted.ID, hired); err != nil || got != deleted {
+			t.Errorf("GetEmployeeAsOf() before the purge = %+v, %v, want %+v", got, err, deleted)
+		}
+		page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: hired})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{kept.ID, deleted.ID}) || page.Total != 2 {
+			t.Errorf("ListEmployees() as of the hire = %+v, %v, want both employees", page, err)
+		}
+		time.Sleep(time.Millisecond)
+		page, err = edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: time.Now(), IncludeDeleted: true})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{kept.ID}) {
+			t.Errorf("ListEmployees() with deleted after the purge = %+v, %v, want the employee that was kept", page, err)
+		}
+	})
+
+	t.Run("conditional writes check the version", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		if created.Version != 1 {
+			t.Fatalf("CreateEmployee() version = %d, want 1", created.Version)
+		}
+		updated, err := edb.UpdateEmployee(ctx, created)
+		if err != nil || updated.Version != 2 {
+			t.Fatalf("UpdateEmployee() = %+v, %v, want version 2", updated, err)
+		}
+
+		// created is now stale, so every conditional write based on it fails.
+		if _, err := edb.UpdateEmployee(ctx, created); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("UpdateEmployee() stale error = %v, want %v", err, ErrVersionMismatch)
+		}
+		name := "Jane Doe"
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Name: &name, Version: created.Version}); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("PatchEmployee() stale error = %v, want %v", err, ErrVersionMismatch)
+		}
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Version: created.Version}); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("PatchEmployee() stale without changes error = %v, want %v", err, ErrVersionMismatch)
+		}
+		if err := edb.DeleteEmployee(ctx, created.ID, created.Version); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("DeleteEmployee() stale error = %v, want %v", err, ErrVersionMismatch)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); got != updated {
+			t.Errorf("GetEmployeeByID() after stale writes = %+v, want %+v", got, updated)
+		}
+
+		patched, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Name: &name, Version: updated.Version})
+		if err != nil || patched.Version != 3 {
+			t.Fatalf("PatchEmployee() = %+v, %v, want version 3", patched, err)
+		}
+		if err := edb.DeleteEmployee(ctx, created.ID, patched.Version); err != nil {
+			t.Errorf("DeleteEmployee() error = %v", err)
+		}
+		if err := edb.DeleteEmployee(ctx, created.ID, patched.Version); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteEmployee() missing error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("writes are recorded in the audit log", func(t *testing.T) {
+		edb := newDB(t)
+		actx := WithRequestID(WithActor(ctx, "jane"), "req-1")
+		created, _ := edb.CreateEmployee(actx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		other, _ := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Manager", Salary: usd(60000)})
+		salary := usd(55000)
+		if _, err := edb.PatchEmployee(WithActor(ctx, "joe"), created.ID, EmployeeChanges{Salary: &salary}); err != nil {
+			t.Fatalf("PatchEmployee() error = %v", err)
+		}
+		if err := edb.DeleteEmployee(actx, created.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		if _, err := edb.RestoreEmployee(actx, created.ID); err != nil {
+			t.Fatalf("RestoreEmployee() error = %v", err)
+		}
+
+		page, err := edb.ListAuditEntries(ctx, AuditQuery{EmployeeID: created.ID, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListAuditEntries() error = %v", err)
+		}
+		var actions []string
+		for _, entry := range page.Entries {
+			actions = append(actions, entry.Action)
+			if entry.EmployeeID != created.ID || entry.At.IsZero() {
+				t.Errorf("ListAuditEntries() entry %+v, want employee %d and a time", entry, created.ID)
+			}
+		}
+		if want := []string{AuditCreate, AuditUpdate, AuditDelete, AuditRestore}; !slices.Equal(actions, want) || page.More {
+			t.Fatalf("ListAuditEntries() actions = %v, more %v, want %v", actions, page.More, want)
+		}
+		if first := page.Entries[0]; first.Actor != "jane" || first.RequestID != "req-1" || first.Changes["name"] != (Change{To: "John Doe"}) {
+			t.Errorf("create entry = %+v, want jane, req-1 and the new name", first)
+		}
+		if raise := page.Entries[1]; raise.Actor != "joe" || len(raise.Changes) != 1 || raise.Changes["salary"] != (Change{From: "50000.00", To: "55000.00"}) {
+			t.Errorf("update entry = %+v, want joe raising the salary", raise)
+		}
+		if deleted := page.Entries[2]; deleted.Changes["deleted_by"] != (Change{To: "jane"}) || deleted.Changes["deleted_at"].To == "" {
+			t.Errorf("delete entry = %+v, want deleted_at and deleted_by set", deleted)
+		}
+
+		for _, tt := range []struct {
+			q    AuditQuery
+			want []int64
+		}{
+			{q: AuditQuery{Field: "salary"}, want: []int64{1, 2, 3}},
+			{q: AuditQuery{Actor: "jane", Action: AuditDelete}, want: []int64{4}},
+			{q: AuditQuery{RequestID: "req-1", After: 1}, want: []int64{4, 5}},
+			{q: AuditQuery{EmployeeID: other.ID}, want: []int64{2}},
+			{q: AuditQuery{Since: page.Entries[0].At, Until: page.Entries[0].At.Add(-time.Second)}},
+		} {
+			tt.q.Limit = 10
+			got, err := edb.ListAuditEntries(ctx, tt.q)
+			var ids []int64
+			for _, entry := range got.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if err != nil || !slices.Equal(ids, tt.want) {
+				t.Errorf("ListAuditEntries(%+v) = %v, %v, want %v", tt.q, ids, err, tt.want)
+			}
+		}
+
+		first, _ := edb.ListAuditEntries(ctx, AuditQuery{Limit: 2})
+		if len(first.Entries) != 2 || !first.More {
+			t.Fatalf("ListAuditEntries() first page = %+v, want 2 entries and more", first)
+		}
+		rest, _ := edb.ListAuditEntries(ctx, AuditQuery{After: first.Entries[1].ID, Limit: 10})
+		if len(rest.Entries) != 3 || rest.More {
+			t.Errorf("ListAuditEntries() after %d = %+v, want the last 3 entries", first.Entries[1].ID, rest)
+		}
+	})
+
+	t.Run("purge is recorded in the audit log", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		if err := edb.DeleteEmployee(ctx, created.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		if _, err := edb.PurgeEmployees(WithActor(ctx, "purge"), time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("PurgeEmployees() error = %v", err)
+		}
+		page, err := edb.ListAuditEntries(ctx, AuditQuery{EmployeeID: created.ID, Action: AuditPurge, Limit: 10})
+		if err != nil || len(page.Entries) != 1 {
+			t.Fatalf("ListAuditEntries() = %+v, %v, want the purge", page, err)
+		}
+		if purge := page.Entries[0]; purge.Actor != "purge" || purge.Changes["salary"] != (Change{From: "50000.00"}) {
+			t.Errorf("purge entry = %+v, want the purge actor and the removed salary", purge)
+		}
+	})
+
+	t.Run("audit entries form a signed hash chain", func(t *testing.T) {
+		edb := newDB(t)
+		_, key, _ := ed25519.GenerateKey(nil)
+		if _, err := edb.CheckpointAudit(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CheckpointAudit() of an empty log error = %v, want %v", err, ErrNotFound)
+		}
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		salary := usd(55000)
+		edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Salary: &salary})
+		checkpoint, err := edb.CheckpointAudit(ctx, key)
+		if err != nil || checkpoint.EntryID != 2 {
+			t.Fatalf("CheckpointAudit() = %+v, %v, want a checkpoint of entry 2", checkpoint, err)
+		}
+		if _, err := edb.CheckpointAudit(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CheckpointAudit() without new entries error = %v, want %v", err, ErrNotFound)
+		}
+		edb.DeleteEmployee(ctx, created.ID, 0)
+
+		page, _ := edb.ListAuditEntries(ctx, AuditQuery{Limit: 10})
+		prev := ""
+		for _, entry := range page.Entries {
+			if entry.Hash != chainHash(prev, entry) {
+				t.Errorf("entry %d has hash %q, want it chained to %q", entry.ID, entry.Hash, prev)
+			}
+			prev = entry.Hash
+		}
+		v, err := VerifyAuditChain(ctx, edb, key.Public().(ed25519.PublicKey))
+		if want := (AuditVerification{Entries: 3, Checkpoints: 1, Unanchored: 1, Head: prev}); err != nil || v != want {
+			t.Errorf("VerifyAuditChain() = %+v, %v, want %+v", v, err, want)
+		}
+		other, _, _ := ed25519.GenerateKey(nil)
+		var broken *BrokenLinkError
+		if _, err := VerifyAuditChain(ctx, edb, other); !errors.As(err, &broken) || broken.EntryID != 2 {
+			t.Errorf("VerifyAuditChain() with another key error = %v, want a broken link at entry 2", err)
+		}
+	})
+
+	t.Run("salary history tracks effective-dated changes", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		day := today()
+		promotion := SalaryChange{EmployeeID: created.ID, Salary: usd(60000), EffectiveFrom: day.AddDate(0, 1, 0), Reason: SalaryPromotion}
+		added, err := edb.AddSalaryChange(WithActor(ctx, "jane"), promotion)
+		if err != nil || added.ID == 0 || added.CreatedBy != "jane" {
+			t.Fatalf("AddSalaryChange() = %+v, %v, want a change made by jane", added, err)
+		}
+		correction := SalaryChange{EmployeeID: created.ID, Salary: usd(45000), EffectiveFrom: day.AddDate(0, 0, -10), Reason: SalaryCorrection}
+		if _, err := edb.AddSalaryChange(ctx, correction); err != nil {
+			t.Fatalf("AddSalaryChange() past change error = %v", err)
+		}
+		if _, err := edb.AddSalaryChange(ctx, promotion); !errors.Is(err, ErrConflict) {
+			t.Errorf("AddSalaryChange() on the same day error = %v, want %v", err, ErrConflict)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); got.Salary != usd(50000) || got.Version != 1 {
+			t.Errorf("GetEmployeeByID() = %+v, want the hiring salary until the promotion", got)
+		}
+
+		raise := usd(52000)
+		if _, err := edb.PatchEmployee(ctx, created.ID, EmployeeChanges{Salary: &raise}); err != nil {
+			t.Fatalf("PatchEmployee() error = %v", err)
+		}
+		changes, err := edb.ListSalaryChanges(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("ListSalaryChanges() error = %v", err)
+		}
+		var got []string
+		for _, c := range changes {
+			got = append(got, fmt.Sprintf("%s %s %s", dateValue(c.EffectiveFrom), c.Salary, c.Reason))
+		}
+		want := []string{
+			dateValue(day.AddDate(0, 0, -10)) + " 45000.00 correction",
+			dateValue(day) + " 52000.00 adjustment",
+			dateValue(day.AddDate(0, 1, 0)) + " 60000.00 promotion",
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("ListSalaryChanges() = %q, want %q", got, want)
+		}
+
+		if applied, err := edb.ApplySalaryChanges(ctx, day); err != nil || applied != 0 {
+			t.Errorf("ApplySalaryChanges(today) = %d, %v, want nothing to apply", applied, err)
+		}
+		if applied, err := edb.ApplySalaryChanges(ctx, day.AddDate(0, 1, 0)); err != nil || applied != 1 {
+			t.Errorf("ApplySalaryChanges(next month) = %d, %v, want the promotion applied", applied, err)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); got.Salary != usd(52000) {
+			t.Errorf("GetEmployeeByID() salary = %v, want the raise until the promotion", got.Salary)
+		}
+		if got, _ := edb.GetEmployeeAsOf(ctx, created.ID, day.AddDate(0, 1, 0)); got.Salary != usd(60000) {
+			t.Errorf("GetEmployeeAsOf(next month) salary = %v, want the promotion", got.Salary)
+		}
+		page, _ := edb.ListAuditEntries(ctx, AuditQuery{EmployeeID: created.ID, Field: "salary", Limit: 10})
+		if n := len(page.Entries); n != 3 || page.Entries[n-1].Changes["salary"] != (Change{From: "52000.00", To: "60000.00"}) {
+			t.Errorf("ListAuditEntries() = %+v, want the promotion recorded last", page.Entries)
+		}
+
+		if _, err := edb.AddSalaryChange(ctx, SalaryChange{EmployeeID: created.ID, Salary: usd(1), EffectiveFrom: day, Reason: "bonus"}); !errors.Is(err, ErrConstraint) {
+			t.Errorf("AddSalaryChange() unknown reason error = %v, want %v", err, ErrConstraint)
+		}
+		if _, err := edb.AddSalaryChange(ctx, SalaryChange{EmployeeID: 999, Salary: usd(1), EffectiveFrom: day, Reason: SalaryMerit}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddSalaryChange() missing employee error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err := edb.ListSalaryChanges(ctx, 999); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ListSalaryChanges() missing employee error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("salary history checks the band", func(t *testing.T) {
+		edb := newDB(t)
+		jane, _ := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Engineer", Salary: usd(150)})
+		position, err := edb.CreatePosition(ctx, Position{Code: "ENG-1", Title: "Engineer", Bands: []SalaryBand{{Currency: "USD", Min: 10000, Mid: 15000, Max: 20000}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		john, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Salary: usd(150), PositionID: position.ID})
+		day := today()
+		offer := SalaryChange{EmployeeID: john.ID, Salary: Money{Amount: 999999, Currency: "USD"}, EffectiveFrom: day.AddDate(0, 0, 3), Reason: SalaryMerit}
+		if _, err := edb.AddSalaryChange(ctx, offer); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("AddSalaryChange() above the band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		yen := SalaryChange{EmployeeID: john.ID, Salary: Money{Amount: 15000, Currency: "JPY"}, EffectiveFrom: day, Reason: SalaryAdjustment}
+		if _, err := edb.AddSalaryChange(ctx, yen); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("AddSalaryChange() in a currency without a band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		if added, err := edb.AddSalaryChange(WithBandOverride(ctx, "Counter offer"), offer); err != nil || added.BandOverride != "Counter offer" {
+			t.Fatalf("AddSalaryChange() with an override = %+v, %v, want the reason kept", added, err)
+		}
+		raise := SalaryChange{EmployeeID: john.ID, Salary: usd(180), EffectiveFrom: day.AddDate(0, 0, 10), Reason: SalaryMerit}
+		if added, err := edb.AddSalaryChange(WithBandOverride(ctx, "unused"), raise); err != nil || added.BandOverride != "" {
+			t.Fatalf("AddSalaryChange() within the band = %+v, %v, want no reason kept", added, err)
+		}
+		if _, err := edb.AddSalaryChange(ctx, SalaryChange{EmployeeID: jane.ID, Salary: usd(200), EffectiveFrom: day.AddDate(0, 0, 10), Reason: SalaryMerit}); err != nil {
+			t.Fatal(err)
+		}
+
+		if applied, err := edb.ApplySalaryChanges(ctx, day.AddDate(0, 0, 5)); err != nil || applied != 1 {
+			t.Errorf("ApplySalaryChanges() = %d, %v, want the offer applied", applied, err)
+		}
+		page, _ := edb.ListAuditEntries(ctx, AuditQuery{EmployeeID: john.ID, Field: "band_override", Limit: 10})
+		if len(page.Entries) != 1 || page.Entries[0].Changes["band_override"].To != "Counter offer" || page.Entries[0].Changes["salary"].To != "9999.99" {
+			t.Errorf("ListAuditEntries() overrides = %+v, want the offer with its reason", page.Entries)
+		}
+
+		// A band that changed since is checked when the change takes effect.
+		position.Bands[0].Max = 17000
+		if _, err := edb.UpdatePosition(ctx, position); err != nil {
+			t.Fatal(err)
+		}
+		if applied, err := edb.ApplySalaryChanges(ctx, day.AddDate(0, 0, 10)); !errors.Is(err, ErrSalaryOutOfBand) || applied != 1 {
+			t.Errorf("ApplySalaryChanges() = %d, %v, want the raise of Jane only and %v", applied, err, ErrSalaryOutOfBand)
+		}
+		if got, _ := edb.GetEmployeeAsOf(ctx, john.ID, day.AddDate(0, 0, 10)); got.Salary.Amount != 999999 {
+			t.Errorf("GetEmployeeAsOf() salary = %v, want the offer kept", got.Salary)
+		}
+		if got, _ := edb.GetEmployeeAsOf(ctx, jane.ID, day.AddDate(0, 0, 10)); got.Salary != usd(200) {
+			t.Errorf("GetEmployeeAsOf() salary = %v, want the raise", got.Salary)
+		}
+	})
+
+	t.Run("reads show the salary in effect before it is applied", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		tomorrow := today().AddDate(0, 0, 1)
+		raise := SalaryChange{EmployeeID: created.ID, Salary: usd(60000), EffectiveFrom: tomorrow, Reason: SalaryMerit}
+		if _, err := edb.AddSalaryChange(ctx, raise); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, created.ID); got.Salary != usd(50000) {
+			t.Errorf("GetEmployeeByID() salary = %v, want the hiring salary until the raise", got.Salary)
+		}
+		// Nothing applied the raise, which shows from the day it takes effect
+		// all the same.
+		at := tomorrow.Add(time.Hour)
+		if got, err := edb.GetEmployeeAsOf(ctx, created.ID, at); err != nil || got.Salary != usd(60000) || got.Version != 1 {
+			t.Errorf("GetEmployeeAsOf(tomorrow) = %+v, %v, want the raise", got, err)
+		}
+		floor := int64(5500000)
+		page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, AsOf: at, Currency: "USD", MinSalary: &floor})
+		if err != nil || len(page.Employees) != 1 || page.Employees[0].Salary != usd(60000) {
+			t.Errorf("ListEmployees(tomorrow, above 55000) = %+v, %v, want the raise", page, err)
+		}
+		if page, _ := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, Currency: "USD", MinSalary: &floor}); len(page.Employees) != 0 {
+			t.Errorf("ListEmployees(above 55000) = %+v, want none until the raise", page.Employees)
+		}
+	})
+
+	t.Run("departments group employees", func(t *testing.T) {
+		edb := newDB(t)
+		engineering, err := edb.CreateDepartment(ctx, Department{Name: "Engineering"})
+		if err != nil || engineering.ID == 0 {
+			t.Fatalf("CreateDepartment() = %+v, %v", engineering, err)
+		}
+		sales, _ := edb.CreateDepartment(ctx, Department{Name: "Sales"})
+		if _, err := edb.CreateDepartment(ctx, Department{Name: "Sales"}); !errors.Is(err, ErrConflict) {
+			t.Errorf("CreateDepartment() duplicate name error = %v, want %v", err, ErrConflict)
+		}
+		if _, err := edb.UpdateDepartment(ctx, Department{ID: engineering.ID, Name: "Sales"}); !errors.Is(err, ErrConflict) {
+			t.Errorf("UpdateDepartment() duplicate name error = %v, want %v", err, ErrConflict)
+		}
+		if _, err := edb.UpdateDepartment(ctx, Department{ID: sales.ID, Name: "Account Management"}); err != nil {
+			t.Errorf("UpdateDepartment() error = %v", err)
+		}
+		if _, err := edb.UpdateDepartment(ctx, Department{ID: 999, Name: "Legal"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateDepartment() missing department error = %v, want %v", err, ErrNotFound)
+		}
+		departments, err := edb.ListDepartments(ctx)
+		if err != nil || len(departments) != 2 || departments[0].Name != "Account Management" || departments[1] != engineering {
+			t.Errorf("ListDepartments() = %+v, %v, want the departments by name", departments, err)
+		}
+
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000), DepartmentID: 999}); !errors.Is(err, ErrUnknownDepartment) {
+			t.Errorf("CreateEmployee() unknown department error = %v, want %v", err, ErrUnknownDepartment)
+		}
+		john, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000), DepartmentID: engineering.ID})
+		if err != nil || john.DepartmentID != engineering.ID {
+			t.Fatalf("CreateEmployee() = %+v, %v, want an engineer", john, err)
+		}
+		edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Account Manager", Salary: usd(60000), DepartmentID: sales.ID})
+		page, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, DepartmentID: engineering.ID})
+		if err != nil || page.Total != 1 || page.Employees[0].ID != john.ID {
+			t.Errorf("ListEmployees() by department = %+v, %v, want John Doe", page, err)
+		}
+
+		if err := edb.DeleteEmployee(ctx, john.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := edb.DeleteDepartment(ctx, engineering.ID); !errors.Is(err, ErrDepartmentInUse) {
+			t.Errorf("DeleteDepartment() with a deleted employee error = %v, want %v", err, ErrDepartmentInUse)
+		}
+		if _, err := edb.PurgeEmployees(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := edb.DeleteDepartment(ctx, engineering.ID); err != nil {
+			t.Errorf("DeleteDepartment() error = %v", err)
+		}
+		if _, err := edb.GetDepartment(ctx, engineering.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetDepartment() deleted department error = %v, want %v", err, ErrNotFound)
+		}
+		if err := edb.DeleteDepartment(ctx, engineering.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteDepartment() twice error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("managers form a hierarchy without cycles", func(t *testing.T) {
+		edb := newDB(t)
+		hire := func(name string, managerID int) Employee {
+			t.Helper()
+			emp, err := edb.CreateEmployee(ctx, Employee{Name: name, Position: "Engineer", Salary: usd(50000), ManagerID: managerID})
+			if err != nil {
+				t.Fatalf("CreateEmployee(%s) error = %v", name, err)
+			}
+			return emp
+		}
+		ceo := hire("Ada", 0)
+		cto := hire("Grace", ceo.ID)
+		lead := hire("Linus", cto.ID)
+		dev := hire("Ken", lead.ID)
+		cfo := hire("Mary", ceo.ID)
+
+		ids := func(employees []Employee) []int {
+			var ids []int
+			for _, e := range employees {
+				ids = append(ids, e.ID)
+			}
+			return ids
+		}
+		if reports, err := edb.ListDirectReports(ctx, ceo.ID); err != nil || !slices.Equal(ids(reports), []int{cto.ID, cfo.ID}) {
+			t.Errorf("ListDirectReports() = %v, %v, want the CTO and CFO", ids(reports), err)
+		}
+		page, err := edb.ListEmployees(ctx, ListQuery{PerPage: 10, ManagerIDs: []int{ceo.ID, lead.ID}})
+		if err != nil || !slices.Equal(employeeIDs(page), []int{cto.ID, dev.ID, cfo.ID}) {
+			t.Errorf("ListEmployees(ManagerIDs) = %v, %v, want the CTO, developer and CFO", employeeIDs(page), err)
+		}
+		if chain, err := edb.ListReportingChain(ctx, dev.ID); err != nil || !slices.Equal(ids(chain), []int{lead.ID, cto.ID, ceo.ID}) {
+			t.Errorf("ListReportingChain() = %v, %v, want the lead, CTO and CEO", ids(chain), err)
+		}
+		if chain, err := edb.ListReportingChain(ctx, ceo.ID); err != nil || len(chain) != 0 {
+			t.Errorf("ListReportingChain() of the CEO = %v, %v, want none", ids(chain), err)
+		}
+		subtree, err := edb.ListReports(ctx, ceo.ID)
+		var got []string
+		for _, r := range subtree {
+			got = append(got, fmt.Sprintf("%s %d", r.Name, r.Depth))
+		}
+		if want := []string{"Grace 1", "Mary 1", "Linus 2", "Ken 3"}; err != nil || !slices.Equal(got, want) {
+			t.Errorf("ListReports() = %q, %v, want %q", got, err, want)
+		}
+		if _, err := edb.ListReports(ctx, 999); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ListReports() missing employee error = %v, want %v", err, ErrNotFound)
+		}
+
+		for name, managerID := range map[string]int{"itself": ceo.ID, "a report": lead.ID, "a report of a report": dev.ID} {
+			if _, err := edb.PatchEmployee(ctx, ceo.ID, EmployeeChanges{ManagerID: &managerID}); !errors.Is(err, ErrReportingCycle) {
+				t.Errorf("PatchEmployee() managed by %s error = %v, want %v", name, err, ErrReportingCycle)
+			}
+		}
+		cto.ManagerID = dev.ID
+		cto.Version = 0
+		if _, err := edb.UpdateEmployee(ctx, cto); !errors.Is(err, ErrReportingCycle) {
+			t.Errorf("UpdateEmployee() managed by a report error = %v, want %v", err, ErrReportingCycle)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, cto.ID); got.ManagerID != ceo.ID || got.Version != 1 {
+			t.Errorf("GetEmployeeByID() = %+v, want the refused change rolled back", got)
+		}
+		missing := 999
+		if _, err := edb.PatchEmployee(ctx, dev.ID, EmployeeChanges{ManagerID: &missing}); !errors.Is(err, ErrUnknownManager) {
+			t.Errorf("PatchEmployee() missing manager error = %v, want %v", err, ErrUnknownManager)
+		}
+
+		if err := edb.DeleteEmployee(ctx, lead.ID, 0); !errors.Is(err, ErrHasReports) {
+			t.Errorf("DeleteEmployee() with reports error = %v, want %v", err, ErrHasReports)
+		}
+		if _, err := edb.PatchEmployee(ctx, dev.ID, EmployeeChanges{ManagerID: &cto.ID}); err != nil {
+			t.Fatalf("PatchEmployee() moving the developer error = %v", err)
+		}
+		if err := edb.DeleteEmployee(ctx, lead.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() without reports error = %v", err)
+		}
+		if _, err := edb.PatchEmployee(ctx, dev.ID, EmployeeChanges{ManagerID: &lead.ID}); !errors.Is(err, ErrUnknownManager) {
+			t.Errorf("PatchEmployee() deleted manager error = %v, want %v", err, ErrUnknownManager)
+		}
+		none := 0
+		if got, err := edb.PatchEmployee(ctx, dev.ID, EmployeeChanges{ManagerID: &none}); err != nil || got.ManagerID != 0 {
+			t.Errorf("PatchEmployee() clearing the manager = %+v, %v", got, err)
+		}
+	})
+
+	t.Run("positions bound salaries", func(t *testing.T) {
+		edb := newDB(t)
+		band := SalaryBand{Currency: "USD", Min: 9000000, Mid: 11000000, Max: 13000000}
+		if _, err := edb.CreatePosition(ctx, Position{Code: "ENG-2", Title: "Engineer", Bands: []SalaryBand{band, band}}); !errors.Is(err, ErrConstraint) {
+			t.Errorf("CreatePosition() repeated band error = %v, want %v", err, ErrConstraint)
+		}
+		senior, err := edb.CreatePosition(ctx, Position{Code: "ENG-3", Title: "Senior Engineer", Level: 3, Bands: []SalaryBand{band}})
+		if err != nil || senior.ID == 0 {
+			t.Fatalf("CreatePosition() = %+v, %v", senior, err)
+		}
+		junior, _ := edb.CreatePosition(ctx, Position{Code: "ENG-1", Title: "Junior Engineer", Level: 1})
+		if _, err := edb.CreatePosition(ctx, Position{Code: "ENG-3", Title: "Staff Engineer"}); !errors.Is(err, ErrConflict) {
+			t.Errorf("CreatePosition() duplicate code error = %v, want %v", err, ErrConflict)
+		}
+		positions, err := edb.ListPositions(ctx)
+		if err != nil || len(positions) != 2 || positions[0].ID != senior.ID || len(positions[0].Bands) != 1 || positions[0].Bands[0] != band {
+			t.Errorf("ListPositions() = %+v, %v, want the senior position and its band first", positions, err)
+		}
+
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Salary: usd(100000), PositionID: 999}); !errors.Is(err, ErrUnknownPosition) {
+			t.Errorf("CreateEmployee() unknown position error = %v, want %v", err, ErrUnknownPosition)
+		}
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Salary: usd(150000), PositionID: senior.ID}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("CreateEmployee() above the band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		john, err := edb.CreateEmployee(WithBandOverride(ctx, "unused"), Employee{Name: "John Doe", Position: "Coder", Salary: usd(100000), PositionID: senior.ID})
+		if err != nil || john.Position != "Senior Engineer" {
+			t.Fatalf("CreateEmployee() = %+v, %v, want the title of the position", john, err)
+		}
+		// A currency the position has no band in needs an override too.
+		yen := Money{Amount: 100, Currency: "JPY"}
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Salary: yen, PositionID: senior.ID}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("CreateEmployee() in a currency without a band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		if _, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{Salary: &yen}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("PatchEmployee() to a currency without a band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		if _, err := edb.CreateEmployee(WithBandOverride(ctx, "Tokyo office"), Employee{Name: "Jane Doe", Salary: yen, PositionID: senior.ID}); err != nil {
+			t.Errorf("CreateEmployee() in a currency without a band with an override error = %v", err)
+		}
+
+		raise := usd(150000)
+		if _, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{Salary: &raise}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("PatchEmployee() above the band error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		if _, err := edb.PatchEmployee(WithBandOverride(ctx, "Retention offer"), john.ID, EmployeeChanges{Salary: &raise}); err != nil {
+			t.Fatalf("PatchEmployee() with an override error = %v", err)
+		}
+		name := "John Smith"
+		if _, err := edb.PatchEmployee(WithBandOverride(ctx, "unused"), john.ID, EmployeeChanges{Name: &name}); err != nil {
+			t.Fatalf("PatchEmployee() keeping the salary error = %v", err)
+		}
+		page, _ := edb.ListAuditEntries(ctx, AuditQuery{EmployeeID: john.ID, Field: "band_override", Limit: 10})
+		if len(page.Entries) != 1 || page.Entries[0].Changes["band_override"].To != "Retention offer" || page.Entries[0].Changes["salary"].To != "150000.00" {
+			t.Errorf("ListAuditEntries() overrides = %+v, want the raise only", page.Entries)
+		}
+
+		// A new position checks the salary again, whatever the old one allowed.
+		if _, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{PositionID: &junior.ID}); err != nil {
+			t.Fatalf("PatchEmployee() to a position without bands error = %v", err)
+		}
+		if _, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{PositionID: &senior.ID}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("PatchEmployee() back to the senior position error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+
+		junior.Title = "Associate Engineer"
+		if _, err := edb.UpdatePosition(ctx, junior); err != nil {
+			t.Fatalf("UpdatePosition() error = %v", err)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, john.ID); got.Position != "Associate Engineer" || got.PositionID != junior.ID {
+			t.Errorf("GetEmployeeByID() after renaming the position = %+v, want the new title", got)
+		}
+		if _, err := edb.UpdatePosition(ctx, Position{ID: 999, Code: "X", Title: "X"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdatePosition() missing position error = %v, want %v", err, ErrNotFound)
+		}
+		if got, err := edb.GetPosition(ctx, senior.ID); err != nil || len(got.Bands) != 1 || got.Bands[0] != band {
+			t.Errorf("GetPosition() = %+v, %v, want the senior position and its band", got, err)
+		}
+
+		if err := edb.DeletePosition(ctx, junior.ID); !errors.Is(err, ErrPositionInUse) {
+			t.Errorf("DeletePosition() in use error = %v, want %v", err, ErrPositionInUse)
+		}
+		none := 0
+		if _, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{PositionID: &none}); err != nil {
+			t.Fatal(err)
+		}
+		if err := edb.DeletePosition(ctx, junior.ID); err != nil {
+			t.Errorf("DeletePosition() error = %v", err)
+		}
+		if _, err := edb.GetPosition(ctx, junior.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetPosition() deleted position error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("positions given by title are linked to the catalog", func(t *testing.T) {
+		edb := newDB(t)
+		legacy, err := edb.CreateEmployee(ctx, Employee{Name: "Ada", Position: "CTO", Salary: usd(150000)})
+		if err != nil || legacy.PositionID != 0 {
+			t.Fatalf("CreateEmployee() without a catalog = %+v, %v, want a free-text position", legacy, err)
+		}
+		senior, _ := edb.CreatePosition(ctx, Position{Code: "ENG-3", Title: "Senior Engineer", Level: 3,
+			Bands: []SalaryBand{{Currency: "USD", Min: 9000000, Mid: 11000000, Max: 13000000}}})
+		edb.CreatePosition(ctx, Position{Code: "MGR-1", Title: "Manager", Level: 4})
+		edb.CreatePosition(ctx, Position{Code: "MGR-2", Title: "manager", Level: 5})
+
+		john, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "senior ENGINEER", Salary: usd(100000)})
+		if err != nil || john.PositionID != senior.ID || john.Position != "Senior Engineer" {
+			t.Fatalf("CreateEmployee() = %+v, %v, want the senior position", john, err)
+		}
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Senior Engineer", Salary: usd(200000)}); !errors.Is(err, ErrSalaryOutOfBand) {
+			t.Errorf("CreateEmployee() above the band of the title error = %v, want %v", err, ErrSalaryOutOfBand)
+		}
+		for _, title := range []string{"Engineer", "MANAGER"} {
+			if _, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: title, Salary: usd(100000)}); !errors.Is(err, ErrUnmatchedPosition) {
+				t.Errorf("CreateEmployee() titled %q error = %v, want %v", title, err, ErrUnmatchedPosition)
+			}
+		}
+
+		// Free-text positions from before the catalog are kept as they are.
+		legacy.Salary = usd(120000)
+		if _, err := edb.UpdateEmployee(ctx, legacy); err != nil {
+			t.Errorf("UpdateEmployee() keeping the free-text position error = %v", err)
+		}
+		title := "Chief Technology Officer"
+		if _, err := edb.PatchEmployee(ctx, legacy.ID, EmployeeChanges{Position: &title}); !errors.Is(err, ErrUnmatchedPosition) {
+			t.Errorf("PatchEmployee() to an unknown title error = %v, want %v", err, ErrUnmatchedPosition)
+		}
+		title = "senior engineer"
+		if got, err := edb.PatchEmployee(ctx, legacy.ID, EmployeeChanges{Position: &title}); err != nil || got.PositionID != senior.ID {
+			t.Errorf("PatchEmployee() to a catalog title = %+v, %v, want the senior position", got, err)
+		}
+
+		// Removing the link keeps the title as free text.
+		none := 0
+		if got, err := edb.PatchEmployee(ctx, john.ID, EmployeeChanges{PositionID: &none}); err != nil || got.PositionID != 0 || got.Position != "Senior Engineer" {
+			t.Errorf("PatchEmployee() without a position ID = %+v, %v, want the title kept", got, err)
+		}
+	})
+
+	t.Run("imports are all or nothing", func(t *testing.T) {
+		edb := newDB(t)
+		engineering, _ := edb.CreateDepartment(ctx, Department{Name: "Engineering"})
+		existing, _ := edb.CreateEmployee(ctx, Employee{Name: "Ada", Position: "CTO", Salary: usd(150000)})
+		senior, _ := edb.CreatePosition(ctx, Position{Code: "ENG-3", Title: "Senior Engineer", Level: 3,
+			Bands: []SalaryBand{{Currency: "USD", Min: 9000000, Mid: 11000000, Max: 13000000}}})
+		rows := []ImportRow{
+			{Employee: Employee{Name: "Grace", Position: "senior engineer", Salary: usd(100000), DepartmentID: engineering.ID, ManagerID: existing.ID}},
+			{Employee: Employee{Name: "Linus", Salary: usd(150000), PositionID: senior.ID}},
+			{Employee: Employee{Name: "Mary", Position: "Senior Engineer", Salary: usd(100000), DepartmentID: 999}},
+			{Employee: Employee{Name: "Ken", Salary: usd(100000), PositionID: 999}},
+		}
+		var importErr *ImportError
+		_, err := edb.ImportEmployees(ctx, rows, true)
+		if !errors.As(err, &importErr) || len(importErr.Rows) != 3 || importErr.Rows[0].Index != 1 ||
+			!errors.Is(importErr.Rows[0].Err, ErrSalaryOutOfBand) || !errors.Is(importErr.Rows[1].Err, ErrUnknownDepartment) || !errors.Is(importErr.Rows[2].Err, ErrUnknownPosition) {
+			t.Fatalf("ImportEmployees() error = %v, want rows 1, 2 and 3 refused", err)
+		}
+		if _, err := edb.ImportEmployees(ctx, rows, false); !errors.Is(err, ErrConstraint) {
+			t.Errorf("ImportEmployees() error = %v, want %v", err, ErrConstraint)
+		}
+		if page, _ := edb.ListEmployees(ctx, ListQuery{PerPage: 10}); page.Total != 1 {
+			t.Errorf("ListEmployees() after a refused import = %d employees, want 1", page.Total)
+		}
+
+		rows[1].BandOverride = "Competing offer"
+		rows = rows[:2]
+		checked, err := edb.ImportEmployees(ctx, rows, true)
+		if err != nil || len(checked) != 2 || checked[1].ID != 0 || checked[1].Position != "Senior Engineer" || checked[0].PositionID != senior.ID {
+			t.Fatalf("ImportEmployees() dry run = %+v, %v, want both rows checked", checked, err)
+		}
+		imported, err := edb.ImportEmployees(ctx, rows, false)
+		if err != nil || len(imported) != 2 || imported[0].ID <= existing.ID || imported[1].ID <= imported[0].ID || imported[1].Version != 1 {
+			t.Fatalf("ImportEmployees() = %+v, %v, want two new employees", imported, err)
+		}
+		if got, err := edb.GetEmployeeByID(ctx, imported[1].ID); err != nil || got != imported[1] {
+			t.Errorf("GetEmployeeByID() = %+v, %v, want %+v", got, err, imported[1])
+		}
+		if reports, _ := edb.ListDirectReports(ctx, existing.ID); len(reports) != 1 || reports[0].ID != imported[0].ID {
+			t.Errorf("ListDirectReports() = %+v, want the imported report", reports)
+		}
+		if changes, err := edb.ListSalaryChanges(ctx, imported[0].ID); err != nil || len(changes) != 1 || changes[0].Reason != SalaryHire {
+			t.Errorf("ListSalaryChanges() = %+v, %v, want the hire", changes, err)
+		}
+		if _, err := edb.GetEmployeeAsOf(ctx, imported[0].ID, time.Now().Add(time.Minute)); err != nil {
+			t.Errorf("GetEmployeeAsOf() error = %v, want the first version", err)
+		}
+		page, _ := edb.ListAuditEntries(ctx, AuditQuery{Field: "band_override", Limit: 10})
+		if len(page.Entries) != 1 || page.Entries[0].EmployeeID != imported[1].ID || page.Entries[0].Action != AuditCreate {
+			t.Errorf("ListAuditEntries() overrides = %+v, want the override of the second import", page.Entries)
+		}
+		if v, err := VerifyAuditChain(ctx, edb, nil); err != nil || v.Entries != 3 {
+			t.Errorf("VerifyAuditChain() = %+v, %v, want 3 chained entries", v, err)
+		}
+	})
+
+	t.Run("external ids and user names are unique", func(t *testing.T) {
+		edb := newDB(t)
+		ada, err := edb.CreateEmployee(ctx, Employee{Name: "Ada Lovelace", Position: "Engineer", Salary: usd(50000), ExternalID: "idp-1", UserName: "ada@example.com"})
+		if err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		if got, _ := edb.GetEmployeeByID(ctx, ada.ID); got.ExternalID != "idp-1" || got.UserName != "ada@example.com" {
+			t.Errorf("GetEmployeeByID() = %+v, want the external ID and user name", got)
+		}
+		for _, employee := range []Employee{
+			{Name: "Ada", Position: "Engineer", Salary: usd(1), ExternalID: "idp-1"},
+			{Name: "Ada", Position: "Engineer", Salary: usd(1), UserName: "ADA@example.com"},
+		} {
+			if _, err := edb.CreateEmployee(ctx, employee); !errors.Is(err, ErrConflict) {
+				t.Errorf("CreateEmployee(%+v) error = %v, want %v", employee, err, ErrConflict)
+			}
+		}
+		// Employees without either do not conflict.
+		grace, err := edb.CreateEmployee(ctx, Employee{Name: "Grace Hopper", Position: "Engineer", Salary: usd(50000)})
+		if err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "Alan Turing", Position: "Engineer", Salary: usd(50000)}); err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		taken := "idp-1"
+		if _, err := edb.PatchEmployee(ctx, grace.ID, EmployeeChanges{ExternalID: &taken}); !errors.Is(err, ErrConflict) {
+			t.Errorf("PatchEmployee() error = %v, want %v", err, ErrConflict)
+		}
+		// Deleted employees keep theirs.
+		if err := edb.DeleteEmployee(ctx, ada.ID, 0); err != nil {
+			t.Fatalf("DeleteEmployee() error = %v", err)
+		}
+		userName := "Ada@Example.com"
+		if _, err := edb.PatchEmployee(ctx, grace.ID, EmployeeChanges{UserName: &userName}); !errors.Is(err, ErrConflict) {
+			t.Errorf("PatchEmployee() error = %v, want %v", err, ErrConflict)
+		}
+
+		userName = "grace@example.com"
+		grace, err = edb.PatchEmployee(ctx, grace.ID, EmployeeChanges{UserName: &userName})
+		if err != nil {
+			t.Fatalf("PatchEmployee() error = %v", err)
+		}
+		grace.Position = "Admiral"
+		if grace, err = edb.UpdateEmployee(ctx, grace); err != nil || grace.UserName != userName {
+			t.Errorf("UpdateEmployee() = %+v, %v, want the user name kept", grace, err)
+		}
+		page, err := edb.ListEmployees(ctx, ListQuery{PerPage: 10, UserName: "GRACE@example.com"})
+		if err != nil || fmt.Sprint(employeeIDs(page)) != fmt.Sprint([]int{grace.ID}) {
+			t.Errorf("ListEmployees(UserName) = %v, %v, want [%d]", employeeIDs(page), err, grace.ID)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{PerPage: 10, ExternalID: "idp-1", IncludeDeleted: true})
+		if err != nil || fmt.Sprint(employeeIDs(page)) != fmt.Sprint([]int{ada.ID}) {
+			t.Errorf("ListEmployees(ExternalID) = %v, %v, want [%d]", employeeIDs(page), err, ada.ID)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{PerPage: 10, ID: ada.ID, IncludeDeleted: true})
+		if err != nil || fmt.Sprint(employeeIDs(page)) != fmt.Sprint([]int{ada.ID}) {
+			t.Errorf("ListEmployees(ID) = %v, %v, want [%d]", employeeIDs(page), err, ada.ID)
+		}
+		page, err = edb.ListEmployees(ctx, ListQuery{PerPage: 10, HasUserName: true, IncludeDeleted: true})
+		if err != nil || fmt.Sprint(employeeIDs(page)) != fmt.Sprint([]int{ada.ID, grace.ID}) {
+			t.Errorf("ListEmployees(HasUserName) = %v, %v, want [%d %d]", employeeIDs(page), err, ada.ID, grace.ID)
+		}
+	})
+
+	t.Run("export streams every matching employee", func(t *testing.T) {
+		edb := newDB(t)
+		john, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		jane, _ := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Engineer", Salary: usd(70000)})
+		jim, _ := edb.CreateEmployee(ctx, Employee{Name: "Jim Doe", Position: "Manager", Salary: usd(60000)})
+		edb.CreateEmployee(ctx, Employee{Name: "Jill Doe", Position: "Engineer", Salary: usd(80000)})
+		edb.DeleteEmployee(ctx, 4, 0)
+
+		export := func(q ListQuery) ([]int, error) {
+			var ids []int
+			err := edb.ExportEmployees(ctx, q, func(employee Employee) error {
+				ids = append(ids, employee.ID)
+				return nil
+			})
+			return ids, err
+		}
+		// Paging fields are ignored.
+		sort := []Sort{{Field: "salary", Desc: true}}
+		if got, err := export(ListQuery{Page: 2, PerPage: 1, After: &john, Sort: sort}); err != nil || !slices.Equal(got, []int{jane.ID, jim.ID, john.ID}) {
+			t.Errorf("ExportEmployees() = %v, %v, want every employee by descending salary", got, err)
+		}
+		if got, err := export(ListQuery{Position: "engineer", IncludeDeleted: true}); err != nil || !slices.Equal(got, []int{john.ID, jane.ID, 4}) {
+			t.Errorf("ExportEmployees() of engineers = %v, %v, want the engineers, deleted or not", got, err)
+		}
+		if _, err := export(ListQuery{MinSalary: new(int64)}); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ExportEmployees() invalid query error = %v, want %v", err, ErrInvalidQuery)
+		}
+		stop := errors.New("stop")
+		calls := 0
+		err := edb.ExportEmployees(ctx, ListQuery{}, func(Employee) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("ExportEmployees() = %v after %d calls, want the error of the first call", err, calls)
+		}
+		// The store is usable once an export stops.
+		if _, err := edb.GetEmployeeByID(ctx, john.ID); err != nil {
+			t.Errorf("GetEmployeeByID() after an export error = %v", err)
+		}
+	})
+
+	t.Run("concurrent creates get unique ids", func(t *testing.T) {
+		edb := newDB(t)
+		var wg sync.WaitGroup
+		ids := make([]int, 20)
+		for i := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				emp, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+				if err != nil {
+					t.Errorf("CreateEmployee() error = %v", err)
+				}
+				ids[i] = emp.ID
+			}()
+		}
+		wg.Wait()
+		slices.Sort(ids)
+		if got := slices.Compact(ids); len(got) != len(ids) {
+			t.Errorf("CreateEmployee() returned duplicate ids: %v", ids)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		edb := newDB(t)
+		created, _ := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)})
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := edb.GetEmployeeByID(cancelled, created.ID); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetEmployeeByID() error = %v, want %v", err, context.Canceled)
+		}
+		if _, err := edb.CreateEmployee(cancelled, created); !errors.Is(err, context.Canceled) {
+			t.Errorf("CreateEmployee() error = %v, want %v", err, context.Canceled)
+		}
+		if _, err := edb.ListEmployees(cancelled, ListQuery{Page: 1, PerPage: 10}); !errors.Is(err, context.Canceled) {
+			t.Errorf("ListEmployees() error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("list paginates by id", func(t *testing.T) {
+		edb := newDB(t)
+		for _, name := range []string{"A", "B", "C", "D", "E"} {
+			if _, err := edb.CreateEmployee(ctx, Employee{Name: name, Position: "Engineer", Salary: usd(50000)}); err != nil {
+				t.Fatalf("CreateEmployee() error = %v", err)
+			}
+		}
+		tests := []struct {
+			page, perPage, offset int
+			wantIDs               []int
+			wantMore              bool
+		}{
+			{page: 1, perPage: 2, wantIDs: []int{1, 2}, wantMore: true},
+			{page: 3, perPage: 2, wantIDs: []int{5}, wantMore: false},
+			{page: 1, perPage: 5, wantIDs: []int{1, 2, 3, 4, 5}, wantMore: false},
+			{page: 4, perPage: 2, wantIDs: nil, wantMore: false},
+			{page: 1, perPage: 2, offset: 1, wantIDs: []int{2, 3}, wantMore: true},
+			{page: 2, perPage: 2, offset: 1, wantIDs: []int{4, 5}, wantMore: false},
+		}
+		for _, tt := range tests {
+			page, err := edb.ListEmployees(ctx, ListQuery{Page: tt.page, PerPage: tt.perPage, Offset: tt.offset})
+			if err != nil {
+				t.Fatalf("ListEmployees(%d, %d, %d) error = %v", tt.page, tt.perPage, tt.offset, err)
+			}
+			if ids := employeeIDs(page); page.Total != 5 || page.More != tt.wantMore || !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ListEmployees(%d, %d, %d) = %v, total %d, more %v, want %v, total 5, more %v", tt.page, tt.perPage, tt.offset, ids, page.Total, page.More, tt.wantIDs, tt.wantMore)
+			}
+		}
+		if page, _ := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 2, SkipTotal: true}); page.Total != 0 {
+			t.Errorf("ListEmployees() with SkipTotal total = %d, want 0", page.Total)
+		}
+	})
+
+	t.Run("list pages by keyset", func(t *testing.T) {
+		edb := newDB(t)
+		for _, salary := range []int64{50000, 70000, 60000, 70000, 40000} {
+			if _, err := edb.CreateEmployee(ctx, Employee{Name: "John Doe", Position: "Engineer", Salary: usd(salary)}); err != nil {
+				t.Fatalf("CreateEmployee() error = %v", err)
+			}
+		}
+		sort := []Sort{{Field: "salary", Desc: true}}
+		list := func(q ListQuery) EmployeePage {
+			t.Helper()
+			q.PerPage, q.Sort = 2, sort
+			page, err := edb.ListEmployees(ctx, q)
+			if err != nil {
+				t.Fatalf("ListEmployees() error = %v", err)
+			}
+			return page
+		}
+		check := func(step string, page EmployeePage, wantIDs []int, wantMore bool, wantTotal int) {
+			t.Helper()
+			if ids := employeeIDs(page); page.Total != wantTotal || page.More != wantMore || !slices.Equal(ids, wantIDs) {
+				t.Errorf("%s: ListEmployees() = %v, total %d, more %v, want %v, total %d, more %v", step, ids, page.Total, page.More, wantIDs, wantTotal, wantMore)
+			}
+		}
+
+		// By salary descending, then id: 2, 4, 3, 1, 5.
+		first := list(ListQuery{Page: 1})
+		check("first page", first, []int{2, 4}, true, 5)
+		second := list(ListQuery{After: &first.Employees[1]})
+		check("second page", second, []int{3, 1}, true, 5)
+
+		// A new top earner lands before the cursor and does not shift the next page.
+		if _, err := edb.CreateEmployee(ctx, Employee{Name: "Jane Doe", Position: "Manager", Salary: usd(90000)}); err != nil {
+			t.Fatalf("CreateEmployee() error = %v", err)
+		}
+		third := list(ListQuery{After: &second.Employees[1], SkipTotal: true})
+		check("third page", third, []int{5}, false, 0)
+
+		back := list(ListQuery{Before: &third.Employees[0]})
+		check("back from third page", back, []int{3, 1}, true, 6)
+		start := list(ListQuery{Before: &back.Employees[0]})
+		check("back to the start", start, []int{2, 4}, true, 6)
+		top := list(ListQuery{Before: &start.Employees[0]})
+		check("before the start", top, []int{6}, false, 6)
+
+		if _, err := edb.ListEmployees(ctx, ListQuery{PerPage: 2, After: &first.Employees[0], Before: &first.Employees[1]}); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ListEmployees() after and before error = %v, want %v", err, ErrInvalidQuery)
+		}
+	})
+
+	t.Run("list filters and sorts", func(t *testing.T) {
+		edb := newDB(t)
+		for _, emp := range []Employee{
+			{Name: "Alice Smith", Position: "Engineer", Salary: usd(90000)},
+			{Name: "Bob Jones", Position: "Manager", Salary: usd(120000)},
+			{Name: "alan Turing", Position: "Engineer", Salary: usd(150000)},
+			{Name: "Carol 100% Sure", Position: "Senior Engineer", Salary: usd(110000)},
+			{Name: "Dai Tanaka", Position: "Engineer", Salary: Money{Amount: 9000000, Currency: "JPY"}},
+		} {
+			if _, err := edb.CreateEmployee(ctx, emp); err != nil {
+				t.Fatalf("CreateEmployee() error = %v", err)
+			}
+		}
+		minSalary, maxSalary := int64(10000000), int64(14000000)
+		tests := []struct {
+			name      string
+			query     ListQuery
+			wantIDs   []int
+			wantTotal int
+		}{
+			{name: "name prefix ignores case", query: ListQuery{Name: "AL"}, wantIDs: []int{1, 3}, wantTotal: 2},
+			{name: "position is exact", query: ListQuery{Position: "engineer"}, wantIDs: []int{1, 3, 5}, wantTotal: 3},
+			{name: "search name or position", query: ListQuery{Search: "SEN"}, wantIDs: []int{4}, wantTotal: 1},
+			{name: "search is literal", query: ListQuery{Search: "0%"}, wantIDs: []int{4}, wantTotal: 1},
+			{name: "salary range", query: ListQuery{Currency: "USD", MinSalary: &minSalary, MaxSalary: &maxSalary}, wantIDs: []int{2, 4}, wantTotal: 2},
+			{name: "currency", query: ListQuery{Currency: "JPY"}, wantIDs: []int{5}, wantTotal: 1},
+			{
+				name:      "engineers by salary descending",
+				query:     ListQuery{Position: "Engineer", Currency: "USD", Sort: []Sort{{Field: "salary", Desc: true}}},
+				wantIDs:   []int{3, 1},
+				wantTotal: 2,
+			},
+			{name: "salary groups currencies", query: ListQuery{Sort: []Sort{{Field: "salary"}}}, wantIDs: []int{5, 1, 4, 2, 3}, wantTotal: 5},
+			{name: "position then id descending", query: ListQuery{Sort: []Sort{{Field: "position"}, {Field: "id", Desc: true}}}, wantIDs: []int{5, 3, 1, 2, 4}, wantTotal: 5},
+			{name: "sorted page", query: ListQuery{Page: 2, PerPage: 2, Sort: []Sort{{Field: "salary", Desc: true}}}, wantIDs: []int{4, 1}, wantTotal: 5},
+		}
+		for _, tt := range tests {
+			if tt.query.Page == 0 {
+				tt.query.Page, tt.query.PerPage = 1, 10
+			}
+			page, err := edb.ListEmployees(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("%s: ListEmployees() error = %v", tt.name, err)
+			}
+			if ids := employeeIDs(page); page.Total != tt.wantTotal || !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("%s: ListEmployees() = %v, %d, want %v, %d", tt.name, ids, page.Total, tt.wantIDs, tt.wantTotal)
+			}
+		}
+		if _, err := edb.ListEmployees(ctx, ListQuery{Page: 1, PerPage: 10, MinSalary: &minSalary}); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ListEmployees() salary range without currency error = %v, want %v", err, ErrInvalidQuery)
+		}
+	})
+}
+
+func migrateUp(db *sql.DB, dialect Dialect) error {
+	m, err := NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	return m.Up(context.Background())
+}
+
+func TestMemoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) EmployeeDB {
+		return NewMemoryEmployee()
+	})
+}
+
+// TestPostgresConformance runs against the database in TEST_DB_URL. The
+// employees and audit tables are truncated before every subtest.
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	db, dialect, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrateUp(db, dialect); err != nil {
+		t.Fatal(err)
+	}
+	runConformance(t, func(t *testing.T) EmployeeDB {
+		if _, err := db.Exec(`TRUNCATE employees, employee_versions, salary_history, audit_log, audit_checkpoints, departments, positions, salary_bands RESTART IDENTITY`); err != nil {
+			t.Fatal(err)
+		}
+		return NewEmployee(db)
+	})
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) EmployeeDB {
+		db, dialect, err := NewDatabase("sqlite://:memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := migrateUp(db, dialect); err != nil {
+			t.Fatal(err)
+		}
+		return NewSQLiteEmployee(db)
+	})
+}
+
+func employeeIDs(page EmployeePage) []int {
+	var ids []int
+	for _, e := range page.Employees {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func usd(dollars int64) Money {
+	return Money{Amount: dollars * 100, Currency: "USD"}
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	db, dialect, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrateUp(db, dialect); err != nil {
+		t.Fatal(err)
+	}
+	edb := NewSQLiteEmployee(db)
+	if _, err := edb.CreateEmployee(context.Background(), Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := edb.CheckpointAudit(context.Background(), ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))); err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		`UPDATE audit_log SET actor='mallory'`,
+		`DELETE FROM audit_log`,
+		`UPDATE audit_checkpoints SET hash=''`,
+		`DELETE FROM audit_checkpoints`,
+	} {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%s succeeded, want it refused", query)
+		}
+	}
+}
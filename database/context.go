@@ -0,0 +1,49 @@
+package database
+
+import "context"
+
+type (
+	actorKey        struct{}
+	requestIDKey    struct{}
+	bandOverrideKey struct{}
+)
+
+// WithActor returns a copy of ctx that attributes the writes made with it to
+// actor, for example the user name of whoever sent the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor set by WithActor, or "" if there is none.
+func ActorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// WithRequestID returns a copy of ctx that records id as the request behind
+// the writes made with it, so that they can be traced back to it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID set by WithRequestID, or "" if there
+// is none.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithBandOverride returns a copy of ctx that lets the writes made with it
+// set a salary outside the band of the position of the employee, for
+// reason. The reason is recorded in the audit entry of each write that
+// needed it.
+func WithBandOverride(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, bandOverrideKey{}, reason)
+}
+
+// BandOverrideFrom returns the reason set by WithBandOverride, or "" if
+// there is none.
+func BandOverrideFrom(ctx context.Context) string {
+	reason, _ := ctx.Value(bandOverrideKey{}).(string)
+	return reason
+}
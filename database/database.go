@@ -2,30 +2,70 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
-func NewDatabase(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, err
+// Dialect identifies the SQL database behind a *sql.DB.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// ParseURL picks the dialect from the scheme of dbURL and returns the DSN to
+// hand to its driver. postgres:// and postgresql:// URLs, as well as lib/pq
+// key=value strings, select Postgres. sqlite://<path> selects SQLite, where
+// <path> is a file name or :memory:.
+func ParseURL(dbURL string) (Dialect, string, error) {
+	scheme, rest, ok := strings.Cut(dbURL, "://")
+	if !ok {
+		return Postgres, dbURL, nil
 	}
-	if err := db.Ping(); err != nil {
-		return nil, err
+	switch scheme {
+	case "postgres", "postgresql":
+		return Postgres, dbURL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, sqliteDSN(rest), nil
 	}
-	return db, nil
+	return "", "", fmt.Errorf("unsupported database URL scheme %q", scheme)
 }
 
+// sqliteDSN enables foreign keys and waits on locks instead of failing
+// immediately, keeping any query parameters already present in dsn.
+func sqliteDSN(dsn string) string {
+	path, query, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		params = url.Values{}
+	}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	return path + "?" + params.Encode()
+}
 
-func Initialize(db *sql.DB) error {
-	query := `
-    CREATE TABLE IF NOT EXISTS employees (
-        id SERIAL PRIMARY KEY,
-        name TEXT NOT NULL,
-        position TEXT NOT NULL,
-        salary REAL NOT NULL
-    )`
-	_, err := db.Exec(query)
-	return err
+func NewDatabase(dbURL string) (*sql.DB, Dialect, error) {
+	dialect, dsn, err := ParseURL(dbURL)
+	if err != nil {
+		return nil, "", err
+	}
+	db, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, "", err
+	}
+	if dialect == SQLite {
+		// SQLite allows a single writer, and every connection to :memory:
+		// would otherwise open its own empty database.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, "", err
+	}
+	return db, dialect, nil
 }
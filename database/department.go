@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Department groups employees. Names are unique.
+type Department struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+var (
+	errDepartmentNotFound = fmt.Errorf("department %w", ErrNotFound)
+	// ErrDepartmentInUse is returned when deleting a department employees
+	// belong to, including deleted employees that are not purged yet.
+	ErrDepartmentInUse = fmt.Errorf("department has employees: %w", ErrConflict)
+)
+
+func (e *employeeDB) CreateDepartment(ctx context.Context, department Department) (Department, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	err := e.db.QueryRowContext(ctx, `INSERT INTO departments (name) VALUES ($1) RETURNING id`, department.Name).Scan(&department.ID)
+	return department, dbError(ctx, err)
+}
+
+func (e *employeeDB) GetDepartment(ctx context.Context, id int) (Department, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var department Department
+	err := e.db.QueryRowContext(ctx, `SELECT id, name FROM departments WHERE id=$1`, id).Scan(&department.ID, &department.Name)
+	if err == sql.ErrNoRows {
+		return department, errDepartmentNotFound
+	}
+	return department, dbError(ctx, err)
+}
+
+func (e *employeeDB) ListDepartments(ctx context.Context) ([]Department, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	rows, err := e.db.QueryContext(ctx, `SELECT id, name FROM departments ORDER BY name, id`)
+	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	defer rows.Close()
+	var departments []Department
+	for rows.Next() {
+		var d Department
+		if err := rows.Scan(&d.ID, &d.Name); err != nil {
+			return nil, dbError(ctx, err)
+		}
+		departments = append(departments, d)
+	}
+	return departments, dbError(ctx, rows.Err())
+}
+
+func (e *employeeDB) UpdateDepartment(ctx context.Context, department Department) (Department, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	res, err := e.db.ExecContext(ctx, `UPDATE departments SET name=$1 WHERE id=$2`, department.Name, department.ID)
+	if err != nil {
+		return department, dbError(ctx, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return department, errDepartmentNotFound
+	}
+	return department, nil
+}
+
+func (e *employeeDB) DeleteDepartment(ctx context.Context, id int) error {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	return e.inTx(ctx, func(tx *sql.Tx) error {
+		// The foreign key would refuse the delete too, but not say why.
+		var inUse bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE department_id=$1)`, id).Scan(&inUse); err != nil {
+			return dbError(ctx, err)
+		}
+		if inUse {
+			return ErrDepartmentInUse
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM departments WHERE id=$1`, id)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return errDepartmentNotFound
+		}
+		return nil
+	})
+}
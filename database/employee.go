@@ -1,97 +1,882 @@
 package database
 
 import (
+	"context"
+	"crypto/ed25519"
 	"database/sql"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 )
 
+// Employee is a row of the employees table. Version starts at 1 and goes up
+// by one on every write, so callers can detect concurrent changes.
+//
+// A deleted employee keeps its row, with DeletedAt and DeletedBy set, until
+// PurgeEmployees removes it.
+//
+// Every version of a row is kept with the time span it was current, so
+// GetEmployeeAsOf and ListQuery.AsOf can read employees as they were.
+//
+// Salary is the salary in effect today, from the salary history of the
+// employee, which reads derive it from: a scheduled change shows from its
+// date, before ApplySalaryChanges stores it. Writing it directly records a
+// change effective today.
+//
+// DepartmentID and ManagerID are zero for an employee outside any
+// department, and for one who reports to no one, such as the CEO.
+//
+// PositionID references the position catalog, whose title is then the
+// Position of the employee and whose band bounds its salary. It is zero for
+// employees with a free-text Position.
+//
+// ExternalID and UserName identify the employee to the identity provider
+// that provisions it over SCIM; they are empty for employees it does not
+// know. Each is unique across employees, deleted or not, user names
+// ignoring case.
 type Employee struct {
-	ID       int     `json:"id"`
-	Name     string  `json:"name"`
-	Position string  `json:"position"`
-	Salary   float64 `json:"salary"`
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Position     string     `json:"position"`
+	Salary       Money      `json:"salary"`
+	Version      int        `json:"version"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy    string     `json:"deleted_by,omitempty"`
+	DepartmentID int        `json:"department_id,omitempty"`
+	ManagerID    int        `json:"manager_id,omitempty"`
+	PositionID   int        `json:"position_id,omitempty"`
+	ExternalID   string     `json:"external_id,omitempty"`
+	UserName     string     `json:"user_name,omitempty"`
 }
 
+// EmployeeChanges describes a partial update. Only the non-nil fields are
+// written; a department, manager or position ID of zero removes it. A non-zero Version
+// makes the update conditional on the stored version, like
+// Employee.Version does for UpdateEmployee.
+type EmployeeChanges struct {
+	Name         *string
+	Position     *string
+	Salary       *Money
+	DepartmentID *int
+	ManagerID    *int
+	PositionID   *int
+	ExternalID   *string
+	UserName     *string
+	// Deleted, when set, restores a deleted employee before the other
+	// changes are written, or deletes the employee after they are, all in
+	// one transaction. Unlike without it, the changes may be written to an
+	// employee that is deleted and stays so.
+	Deleted *bool
+	Version int
+}
+
+// EmployeeDB stores employees, the departments they belong to and the
+// positions they hold. Deleted employees are invisible to every method but
+// RestoreEmployee, PurgeEmployees and ListEmployees with
+// ListQuery.IncludeDeleted.
+//
+// The manager of an employee must exist and not be deleted, and must not
+// report to the employee, or writes fail with ErrUnknownManager or
+// ErrReportingCycle. Departments must exist too, or writes fail with
+// ErrUnknownDepartment.
+//
+// An employee with a catalog position takes its title as Position, and its
+// salary must be within the band of the position for its currency. Writes
+// fail with ErrUnknownPosition or ErrSalaryOutOfBand otherwise, unless the
+// context carries a reason to override the band, see WithBandOverride. An
+// employee written with a Position but no PositionID is given the catalog
+// position of that title, ignoring case; writes fail with
+// ErrUnmatchedPosition if there is none, or more than one, unless the
+// catalog is empty or an update leaves the Position as it was.
 type EmployeeDB interface {
-	CreateEmployee(employee Employee) (Employee, error)
-	GetEmployeeByID(id int) (Employee, error)
-	UpdateEmployee(employee Employee) error
-	DeleteEmployee(id int) error
-	ListEmployees(page, perPage int) ([]Employee, int, error)
+	// CreateEmployee creates employee, deleted by the actor of ctx right
+	// away, in the same transaction, if employee.DeletedAt is set; the time
+	// it is set to is not used.
+	CreateEmployee(ctx context.Context, employee Employee) (Employee, error)
+	GetEmployeeByID(ctx context.Context, id int) (Employee, error)
+	// GetEmployeesByIDs returns the employees with the given IDs, by ID,
+	// leaving out those that do not exist or are deleted.
+	GetEmployeesByIDs(ctx context.Context, ids []int) ([]Employee, error)
+	// GetEmployeeAsOf returns the version of the employee that was current
+	// at asOf. It fails with ErrNotFound if the employee did not exist yet
+	// or was deleted at the time.
+	GetEmployeeAsOf(ctx context.Context, id int, asOf time.Time) (Employee, error)
+	// UpdateEmployee replaces every field of the employee but its external
+	// ID and user name, which only PatchEmployee changes. When
+	// employee.Version is non-zero the update only applies if it matches
+	// the stored version, and fails with ErrVersionMismatch otherwise.
+	UpdateEmployee(ctx context.Context, employee Employee) (Employee, error)
+	PatchEmployee(ctx context.Context, id int, changes EmployeeChanges) (Employee, error)
+	// DeleteEmployee marks the employee deleted by the actor of ctx. A
+	// non-zero version makes the delete conditional, as for UpdateEmployee.
+	// It fails with ErrHasReports while others report to the employee.
+	DeleteEmployee(ctx context.Context, id int, version int) error
+	// RestoreEmployee undoes DeleteEmployee. It fails with ErrConflict if
+	// the employee is not deleted, and with ErrUnknownManager if its
+	// manager was deleted in the meantime.
+	RestoreEmployee(ctx context.Context, id int) (Employee, error)
+	// PurgeEmployees permanently removes the employees deleted before
+	// deletedBefore and returns how many there were.
+	PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int, error)
+	// ListSalaryChanges returns the salary history of an employee, by
+	// effective date.
+	ListSalaryChanges(ctx context.Context, employeeID int) ([]SalaryChange, error)
+	// AddSalaryChange adds a change to the salary history of an employee.
+	// A change effective today or earlier sets the salary of the employee
+	// unless a later one is already in effect; later changes show from
+	// their date and are stored by ApplySalaryChanges. It fails with ErrConflict if the employee has a
+	// change effective the same day, and with ErrSalaryOutOfBand if the
+	// salary is outside the band of the position and the context carries
+	// no override reason.
+	AddSalaryChange(ctx context.Context, change SalaryChange) (SalaryChange, error)
+	// ApplySalaryChanges brings the salary of every employee in line with
+	// the latest change effective on asOf, and returns how many changed.
+	// Reads show those salaries already; storing them records the changes
+	// in the audit log and the versions of the employees. Like
+	// AddSalaryChange, it checks the salaries against the band of the
+	// position, with the override reason each change was added with; the
+	// changes outside it are left pending and reported in an error wrapping
+	// ErrSalaryOutOfBand, after the others are applied.
+	ApplySalaryChanges(ctx context.Context, asOf time.Time) (int, error)
+	// ListAuditEntries returns the audit entries matching q. Every write
+	// above records one entry per employee it changes, atomically with the
+	// write, attributed to the actor and request ID of its context.
+	ListAuditEntries(ctx context.Context, q AuditQuery) (AuditPage, error)
+	// CheckpointAudit signs the hash of the last audit entry with key. It
+	// fails with ErrNotFound if no chained entry was written since the
+	// last checkpoint.
+	CheckpointAudit(ctx context.Context, key ed25519.PrivateKey) (AuditCheckpoint, error)
+	// ListAuditCheckpoints returns every checkpoint, oldest first.
+	ListAuditCheckpoints(ctx context.Context) ([]AuditCheckpoint, error)
+	// ListEmployees returns one page of the employees matching q.
+	ListEmployees(ctx context.Context, q ListQuery) (EmployeePage, error)
+	// ExportEmployees calls fn with every employee matching the filters of
+	// q, in its sort order, all read from one consistent snapshot and
+	// without holding them all in memory. The paging fields of q are
+	// ignored. It stops at the first error of fn and returns it.
+	ExportEmployees(ctx context.Context, q ListQuery, fn func(Employee) error) error
+
+	// ListDirectReports returns the employees reporting to a manager, by
+	// ID.
+	ListDirectReports(ctx context.Context, managerID int) ([]Employee, error)
+	// ListReportingChain returns the manager of an employee, the manager
+	// of that manager and so on up to the head of the hierarchy.
+	ListReportingChain(ctx context.Context, id int) ([]Employee, error)
+	// ListReports returns every employee under a manager, by depth and
+	// then ID.
+	ListReports(ctx context.Context, managerID int) ([]Report, error)
+
+	// CreateDepartment fails with ErrConflict if the name is taken.
+	CreateDepartment(ctx context.Context, department Department) (Department, error)
+	GetDepartment(ctx context.Context, id int) (Department, error)
+	// ListDepartments returns every department, by name.
+	ListDepartments(ctx context.Context) ([]Department, error)
+	// UpdateDepartment renames a department. It fails with ErrConflict if
+	// the name is taken.
+	UpdateDepartment(ctx context.Context, department Department) (Department, error)
+	// DeleteDepartment fails with ErrDepartmentInUse while employees belong
+	// to the department.
+	DeleteDepartment(ctx context.Context, id int) error
+
+	// CreatePosition adds a position and its bands to the catalog. It fails
+	// with ErrConflict if the code is taken.
+	CreatePosition(ctx context.Context, position Position) (Position, error)
+	GetPosition(ctx context.Context, id int) (Position, error)
+	// ListPositions returns every position, by descending level and then
+	// code.
+	ListPositions(ctx context.Context) ([]Position, error)
+	// UpdatePosition replaces a position and its bands. It fails with
+	// ErrConflict if the code is taken.
+	UpdatePosition(ctx context.Context, position Position) (Position, error)
+	// DeletePosition fails with ErrPositionInUse while employees hold the
+	// position.
+	DeletePosition(ctx context.Context, id int) error
+
+	// ImportEmployees creates the employees of rows like CreateEmployee, all
+	// in one transaction. If any row fails the checks of CreateEmployee it
+	// creates none and returns an *ImportError listing every such row. With
+	// dryRun it only runs the checks, and the employees it returns have no
+	// ID.
+	ImportEmployees(ctx context.Context, rows []ImportRow, dryRun bool) ([]Employee, error)
 }
 
+// employeeDB implements EmployeeDB on top of database/sql. The queries are
+// shared by Postgres and SQLite, which both accept $n placeholders, RETURNING
+// and window functions.
 type employeeDB struct {
-	db *sql.DB
+	db           *sql.DB
+	dialect      Dialect
+	queryTimeout time.Duration
+}
+
+// Option configures the SQL-backed EmployeeDB implementations.
+type Option func(*employeeDB)
+
+// WithQueryTimeout bounds every query by d on top of the caller's context.
+// A zero or negative d leaves queries bounded by the context alone.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(e *employeeDB) {
+		e.queryTimeout = d
+	}
+}
+
+func NewEmployee(db *sql.DB, opts ...Option) EmployeeDB {
+	return newEmployeeDB(db, Postgres, opts)
+}
+
+func NewSQLiteEmployee(db *sql.DB, opts ...Option) EmployeeDB {
+	return newEmployeeDB(db, SQLite, opts)
+}
+
+func newEmployeeDB(db *sql.DB, dialect Dialect, opts []Option) *employeeDB {
+	e := &employeeDB{db: db, dialect: dialect}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// queryContext derives the context a single query, or transaction, runs
+// under.
+func (e *employeeDB) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, e.queryTimeout)
+}
+
+// employeeColumns are the columns scanned by scanEmployee, in order.
+const employeeColumns = `id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name`
+
+// qualified returns employeeColumns prefixed with the alias of a table.
+func qualified(alias string) string {
+	return alias + "." + strings.ReplaceAll(employeeColumns, ", ", ", "+alias+".")
+}
+
+func scanEmployee(row interface{ Scan(...any) error }, employee *Employee, extra ...any) error {
+	var departmentID, managerID, positionID sql.NullInt64
+	var externalID, userName sql.NullString
+	dest := []any{&employee.ID, &employee.Name, &employee.Position, &employee.Salary.Amount, &employee.Salary.Currency, &employee.Version, &employee.DeletedAt, &employee.DeletedBy, &departmentID, &managerID, &positionID, &externalID, &userName}
+	err := row.Scan(append(dest, extra...)...)
+	employee.DepartmentID, employee.ManagerID, employee.PositionID = int(departmentID.Int64), int(managerID.Int64), int(positionID.Int64)
+	employee.ExternalID, employee.UserName = externalID.String, userName.String
+	return err
 }
 
-func NewEmployee(db *sql.DB) EmployeeDB {
-	return &employeeDB{db: db}
+// nullID is the value an optional ID is stored as: NULL for zero.
+func nullID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
 }
 
-func (e *employeeDB) CreateEmployee(employee Employee) (Employee, error) {
-	query := `INSERT INTO employees (name, position, salary) VALUES ($1, $2, $3) RETURNING id`
-	err := e.db.QueryRow(query, employee.Name, employee.Position, employee.Salary).Scan(&employee.ID)
+// nullString is the value an optional string is stored as: NULL for empty,
+// so that unique indexes only apply to the strings that are set.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (e *employeeDB) CreateEmployee(ctx context.Context, employee Employee) (Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	deleted := employee.DeletedAt != nil
+	employee.DeletedAt, employee.DeletedBy = nil, ""
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		if err := e.checkRelations(ctx, tx, employee); err != nil {
+			return err
+		}
+		if employee.PositionID == 0 {
+			id, err := e.positionTitled(ctx, tx, employee.Position)
+			if err != nil {
+				return err
+			}
+			employee.PositionID = id
+		}
+		wctx := ctx
+		if employee.PositionID != 0 {
+			position, err := e.position(ctx, tx, employee.PositionID)
+			if err != nil {
+				return err
+			}
+			employee.Position = position.Title
+			if wctx, err = checkBand(ctx, position, employee.Salary); err != nil {
+				return err
+			}
+		} else {
+			wctx = WithBandOverride(ctx, "")
+		}
+		query := `INSERT INTO employees (name, position, salary_minor, currency, department_id, manager_id, position_id, external_id, user_name) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, version`
+		err := tx.QueryRowContext(ctx, query, employee.Name, employee.Position, employee.Salary.Amount, employee.Salary.Currency,
+			nullID(employee.DepartmentID), nullID(employee.ManagerID), nullID(employee.PositionID),
+			nullString(employee.ExternalID), nullString(employee.UserName)).Scan(&employee.ID, &employee.Version)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		if err := e.recordVersion(ctx, tx, employee, now()); err != nil {
+			return err
+		}
+		hire := SalaryChange{EmployeeID: employee.ID, Salary: employee.Salary, EffectiveFrom: today(), Reason: SalaryHire, BandOverride: BandOverrideFrom(wctx)}
+		if _, err := e.insertSalaryChange(ctx, tx, hire, false); err != nil {
+			return err
+		}
+		if err := e.audit(wctx, tx, AuditCreate, nil, &employee); err != nil || !deleted {
+			return err
+		}
+		employee, err = e.updateTx(ctx, tx, AuditDelete, employee.ID, 0, false, deletedColumns(ctx))
+		return err
+	})
 	return employee, err
 }
 
-func (e *employeeDB) GetEmployeeByID(id int) (Employee, error) {
+func (e *employeeDB) GetEmployeeByID(ctx context.Context, id int) (Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
 	var employee Employee
-	query := `SELECT id, name, position, salary FROM employees WHERE id=$1`
-	err := e.db.QueryRow(query, id).Scan(&employee.ID, &employee.Name, &employee.Position, &employee.Salary)
+	table, args := employeesAt(time.Time{}, []any{id})
+	query := `SELECT ` + employeeColumns + ` FROM ` + table + ` WHERE id=$1 AND deleted_at IS NULL`
+	err := scanEmployee(e.db.QueryRowContext(ctx, query, args...), &employee)
 	if err == sql.ErrNoRows {
-		return employee, errors.New("employee not found")
+		return employee, errEmployeeNotFound
 	}
-	return employee, err
+	return employee, dbError(ctx, err)
 }
 
-func (e *employeeDB) UpdateEmployee(employee Employee) error {
-	query := `UPDATE employees SET name=$1, position=$2, salary=$3 WHERE id=$4`
-	result, err := e.db.Exec(query, employee.Name, employee.Position, employee.Salary, employee.ID)
+func (e *employeeDB) GetEmployeesByIDs(ctx context.Context, ids []int) ([]Employee, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	table, args := employeesAt(time.Time{}, args)
+	query := `SELECT ` + employeeColumns + ` FROM ` + table + ` WHERE id IN (` + strings.Join(placeholders, ", ") + `) AND deleted_at IS NULL ORDER BY id`
+	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	var employees []Employee
+	err = eachEmployee(ctx, rows, func(employee Employee) error {
+		employees = append(employees, employee)
+		return nil
+	})
+	return employees, err
+}
+
+func (e *employeeDB) UpdateEmployee(ctx context.Context, employee Employee) (Employee, error) {
+	if employee.ManagerID == employee.ID {
+		return Employee{}, ErrReportingCycle
+	}
+	return e.update(ctx, AuditUpdate, employee.ID, employee.Version, false, []column{
+		{"name", employee.Name},
+		{"position", employee.Position},
+		{"salary_minor", employee.Salary.Amount},
+		{"currency", employee.Salary.Currency},
+		{"department_id", nullID(employee.DepartmentID)},
+		{"manager_id", nullID(employee.ManagerID)},
+		{"position_id", nullID(employee.PositionID)},
+	})
+}
+
+// PatchEmployee writes only the changed columns and returns the resulting
+// employee. With no changes it is equivalent to GetEmployeeByID, apart from
+// the version check, unless it deletes or restores the employee.
+func (e *employeeDB) PatchEmployee(ctx context.Context, id int, changes EmployeeChanges) (Employee, error) {
+	if changes.ManagerID != nil && *changes.ManagerID == id {
+		return Employee{}, ErrReportingCycle
+	}
+	var columns []column
+	if changes.Name != nil {
+		columns = append(columns, column{"name", *changes.Name})
+	}
+	if changes.Position != nil {
+		columns = append(columns, column{"position", *changes.Position})
+	}
+	if changes.Salary != nil {
+		columns = append(columns, column{"salary_minor", changes.Salary.Amount}, column{"currency", changes.Salary.Currency})
+	}
+	if changes.DepartmentID != nil {
+		columns = append(columns, column{"department_id", nullID(*changes.DepartmentID)})
+	}
+	if changes.ManagerID != nil {
+		columns = append(columns, column{"manager_id", nullID(*changes.ManagerID)})
+	}
+	if changes.PositionID != nil {
+		columns = append(columns, column{"position_id", nullID(*changes.PositionID)})
+	}
+	if changes.ExternalID != nil {
+		columns = append(columns, column{"external_id", nullString(*changes.ExternalID)})
+	}
+	if changes.UserName != nil {
+		columns = append(columns, column{"user_name", nullString(*changes.UserName)})
+	}
+	if changes.Deleted != nil {
+		return e.patchDeleted(ctx, id, changes.Version, *changes.Deleted, columns)
+	}
+	if len(columns) == 0 {
+		employee, err := e.GetEmployeeByID(ctx, id)
+		if err == nil && changes.Version != 0 && changes.Version != employee.Version {
+			return Employee{}, errEmployeeChanged
+		}
+		return employee, err
+	}
+	return e.update(ctx, AuditUpdate, id, changes.Version, false, columns)
+}
+
+// patchDeleted writes columns to employee id and leaves it deleted or not,
+// restoring it first or deleting it last, in one transaction. Each step is
+// audited as it would be on its own.
+func (e *employeeDB) patchDeleted(ctx context.Context, id, version int, deleted bool, columns []column) (Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var employee Employee
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if employee, err = e.lock(ctx, tx, id); err != nil {
+			return err
+		}
+		if version != 0 && version != employee.Version {
+			return errEmployeeChanged
+		}
+		wasDeleted := employee.DeletedAt != nil
+		if wasDeleted && !deleted {
+			if employee, err = e.updateTx(ctx, tx, AuditRestore, id, 0, true, restoredColumns()); err != nil {
+				return err
+			}
+		}
+		if len(columns) > 0 {
+			if employee, err = e.updateTx(ctx, tx, AuditUpdate, id, 0, employee.DeletedAt != nil, columns); err != nil {
+				return err
+			}
+		}
+		if !wasDeleted && deleted {
+			employee, err = e.updateTx(ctx, tx, AuditDelete, id, 0, false, deletedColumns(ctx))
+		}
 		return err
+	})
+	return employee, err
+}
+
+// column is a column name and the value to write to it.
+type column struct {
+	name  string
+	value any
+}
+
+// setColumn sets the value written to column name, adding the column if
+// columns lack it.
+func setColumn(columns []column, name string, value any) []column {
+	for i, c := range columns {
+		if c.name == name {
+			columns[i].value = value
+			return columns
+		}
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errors.New("employee not found")
+	return append(columns, column{name, value})
+}
+
+// withColumns returns employee as written with columns, as far as the
+// checks made ahead of a write need.
+func withColumns(employee Employee, columns []column) Employee {
+	for _, c := range columns {
+		switch c.name {
+		case "position":
+			employee.Position, _ = c.value.(string)
+		case "salary_minor":
+			employee.Salary.Amount, _ = c.value.(int64)
+		case "currency":
+			employee.Salary.Currency, _ = c.value.(string)
+		case "department_id":
+			employee.DepartmentID, _ = c.value.(int)
+		case "manager_id":
+			employee.ManagerID, _ = c.value.(int)
+		case "position_id":
+			employee.PositionID, _ = c.value.(int)
+		}
 	}
-	return nil
+	return employee
 }
 
-func (e *employeeDB) DeleteEmployee(id int) error {
-	query := `DELETE FROM employees WHERE id=$1`
-	result, err := e.db.Exec(query, id)
+// update sets columns on employee id, bumps its version and records the
+// write in the audit log as action. A non-zero version restricts the update
+// to that version of the row. Only deleted employees are updated when
+// deleted is set, and only the others otherwise.
+func (e *employeeDB) update(ctx context.Context, action string, id, version int, deleted bool, columns []column) (Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var employee Employee
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		employee, err = e.updateTx(ctx, tx, action, id, version, deleted, columns)
+		return err
+	})
+	return employee, err
+}
+
+// updateTx is update within tx.
+func (e *employeeDB) updateTx(ctx context.Context, tx *sql.Tx, action string, id, version int, deleted bool, columns []column) (Employee, error) {
+	before, err := e.lock(ctx, tx, id)
+	switch {
+	case err != nil:
+		return Employee{}, err
+	case before.DeletedAt != nil && !deleted:
+		return Employee{}, errEmployeeNotFound
+	case before.DeletedAt == nil && deleted:
+		return Employee{}, ErrNotDeleted
+	case version != 0 && version != before.Version:
+		return Employee{}, errEmployeeChanged
+	}
+	if action == AuditUpdate && before.DeletedAt == nil {
+		// Reads show the salary in effect today even before it is applied,
+		// so the update starts from it rather than adjusting it back.
+		if before, err = e.catchUpSalary(ctx, tx, before); err != nil {
+			return Employee{}, err
+		}
+	}
+	if action == AuditDelete {
+		if err := e.checkNoReports(ctx, tx, id); err != nil {
+			return Employee{}, err
+		}
+	}
+	after := withColumns(before, columns)
+	if action == AuditRestore || after.DepartmentID != before.DepartmentID || after.ManagerID != before.ManagerID {
+		if err := e.checkRelations(ctx, tx, after); err != nil {
+			return Employee{}, err
+		}
+	}
+	// A position given by title alone is linked to the catalog, unless it
+	// is left as it was, as when only the link is removed.
+	if action == AuditUpdate && after.PositionID == 0 && after.Position != before.Position {
+		id, err := e.positionTitled(ctx, tx, after.Position)
+		if err != nil {
+			return Employee{}, err
+		}
+		if id != 0 {
+			after.PositionID = id
+			columns = setColumn(columns, "position_id", id)
+		}
+	}
+	// The audit entry only keeps an override the write needed.
+	wctx := WithBandOverride(ctx, "")
+	if action == AuditUpdate && after.PositionID != 0 &&
+		(after.PositionID != before.PositionID || after.Position != before.Position || after.Salary != before.Salary) {
+		position, err := e.position(ctx, tx, after.PositionID)
+		if err != nil {
+			return Employee{}, err
+		}
+		columns = setColumn(columns, "position", position.Title)
+		if after.PositionID != before.PositionID || after.Salary != before.Salary {
+			if wctx, err = checkBand(ctx, position, after.Salary); err != nil {
+				return Employee{}, err
+			}
+		}
+	}
+	employee, err := e.write(wctx, tx, action, before, columns)
+	if err != nil {
+		return Employee{}, err
+	}
+	if action == AuditUpdate && employee.Salary != before.Salary {
+		// Salaries set directly take effect today.
+		_, err = e.insertSalaryChange(ctx, tx, SalaryChange{
+			EmployeeID:    id,
+			Salary:        employee.Salary,
+			EffectiveFrom: today(),
+			Reason:        SalaryAdjustment,
+			BandOverride:  BandOverrideFrom(wctx),
+		}, true)
+	}
+	return employee, err
+}
+
+// write sets columns on employee before, locked in tx, bumps its version and
+// records the new version, and the write in the audit log as action.
+func (e *employeeDB) write(ctx context.Context, tx *sql.Tx, action string, before Employee, columns []column) (Employee, error) {
+	var set []string
+	var args []any
+	for _, c := range columns {
+		args = append(args, c.value)
+		set = append(set, fmt.Sprintf("%s=$%d", c.name, len(args)))
+	}
+	set = append(set, "version=version+1")
+	args = append(args, before.ID)
+	var employee Employee
+	query := fmt.Sprintf(`UPDATE employees SET %s WHERE id=$%d RETURNING %s`, strings.Join(set, ", "), len(args), employeeColumns)
+	if err := scanEmployee(tx.QueryRowContext(ctx, query, args...), &employee); err != nil {
+		return employee, dbError(ctx, err)
+	}
+	if err := e.recordVersion(ctx, tx, employee, now()); err != nil {
+		return employee, err
+	}
+	return employee, e.audit(ctx, tx, action, &before, &employee)
+}
+
+// lock reads employee id within tx and, on Postgres, locks its row until tx
+// ends. SQLite allows a single writer, so there the transaction is enough to
+// keep the row from changing.
+//
+// The lock is FOR NO KEY UPDATE, since the ID of an employee never changes:
+// a FOR UPDATE lock would block the foreign key checks of writes naming the
+// employee as manager, which hold the hierarchy lock the writer may go on
+// to take, and deadlock with them.
+func (e *employeeDB) lock(ctx context.Context, tx *sql.Tx, id int) (Employee, error) {
+	var employee Employee
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE id=$1`
+	if e.dialect == Postgres {
+		query += ` FOR NO KEY UPDATE`
+	}
+	err := scanEmployee(tx.QueryRowContext(ctx, query, id), &employee)
+	if err == sql.ErrNoRows {
+		return employee, errEmployeeNotFound
+	}
+	return employee, dbError(ctx, err)
+}
+
+func (e *employeeDB) DeleteEmployee(ctx context.Context, id int, version int) error {
+	_, err := e.update(ctx, AuditDelete, id, version, false, deletedColumns(ctx))
+	return err
+}
+
+func (e *employeeDB) RestoreEmployee(ctx context.Context, id int) (Employee, error) {
+	return e.update(ctx, AuditRestore, id, 0, true, restoredColumns())
+}
+
+// deletedColumns are the columns that mark an employee deleted by the actor
+// of ctx, and restoredColumns those that undo them.
+func deletedColumns(ctx context.Context) []column {
+	return []column{{"deleted_at", now()}, {"deleted_by", ActorFrom(ctx)}}
+}
+
+func restoredColumns() []column {
+	return []column{{"deleted_at", nil}, {"deleted_by", ""}}
+}
+
+func (e *employeeDB) PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var purged []Employee
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `DELETE FROM employees WHERE deleted_at < $1 RETURNING `+employeeColumns, deletedBefore.UTC())
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var employee Employee
+			if err := scanEmployee(rows, &employee); err != nil {
+				return dbError(ctx, err)
+			}
+			purged = append(purged, employee)
+		}
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		rows.Close()
+		at := now()
+		for i := range purged {
+			// The versions are kept for reads as of earlier times.
+			if _, err := tx.ExecContext(ctx, `UPDATE employee_versions SET valid_to=$1 WHERE id=$2 AND valid_to IS NULL`, at, purged[i].ID); err != nil {
+				return dbError(ctx, err)
+			}
+			if err := e.audit(ctx, tx, AuditPurge, &purged[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// inTx runs fn in a transaction, which is committed if fn returns nil and
+// rolled back otherwise.
+func (e *employeeDB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return dbError(ctx, tx.Commit())
+}
+
+// audit records in tx the write of action that turned before into after,
+// chained to the last entry of the log.
+func (e *employeeDB) audit(ctx context.Context, tx *sql.Tx, action string, before, after *Employee) error {
+	entry := newAuditEntry(ctx, action, before, after)
+	changes, err := json.Marshal(entry.Changes)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errors.New("employee not found")
+	prev, err := e.lastAuditHash(ctx, tx)
+	if err != nil {
+		return err
 	}
-	return nil
+	entry.Hash = chainHash(prev, entry)
+	_, err = tx.ExecContext(ctx, `INSERT INTO audit_log (employee_id, action, actor, request_id, changes, created_at, hash) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.EmployeeID, entry.Action, entry.Actor, entry.RequestID, string(changes), entry.At, entry.Hash)
+	return dbError(ctx, err)
 }
 
-func (e *employeeDB) ListEmployees(page, perPage int) ([]Employee, int, error) {
-	var employees []Employee
-	query := `
-		SELECT id, name, position, salary, COUNT(*) OVER() AS total 
-		FROM employees 
-		ORDER BY id 
-		LIMIT $1 OFFSET $2
-	`
-	rows, err := e.db.Query(query, perPage, (page-1)*perPage)
+// lastAuditHash returns the hash of the last entry of the log, for the
+// entries tx appends to chain to.
+func (e *employeeDB) lastAuditHash(ctx context.Context, tx *sql.Tx) (string, error) {
+	if e.dialect == Postgres {
+		// Entries are chained in ID order, so appends take turns until
+		// their transaction ends. Reads go on; SQLite allows a single
+		// writer anyway.
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_log IN EXCLUSIVE MODE`); err != nil {
+			return "", dbError(ctx, err)
+		}
+	}
+	var prev string
+	err := tx.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prev)
+	if err != nil && err != sql.ErrNoRows {
+		return "", dbError(ctx, err)
+	}
+	return prev, nil
+}
+
+func (e *employeeDB) CheckpointAudit(ctx context.Context, key ed25519.PrivateKey) (AuditCheckpoint, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var checkpoint AuditCheckpoint
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var last, entryID int64
+		var hash string
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(entry_id), 0) FROM audit_checkpoints`).Scan(&last); err != nil {
+			return dbError(ctx, err)
+		}
+		err := tx.QueryRowContext(ctx, `SELECT id, hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&entryID, &hash)
+		switch {
+		case err == sql.ErrNoRows:
+			return errNothingToCheckpoint
+		case err != nil:
+			return dbError(ctx, err)
+		case entryID <= last || hash == "":
+			return errNothingToCheckpoint
+		}
+		checkpoint = newAuditCheckpoint(entryID, hash, key)
+		query := `INSERT INTO audit_checkpoints (entry_id, hash, signature, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+		err = tx.QueryRowContext(ctx, query, checkpoint.EntryID, checkpoint.Hash, base64.StdEncoding.EncodeToString(checkpoint.Signature), checkpoint.At).Scan(&checkpoint.ID)
+		return dbError(ctx, err)
+	})
+	return checkpoint, err
+}
+
+func (e *employeeDB) ListAuditCheckpoints(ctx context.Context) ([]AuditCheckpoint, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	rows, err := e.db.QueryContext(ctx, `SELECT id, entry_id, hash, signature, created_at FROM audit_checkpoints ORDER BY id`)
 	if err != nil {
-		return nil, 0, err
+		return nil, dbError(ctx, err)
 	}
 	defer rows.Close()
+	var checkpoints []AuditCheckpoint
+	for rows.Next() {
+		var c AuditCheckpoint
+		var signature string
+		if err := rows.Scan(&c.ID, &c.EntryID, &c.Hash, &signature, &c.At); err != nil {
+			return nil, dbError(ctx, err)
+		}
+		// A signature that does not decode fails verification like any
+		// other bad signature.
+		c.Signature, _ = base64.StdEncoding.DecodeString(signature)
+		c.At = c.At.UTC()
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, dbError(ctx, rows.Err())
+}
 
-	var total int
+func (e *employeeDB) ListAuditEntries(ctx context.Context, q AuditQuery) (AuditPage, error) {
+	if err := q.Validate(); err != nil {
+		return AuditPage{}, err
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+
+	conds, args := q.filters()
+	// One entry beyond the page tells whether there are more.
+	args = append(args, q.Limit+1)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log %s ORDER BY id LIMIT $%d`, auditColumns, where(conds), len(args))
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return AuditPage{}, dbError(ctx, err)
+	}
+	defer rows.Close()
+	var page AuditPage
+	for rows.Next() {
+		var entry AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return AuditPage{}, dbError(ctx, err)
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return AuditPage{}, dbError(ctx, err)
+	}
+	page.trim(q)
+	return page, nil
+}
+
+func (e *employeeDB) ListEmployees(ctx context.Context, q ListQuery) (EmployeePage, error) {
+	if err := q.Validate(); err != nil {
+		return EmployeePage{}, err
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+
+	var page EmployeePage
+	table, args := employeesAt(q.AsOf, nil)
+	filters, args := q.filters(args)
+	conds, rowArgs := filters, args
+	if keyset, keyArgs := q.keyset(args); keyset != "" {
+		conds, rowArgs = append(slices.Clone(filters), keyset), keyArgs
+	}
+	// One row beyond the page tells whether there are more.
+	rowArgs = append(rowArgs, q.PerPage+1)
+	limit := fmt.Sprintf("LIMIT $%d", len(rowArgs))
+	if q.After == nil && q.Before == nil {
+		rowArgs = append(rowArgs, (q.Page-1)*q.PerPage+q.Offset)
+		limit += fmt.Sprintf(" OFFSET $%d", len(rowArgs))
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s %s %s %s`, employeeColumns, table, where(conds), q.orderBy(), limit)
+	rows, err := e.db.QueryContext(ctx, query, rowArgs...)
+	if err != nil {
+		return EmployeePage{}, dbError(ctx, err)
+	}
+	defer rows.Close()
 	for rows.Next() {
 		var employee Employee
-		if err := rows.Scan(&employee.ID, &employee.Name, &employee.Position, &employee.Salary, &total); err != nil {
-			return nil, 0, err
+		if err := scanEmployee(rows, &employee); err != nil {
+			return EmployeePage{}, dbError(ctx, err)
 		}
-		employees = append(employees, employee)
+		page.Employees = append(page.Employees, employee)
+	}
+	if err := rows.Err(); err != nil {
+		return EmployeePage{}, dbError(ctx, err)
 	}
+	page.trim(q)
 
-	return employees, total, nil
+	if !q.SkipTotal {
+		query := `SELECT COUNT(*) FROM ` + table + ` ` + where(filters)
+		if err := e.db.QueryRowContext(ctx, query, args...).Scan(&page.Total); err != nil {
+			return EmployeePage{}, dbError(ctx, err)
+		}
+	}
+	return page, nil
 }
@@ -1,13 +1,90 @@
 package database
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bradleyjkemp/cupaloy/v2"
 )
 
+// mockColumns are the columns of the employee rows returned by sqlmock.
+var mockColumns = []string{"id", "name", "position", "salary_minor", "currency", "version", "deleted_at", "deleted_by", "department_id", "manager_id", "position_id", "external_id", "user_name"}
+
+// expectLock expects the read of employee id that starts a write, and
+// returns row as the stored employee.
+func expectLock(mock sqlmock.Sqlmock, id int, row ...driver.Value) {
+	rows := sqlmock.NewRows(mockColumns)
+	if row != nil {
+		rows.AddRow(row...)
+	}
+	mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM employees WHERE id=\$1 FOR NO KEY UPDATE`).WithArgs(id).WillReturnRows(rows)
+}
+
+// expectAudit expects the audit entry of a write to employee id, with the
+// JSON of its changes or sqlmock.AnyArg(), chained to the last entry.
+func expectAudit(mock sqlmock.Sqlmock, id int, action string, changes driver.Value) {
+	mock.ExpectExec(`LOCK TABLE audit_log IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("prev"))
+	mock.ExpectExec(`INSERT INTO audit_log \(employee_id, action, actor, request_id, changes, created_at, hash\)`).
+		WithArgs(id, action, sqlmock.AnyArg(), sqlmock.AnyArg(), changes, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectVersion expects the version of employee id just written to replace
+// the current one.
+func expectVersion(mock sqlmock.Sqlmock, id int) {
+	mock.ExpectExec(`UPDATE employee_versions SET valid_to=\$1 WHERE id=\$2 AND valid_to IS NULL`).WithArgs(sqlmock.AnyArg(), id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO employee_versions \(id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name, valid_from\)`).
+		WithArgs(id, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectNoReports expects the check that no one reports to employee id
+// before it is deleted.
+func expectNoReports(mock sqlmock.Sqlmock, id int) {
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM employees WHERE manager_id=\$1 AND deleted_at IS NULL\)`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+}
+
+// currentEmployees is the pattern of the table employeesAt renders for the
+// current employee rows, with the date at placeholder n.
+func currentEmployees(n int) string {
+	return regexp.QuoteMeta(salaryOn("employees", n, "")) + ` employees`
+}
+
+// expectSalaryHistory expects the salary history of employee id to be read
+// before an update, and returns a change of its salary to amount, in effect
+// today.
+func expectSalaryHistory(mock sqlmock.Sqlmock, id int, amount int64) {
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM employees WHERE id=\$1 AND deleted_at IS NULL\)`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT id, employee_id, salary_minor, currency, effective_from, reason, band_override, created_by, created_at FROM salary_history WHERE employee_id=\$1 ORDER BY effective_from`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "employee_id", "salary_minor", "currency", "effective_from", "reason", "band_override", "created_by", "created_at"}).
+			AddRow(1, id, amount, "USD", today(), SalaryAdjustment, "", "", today()))
+}
+
+// expectNoCatalog expects the position title of a write to be looked up
+// in an empty catalog, which leaves it free text.
+func expectNoCatalog(mock sqlmock.Sqlmock, title string) {
+	mock.ExpectQuery(`SELECT id FROM positions WHERE LOWER\(title\) = LOWER\(\$1\) LIMIT 2`).WithArgs(title).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM positions\)`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+}
+
+// expectSalaryChange expects a change to the salary history of employee id,
+// effective today.
+func expectSalaryChange(mock sqlmock.Sqlmock, id int, amount int64, reason string) {
+	mock.ExpectQuery(`INSERT INTO salary_history \(employee_id, salary_minor, currency, effective_from, reason, band_override, created_by, created_at\)`).
+		WithArgs(id, amount, "USD", dateValue(today()), reason, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
 func TestCreateEmployee(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
@@ -26,28 +103,40 @@ func TestCreateEmployee(t *testing.T) {
 			employee: Employee{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   50000.0,
+				Salary:   usd(50000),
 			},
 			wantErr: false,
 			before: func(emp Employee, t *testing.T) {
-				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectBegin()
+				expectNoCatalog(mock, emp.Position)
+				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary.Amount, emp.Salary.Currency, nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 1))
+				expectVersion(mock, 1)
+				expectSalaryChange(mock, 1, emp.Salary.Amount, SalaryHire)
+				expectAudit(mock, 1, AuditCreate, `{"currency":{"to":"USD"},"name":{"to":"John Doe"},"position":{"to":"Engineer"},"salary":{"to":"50000.00"}}`)
+				mock.ExpectCommit()
 			},
 			after: func(t *testing.T) {
-				mock.ExpectationsWereMet()
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
 			},
 		},
 		{
 			name: "Failed Insert",
 			employee: Employee{
 				Position: "Engineer",
-				Salary:   50000.0,
+				Salary:   usd(50000),
 			},
 			wantErr: true,
 			before: func(emp Employee, t *testing.T) {
-				query := mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary).WillReturnError(errors.New("failed to   insert"))
+				mock.ExpectBegin()
+				expectNoCatalog(mock, emp.Position)
+				query := mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary.Amount, emp.Salary.Currency, nil, nil, nil, nil, nil).WillReturnError(errors.New("failed to   insert"))
 				if query == nil {
 					t.Errorf("error")
 				}
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -61,7 +150,7 @@ func TestCreateEmployee(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.before(tt.employee, t)
-			res, err := edb.CreateEmployee(tt.employee)
+			res, err := edb.CreateEmployee(context.Background(), tt.employee)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateEmployee() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -95,8 +184,8 @@ func TestGetEmployeeByID(t *testing.T) {
 			id:      1,
 			wantErr: false,
 			before: func(id int, t *testing.T) {
-				rows := sqlmock.NewRows([]string{"id", "name", "position", "salary"}).AddRow(1, "John Doe", "Engineer", 50000.0)
-				mock.ExpectQuery(`SELECT id, name, position, salary FROM employees WHERE id=\$1`).WithArgs(id).WillReturnRows(rows)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees(2)+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, dateValue(today())).WillReturnRows(rows)
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -110,7 +199,7 @@ func TestGetEmployeeByID(t *testing.T) {
 			id:      1,
 			wantErr: true,
 			before: func(id int, t *testing.T) {
-				mock.ExpectQuery(`SELECT id, name, position, salary FROM employees WHERE id=\$1`).WithArgs(id).WillReturnError(errors.New("failed to get"))
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees(2)+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, dateValue(today())).WillReturnError(errors.New("failed to get"))
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -124,7 +213,7 @@ func TestGetEmployeeByID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.before(tt.id, t)
-			res, err := edb.GetEmployeeByID(tt.id)
+			res, err := edb.GetEmployeeByID(context.Background(), tt.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetEmployeeByID() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -156,11 +245,42 @@ func TestUpdateEmployee(t *testing.T) {
 				ID:       1,
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   50000.0,
+				Salary:   usd(50000),
 			},
 			wantErr: false,
 			before: func(emp Employee, t *testing.T) {
-				mock.ExpectExec(`UPDATE employees SET name=\$1, position=\$2, salary=\$3 WHERE id=\$4`).WithArgs(emp.Name, emp.Position, emp.Salary, emp.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				expectLock(mock, emp.ID, 1, "John Doe", "Intern", 4000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, emp.ID, 4000000)
+				expectNoCatalog(mock, emp.Position)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET name=\$1, position=\$2, salary_minor=\$3, currency=\$4, department_id=\$5, manager_id=\$6, position_id=\$7, version=version\+1 WHERE id=\$8 RETURNING id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name`).WithArgs(emp.Name, emp.Position, emp.Salary.Amount, emp.Salary.Currency, nil, nil, nil, emp.ID).WillReturnRows(rows)
+				expectVersion(mock, emp.ID)
+				expectAudit(mock, emp.ID, AuditUpdate, `{"position":{"from":"Intern","to":"Engineer"},"salary":{"from":"40000.00","to":"50000.00"}}`)
+				expectSalaryChange(mock, emp.ID, 5000000, SalaryAdjustment)
+				mock.ExpectCommit()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name: "Stale Version",
+			employee: Employee{
+				ID:       1,
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   usd(50000),
+				Version:  1,
+			},
+			wantErr: true,
+			before: func(emp Employee, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, emp.ID, 1, "John Doe", "Intern", 4000000, "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -175,11 +295,16 @@ func TestUpdateEmployee(t *testing.T) {
 				ID:       1,
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   50000.0,
+				Salary:   usd(50000),
 			},
 			wantErr: true,
 			before: func(emp Employee, t *testing.T) {
-				mock.ExpectExec(`UPDATE employees SET name=\$1, position=\$2, salary=\$3 WHERE id=\$4`).WithArgs(emp.Name, emp.Position, emp.Salary, emp.ID).WillReturnError(errors.New("failed to update"))
+				mock.ExpectBegin()
+				expectLock(mock, emp.ID, 1, "John Doe", "Intern", 4000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, emp.ID, 4000000)
+				expectNoCatalog(mock, emp.Position)
+				mock.ExpectQuery(`UPDATE employees SET name=\$1, position=\$2, salary_minor=\$3, currency=\$4, department_id=\$5, manager_id=\$6, position_id=\$7, version=version\+1 WHERE id=\$8`).WithArgs(emp.Name, emp.Position, emp.Salary.Amount, emp.Salary.Currency, nil, nil, nil, emp.ID).WillReturnError(errors.New("failed to update"))
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -193,34 +318,50 @@ func TestUpdateEmployee(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.before(tt.employee, t)
-			err := edb.UpdateEmployee(tt.employee)
+			res, err := edb.UpdateEmployee(context.Background(), tt.employee)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UpdateEmployee() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			tt.after(t)
+			cupaloy.SnapshotT(t, struct {
+				Employee Employee
+				Error    error
+			}{res, err})
 		})
 	}
 }
 
-func TestDeleteEmployee(t *testing.T) {
+func TestPatchEmployee(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
 	edb := NewEmployee(db)
+	position := "Manager"
+	salary := usd(70000)
 
 	tests := []struct {
 		name    string
 		id      int
+		changes EmployeeChanges
 		wantErr bool
 		before  func(id int, t *testing.T)
 		after   func(t *testing.T)
 	}{
 		{
-			name:    "Successful Delete",
+			name:    "Position Only",
 			id:      1,
+			changes: EmployeeChanges{Position: &position},
 			wantErr: false,
 			before: func(id int, t *testing.T) {
-				mock.ExpectExec(`DELETE FROM employees WHERE id=\$1`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, id, 5000000)
+				expectNoCatalog(mock, position)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Manager", 5000000, "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET position=\$1, version=version\+1 WHERE id=\$2 RETURNING id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name`).WithArgs(position, id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditUpdate, `{"position":{"from":"Engineer","to":"Manager"}}`)
+				mock.ExpectCommit()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -230,11 +371,69 @@ func TestDeleteEmployee(t *testing.T) {
 			},
 		},
 		{
-			name:    "Failed Delete",
+			name:    "Position And Salary",
+			id:      1,
+			changes: EmployeeChanges{Position: &position, Salary: &salary},
+			wantErr: false,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, id, 5000000)
+				expectNoCatalog(mock, position)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Manager", 7000000, "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET position=\$1, salary_minor=\$2, currency=\$3, version=version\+1 WHERE id=\$4 RETURNING`).WithArgs(position, salary.Amount, salary.Currency, id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditUpdate, `{"position":{"from":"Engineer","to":"Manager"},"salary":{"from":"50000.00","to":"70000.00"}}`)
+				expectSalaryChange(mock, id, 7000000, SalaryAdjustment)
+				mock.ExpectCommit()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Salary Due",
+			id:      1,
+			changes: EmployeeChanges{Position: &position},
+			wantErr: false,
+			before: func(id int, t *testing.T) {
+				// A raise in effect today, not applied yet, is applied first.
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, id, 5500000)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5500000, "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET salary_minor=\$1, currency=\$2, version=version\+1 WHERE id=\$3 RETURNING`).WithArgs(int64(5500000), "USD", id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditUpdate, `{"salary":{"from":"50000.00","to":"55000.00"}}`)
+				expectNoCatalog(mock, position)
+				rows = sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Manager", 5500000, "USD", 3, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET position=\$1, version=version\+1 WHERE id=\$2 RETURNING`).WithArgs(position, id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditUpdate, `{"position":{"from":"Engineer","to":"Manager"}}`)
+				mock.ExpectCommit()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Failed Patch",
 			id:      1,
+			changes: EmployeeChanges{Position: &position},
 			wantErr: true,
 			before: func(id int, t *testing.T) {
-				mock.ExpectExec(`DELETE FROM employees WHERE id=\$1`).WithArgs(id).WillReturnError(errors.New("failed to delete"))
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock, id, 5000000)
+				expectNoCatalog(mock, position)
+				mock.ExpectQuery(`UPDATE employees SET position=\$1, version=version\+1 WHERE id=\$2`).WithArgs(position, id).WillReturnError(errors.New("failed to patch"))
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -248,8 +447,133 @@ func TestDeleteEmployee(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.before(tt.id, t)
-			err := edb.DeleteEmployee(tt.id)
+			res, err := edb.PatchEmployee(context.Background(), tt.id, tt.changes)
 			if (err != nil) != tt.wantErr {
+				t.Errorf("PatchEmployee() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			tt.after(t)
+			cupaloy.SnapshotT(t, struct {
+				Employee Employee
+				Error    error
+			}{res, err})
+		})
+	}
+}
+
+func TestDeleteEmployee(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	edb := NewEmployee(db)
+	errDeleteFailed := errors.New("failed to delete")
+	deletedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      int
+		version int
+		wantErr error
+		before  func(id int, t *testing.T)
+		after   func(t *testing.T)
+	}{
+		{
+			name:    "Successful Delete",
+			id:      1,
+			wantErr: nil,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectNoReports(mock, id)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 2, deletedAt, "jane", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET deleted_at=\$1, deleted_by=\$2, version=version\+1 WHERE id=\$3 RETURNING`).WithArgs(sqlmock.AnyArg(), "jane", id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditDelete, `{"deleted_at":{"to":"2024-06-01T12:00:00Z"},"deleted_by":{"to":"jane"}}`)
+				mock.ExpectCommit()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Stale Version",
+			id:      1,
+			version: 3,
+			wantErr: ErrVersionMismatch,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 4, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectRollback()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Already Deleted",
+			id:      1,
+			version: 3,
+			wantErr: ErrNotFound,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 3, deletedAt, "joe", nil, nil, nil, nil, nil)
+				mock.ExpectRollback()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Missing Employee",
+			id:      1,
+			version: 3,
+			wantErr: ErrNotFound,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id)
+				mock.ExpectRollback()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Failed Delete",
+			id:      1,
+			wantErr: errDeleteFailed,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectNoReports(mock, id)
+				mock.ExpectQuery(`UPDATE employees SET deleted_at=\$1, deleted_by=\$2`).WithArgs(sqlmock.AnyArg(), "jane", id).WillReturnError(errDeleteFailed)
+				mock.ExpectRollback()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before(tt.id, t)
+			ctx := WithActor(context.Background(), "jane")
+			err := edb.DeleteEmployee(ctx, tt.id, tt.version)
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("DeleteEmployee() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			tt.after(t)
@@ -257,30 +581,183 @@ func TestDeleteEmployee(t *testing.T) {
 	}
 }
 
+func TestRestoreEmployee(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	edb := NewEmployee(db)
+	deletedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      int
+		wantErr error
+		before  func(id int, t *testing.T)
+	}{
+		{
+			name: "Successful Restore",
+			id:   1,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 2, deletedAt, "jane", nil, nil, nil, nil, nil)
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 3, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET deleted_at=\$1, deleted_by=\$2, version=version\+1 WHERE id=\$3 RETURNING`).WithArgs(nil, "", id).WillReturnRows(rows)
+				expectVersion(mock, id)
+				expectAudit(mock, id, AuditRestore, `{"deleted_at":{"from":"2024-06-01T12:00:00Z"},"deleted_by":{"from":"jane"}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "Not Deleted",
+			id:      1,
+			wantErr: ErrConflict,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id, 1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "Missing Employee",
+			id:      1,
+			wantErr: ErrNotFound,
+			before: func(id int, t *testing.T) {
+				mock.ExpectBegin()
+				expectLock(mock, id)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before(tt.id, t)
+			res, err := edb.RestoreEmployee(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RestoreEmployee() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+			cupaloy.SnapshotT(t, struct {
+				Employee Employee
+				Error    error
+			}{res, err})
+		})
+	}
+}
+
+func TestPurgeEmployees(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	edb := NewEmployee(db)
+	cutoff := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := cutoff.Add(-time.Hour)
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows(mockColumns).
+		AddRow(1, "John Doe", "Engineer", 5000000, "USD", 2, deletedAt, "jane", nil, nil, nil, nil, nil).
+		AddRow(4, "Jim Doe", "Manager", 6000000, "EUR", 5, deletedAt, "jane", nil, nil, nil, nil, nil)
+	mock.ExpectQuery(`DELETE FROM employees WHERE deleted_at < \$1 RETURNING id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name`).WithArgs(cutoff).WillReturnRows(rows)
+	mock.ExpectExec(`UPDATE employee_versions SET valid_to=\$1 WHERE id=\$2 AND valid_to IS NULL`).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, 1, AuditPurge, sqlmock.AnyArg())
+	mock.ExpectExec(`UPDATE employee_versions SET valid_to=\$1 WHERE id=\$2 AND valid_to IS NULL`).WithArgs(sqlmock.AnyArg(), 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, 4, AuditPurge, `{"currency":{"from":"EUR"},"deleted_at":{"from":"2024-06-01T11:00:00Z"},"deleted_by":{"from":"jane"},"name":{"from":"Jim Doe"},"position":{"from":"Manager"},"salary":{"from":"60000.00"}}`)
+	mock.ExpectCommit()
+
+	purged, err := edb.PurgeEmployees(context.Background(), cutoff)
+	if err != nil || purged != 2 {
+		t.Errorf("PurgeEmployees() = %d, %v, want 2, nil", purged, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestListEmployees(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
 	edb := NewEmployee(db)
 
+	minSalary := int64(10000000)
+
 	tests := []struct {
 		name    string
-		page    int
-		perPage int
+		query   ListQuery
 		wantErr bool
-		before  func(page, perPage int, t *testing.T)
+		before  func(q ListQuery, t *testing.T)
 		after   func(t *testing.T)
 	}{
 		{
 			name:    "Successful List",
-			page:    1,
-			perPage: 10,
+			query:   ListQuery{Page: 1, PerPage: 10},
+			wantErr: false,
+			before: func(q ListQuery, t *testing.T) {
+				rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees(1)+` WHERE deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
+					WithArgs(dateValue(today()), q.PerPage+1, (q.Page-1)*q.PerPage).
+					WillReturnRows(rows)
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM ` + currentEmployees(1) + ` WHERE deleted_at IS NULL$`).WithArgs(dateValue(today())).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name: "Filtered List",
+			query: ListQuery{
+				Page:      2,
+				PerPage:   10,
+				Name:      "jo",
+				Position:  "Engineer",
+				Search:    "50%_off",
+				Currency:  "USD",
+				MinSalary: &minSalary,
+				Sort:      []Sort{{Field: "salary", Desc: true}, {Field: "name"}},
+
+				IncludeDeleted: true,
+			},
 			wantErr: false,
-			before: func(page, perPage int, t *testing.T) {
-				rows := sqlmock.NewRows([]string{"id", "name", "position", "salary", "total"}).
-					AddRow(1, "John Doe", "Engineer", 50000.0, 1)
-				mock.ExpectQuery(`SELECT id, name, position, salary, COUNT\(\*\) OVER\(\) AS total FROM employees ORDER BY id LIMIT \$1 OFFSET \$2`).
-					WithArgs(perPage, (page-1)*perPage).
+			before: func(q ListQuery, t *testing.T) {
+				where := `WHERE LOWER\(name\) LIKE \$2 ESCAPE '\\' AND LOWER\(position\) = \$3 ` +
+					`AND \(LOWER\(name\) LIKE \$4 ESCAPE '\\' OR LOWER\(position\) LIKE \$4 ESCAPE '\\'\) ` +
+					`AND currency = \$5 AND salary_minor >= \$6`
+				mock.ExpectQuery(`FROM `+currentEmployees(1)+` `+where+` ORDER BY currency DESC, salary_minor DESC, name, id LIMIT \$7 OFFSET \$8`).
+					WithArgs(dateValue(today()), "jo%", "engineer", `%50\%\_off%`, "USD", minSalary, 11, 10).
+					WillReturnRows(sqlmock.NewRows(mockColumns))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM `+currentEmployees(1)+` `+where+`$`).
+					WithArgs(dateValue(today()), "jo%", "engineer", `%50\%\_off%`, "USD", minSalary).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name: "Keyset List",
+			query: ListQuery{
+				PerPage:   1,
+				After:     &Employee{ID: 3, Name: "John Doe", Salary: usd(50000)},
+				Position:  "Engineer",
+				Sort:      []Sort{{Field: "salary", Desc: true}},
+				SkipTotal: true,
+			},
+			wantErr: false,
+			before: func(q ListQuery, t *testing.T) {
+				rows := sqlmock.NewRows(mockColumns).
+					AddRow(1, "Jane Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil).
+					AddRow(2, "Jim Doe", "Engineer", 4000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`FROM `+currentEmployees(1)+` WHERE deleted_at IS NULL AND LOWER\(position\) = \$2 AND \(`+
+					`\(currency < \$3\) OR \(currency = \$3 AND salary_minor < \$4\) OR \(currency = \$3 AND salary_minor = \$4 AND id > \$5\)`+
+					`\) ORDER BY currency DESC, salary_minor DESC, id LIMIT \$6$`).
+					WithArgs(dateValue(today()), "engineer", "USD", int64(5000000), 3, 2).
 					WillReturnRows(rows)
 			},
 			after: func(t *testing.T) {
@@ -292,12 +769,11 @@ func TestListEmployees(t *testing.T) {
 		},
 		{
 			name:    "Failed List",
-			page:    1,
-			perPage: 10,
+			query:   ListQuery{Page: 1, PerPage: 10},
 			wantErr: true,
-			before: func(page, perPage int, t *testing.T) {
-				mock.ExpectQuery(`SELECT id, name, position, salary, COUNT\(\*\) OVER\(\) AS total FROM employees ORDER BY id LIMIT \$1 OFFSET \$2`).
-					WithArgs(perPage, (page-1)*perPage).
+			before: func(q ListQuery, t *testing.T) {
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees(1)+` WHERE deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
+					WithArgs(dateValue(today()), q.PerPage+1, (q.Page-1)*q.PerPage).
 					WillReturnError(errors.New("failed to list"))
 			},
 			after: func(t *testing.T) {
@@ -311,23 +787,175 @@ func TestListEmployees(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.before(tt.page, tt.perPage, t)
-			res, total, err := edb.ListEmployees(tt.page, tt.perPage)
+			tt.before(tt.query, t)
+			res, err := edb.ListEmployees(context.Background(), tt.query)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ListEmployees() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			tt.after(t)
 			cupaloy.SnapshotT(t, struct {
-				Employees []Employee
-				Total     int
-				Error     error
+				Page  EmployeePage
+				Error error
 			}{
 				res,
-				total,
 				err,
 			})
 		})
 	}
 
 }
+
+func TestQueryTimeout(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	edb := NewEmployee(db, WithQueryTimeout(10*time.Millisecond))
+
+	rows := sqlmock.NewRows(mockColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+	mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees(2)+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(1, dateValue(today())).WillDelayFor(time.Second).WillReturnRows(rows)
+
+	_, err := edb.GetEmployeeByID(context.Background(), 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetEmployeeByID() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestListAuditEntries(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	edb := NewEmployee(db)
+
+	auditColumns := []string{"id", "employee_id", "action", "actor", "request_id", "changes", "created_at", "hash"}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    AuditQuery
+		wantErr  bool
+		wantMore bool
+		before   func(q AuditQuery, t *testing.T)
+		after    func(t *testing.T)
+	}{
+		{
+			name:     "Successful List",
+			query:    AuditQuery{Limit: 1},
+			wantErr:  false,
+			wantMore: true,
+			before: func(q AuditQuery, t *testing.T) {
+				rows := sqlmock.NewRows(auditColumns).
+					AddRow(1, 1, "create", "jane", "req-1", `{"name":{"to":"John Doe"}}`, at, "a1").
+					AddRow(2, 1, "update", "jane", "req-2", `{"salary":{"from":"40000.00","to":"50000.00"}}`, at, "b2")
+				mock.ExpectQuery(`SELECT id, employee_id, action, actor, request_id, changes, created_at, hash FROM audit_log ORDER BY id LIMIT \$1`).
+					WithArgs(q.Limit + 1).
+					WillReturnRows(rows)
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name: "Filtered List",
+			query: AuditQuery{
+				EmployeeID: 1,
+				Actor:      "jane",
+				Action:     AuditUpdate,
+				RequestID:  "req-2",
+				Field:      "salary",
+				Since:      at,
+				Until:      at.Add(time.Hour),
+				After:      1,
+				Limit:      10,
+			},
+			wantErr: false,
+			before: func(q AuditQuery, t *testing.T) {
+				rows := sqlmock.NewRows(auditColumns).
+					AddRow(2, 1, "update", "jane", "req-2", `{"salary":{"from":"40000.00","to":"50000.00"}}`, at, "b2")
+				mock.ExpectQuery(`SELECT id, employee_id, action, actor, request_id, changes, created_at, hash FROM audit_log WHERE employee_id = \$1 AND actor = \$2 AND action = \$3 AND request_id = \$4 AND changes LIKE \$5 ESCAPE '\\' AND created_at >= \$6 AND created_at < \$7 AND id > \$8 ORDER BY id LIMIT \$9`).
+					WithArgs(1, "jane", "update", "req-2", `%"salary":%`, at, at.Add(time.Hour), int64(1), 11).
+					WillReturnRows(rows)
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:    "Unknown Field",
+			query:   AuditQuery{Field: "age", Limit: 10},
+			wantErr: true,
+			before:  func(q AuditQuery, t *testing.T) {},
+			after:   func(t *testing.T) {},
+		},
+		{
+			name:    "Failed List",
+			query:   AuditQuery{Limit: 10},
+			wantErr: true,
+			before: func(q AuditQuery, t *testing.T) {
+				mock.ExpectQuery(`SELECT id, employee_id, action, actor, request_id, changes, created_at, hash FROM audit_log`).
+					WithArgs(q.Limit + 1).
+					WillReturnError(errors.New("failed to list"))
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before(tt.query, t)
+			page, err := edb.ListAuditEntries(context.Background(), tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListAuditEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if page.More != tt.wantMore || len(page.Entries) == 0 || page.Entries[0].Actor != "jane" || page.Entries[0].Changes == nil {
+					t.Errorf("ListAuditEntries() = %+v, want entries by jane and more %v", page, tt.wantMore)
+				}
+			}
+			tt.after(t)
+		})
+	}
+}
+
+func TestUpdatePositionLocksBeforeAuditing(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	edb := NewEmployee(db)
+
+	position := Position{ID: 7, Code: "ENG", Title: "Software Engineer", Level: 2}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE positions SET code=\$1, title=\$2, level=\$3 WHERE id=\$4`).
+		WithArgs(position.Code, position.Title, position.Level, position.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM salary_bands WHERE position_id=\$1`).WithArgs(position.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+	// Both employees are locked before the first audit entry is written.
+	mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM employees WHERE position_id=\$1 AND position <> \$2 ORDER BY id FOR NO KEY UPDATE`).
+		WithArgs(position.ID, position.Title).
+		WillReturnRows(sqlmock.NewRows(mockColumns).
+			AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, 7, nil, nil).
+			AddRow(2, "Jane Doe", "Engineer", 6000000, "USD", 4, nil, "", nil, nil, 7, nil, nil))
+	for _, id := range []int{1, 2} {
+		mock.ExpectQuery(`UPDATE employees SET position=\$1, version=version\+1 WHERE id=\$2 RETURNING`).WithArgs(position.Title, id).
+			WillReturnRows(sqlmock.NewRows(mockColumns).AddRow(id, "Doe", position.Title, 5000000, "USD", 2, nil, "", nil, nil, 7, nil, nil))
+		expectVersion(mock, id)
+		expectAudit(mock, id, AuditUpdate, sqlmock.AnyArg())
+	}
+	mock.ExpectCommit()
+
+	if _, err := edb.UpdatePosition(context.Background(), position); err != nil {
+		t.Fatalf("UpdatePosition() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
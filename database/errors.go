@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Errors returned by the EmployeeDB implementations. Driver errors are
+// wrapped so that errors.Is matches one of these while errors.As still
+// reaches the original *pq.Error or *sqlite.Error.
+var (
+	// ErrNotFound means the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the write clashed with existing data or with a
+	// concurrent transaction, e.g. a unique key or serialization failure.
+	ErrConflict = errors.New("conflict")
+	// ErrConstraint means the write broke an integrity constraint such as
+	// a foreign key, NOT NULL or CHECK.
+	ErrConstraint = errors.New("constraint violation")
+	// ErrUnavailable means the database could not be reached or is
+	// refusing work; the operation may succeed if retried.
+	ErrUnavailable = errors.New("database unavailable")
+	// ErrVersionMismatch means a conditional write was refused because the
+	// record changed since the version the caller expected.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrNotDeleted is returned when restoring an employee that is not
+	// deleted.
+	ErrNotDeleted = fmt.Errorf("employee is not deleted: %w", ErrConflict)
+)
+
+var (
+	errEmployeeNotFound = fmt.Errorf("employee %w", ErrNotFound)
+	errEmployeeChanged  = fmt.Errorf("employee %w", ErrVersionMismatch)
+	// errNothingToCheckpoint is returned when the last checkpoint already
+	// covers the audit log.
+	errNothingToCheckpoint = fmt.Errorf("audit entries since the last checkpoint %w", ErrNotFound)
+)
+
+// dbError turns an error from database/sql into one of the errors above.
+// Once ctx is done its error is reported instead, since drivers surface
+// cancellation in their own way (lib/pq returns "canceling statement due to
+// user request").
+func dbError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if kind := classify(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+func classify(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return classifyPostgres(string(pqErr.Code))
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return classifySQLite(liteErr.Code())
+	}
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// classifyPostgres maps a SQLSTATE code, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+func classifyPostgres(code string) error {
+	switch {
+	case code == "23505", code == "40001", code == "40P01":
+		return ErrConflict
+	case strings.HasPrefix(code, "23"):
+		return ErrConstraint
+	case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"),
+		code == "57P01", code == "57P02", code == "57P03":
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// classifySQLite maps an extended result code, see
+// https://www.sqlite.org/rescode.html.
+func classifySQLite(code int) error {
+	switch {
+	case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return ErrConflict
+	case code&0xff == sqlite3.SQLITE_CONSTRAINT:
+		return ErrConstraint
+	case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED:
+		return ErrUnavailable
+	}
+	return nil
+}
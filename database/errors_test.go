@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestDBError(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want error
+	}{
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: ErrConflict},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: ErrConflict},
+		{name: "foreign key violation", err: &pq.Error{Code: "23503"}, want: ErrConstraint},
+		{name: "not null violation", err: &pq.Error{Code: "23502"}, want: ErrConstraint},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: ErrUnavailable},
+		{name: "too many connections", err: &pq.Error{Code: "53300"}, want: ErrUnavailable},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, want: ErrUnavailable},
+		{name: "bad connection", err: driver.ErrBadConn, want: ErrUnavailable},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: ErrUnavailable},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}, want: nil},
+		{name: "cancelled context", ctx: cancelled, err: &pq.Error{Code: "57014"}, want: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			got := dbError(ctx, tt.err)
+			if tt.want == nil {
+				if got != tt.err {
+					t.Errorf("dbError() = %v, want %v unchanged", got, tt.err)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("dbError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDBErrorSQLite(t *testing.T) {
+	db, _, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE parents (id INTEGER PRIMARY KEY, code TEXT UNIQUE);
+		CREATE TABLE children (parent_id INTEGER NOT NULL REFERENCES parents (id));
+		INSERT INTO parents (id, code) VALUES (1, 'a');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  error
+	}{
+		{name: "unique", query: `INSERT INTO parents (code) VALUES ('a')`, want: ErrConflict},
+		{name: "primary key", query: `INSERT INTO parents (id) VALUES (1)`, want: ErrConflict},
+		{name: "foreign key", query: `INSERT INTO children (parent_id) VALUES (2)`, want: ErrConstraint},
+		{name: "not null", query: `INSERT INTO children (parent_id) VALUES (NULL)`, want: ErrConstraint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec(tt.query)
+			if got := dbError(context.Background(), err); !errors.Is(got, tt.want) {
+				t.Errorf("dbError(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// exportBatch is the number of rows an export fetches from its cursor at a
+// time.
+const exportBatch = 500
+
+// ExportEmployees reads the employees in a read-only transaction, so that
+// they all come from one snapshot: repeatable read on Postgres, where the
+// rows are fetched from a server-side cursor a batch at a time, and the
+// snapshot of the single query on SQLite. The query timeout applies to
+// each fetch rather than the whole export, and not at all on SQLite, whose
+// only connection the export holds until it ends.
+func (e *employeeDB) ExportEmployees(ctx context.Context, q ListQuery, fn func(Employee) error) error {
+	q.After, q.Before = nil, nil
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	table, args := employeesAt(q.AsOf, nil)
+	conds, args := q.filters(args)
+	query := fmt.Sprintf(`SELECT %s FROM %s %s %s`, employeeColumns, table, where(conds), q.orderBy())
+
+	opts := &sql.TxOptions{ReadOnly: true}
+	if e.dialect == Postgres {
+		opts.Isolation = sql.LevelRepeatableRead
+	}
+	tx, err := e.db.BeginTx(ctx, opts)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	// Nothing is written: ending the transaction only releases the snapshot.
+	defer tx.Rollback()
+	if e.dialect != Postgres {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		return eachEmployee(ctx, rows, fn)
+	}
+	if _, err := tx.ExecContext(ctx, `DECLARE export NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		return dbError(ctx, err)
+	}
+	for {
+		batch, err := e.fetch(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, employee := range batch {
+			if err := fn(employee); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatch {
+			return nil
+		}
+	}
+}
+
+// fetch reads the next batch of employees from the cursor of an export.
+// The batch is read before it is handed on, so that a slow consumer does
+// not count against the query timeout.
+func (e *employeeDB) fetch(ctx context.Context, tx *sql.Tx) ([]Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM export`, exportBatch))
+	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	batch := make([]Employee, 0, exportBatch)
+	err = eachEmployee(ctx, rows, func(employee Employee) error {
+		batch = append(batch, employee)
+		return nil
+	})
+	return batch, err
+}
+
+// eachEmployee calls fn with each employee of rows, and closes them.
+func eachEmployee(ctx context.Context, rows *sql.Rows, fn func(Employee) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		var employee Employee
+		if err := scanEmployee(rows, &employee); err != nil {
+			return dbError(ctx, err)
+		}
+		if err := fn(employee); err != nil {
+			return err
+		}
+	}
+	return dbError(ctx, rows.Err())
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportEmployees(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	edb := NewEmployee(db)
+
+	// A full batch is followed by another fetch, which comes back short.
+	batch := sqlmock.NewRows(mockColumns)
+	for id := 1; id <= exportBatch; id++ {
+		batch.AddRow(id, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE export NO SCROLL CURSOR FOR SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name `+
+		`FROM `+currentEmployees(1)+` WHERE deleted_at IS NULL AND LOWER\(position\) = \$2 ORDER BY id`).
+		WithArgs(dateValue(today()), "engineer").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 500 FROM export`).WillReturnRows(batch)
+	mock.ExpectQuery(`FETCH FORWARD 500 FROM export`).
+		WillReturnRows(sqlmock.NewRows(mockColumns).AddRow(exportBatch+1, "Jane Doe", "Engineer", 6000000, "USD", 1, nil, "", nil, nil, nil, nil, nil))
+	mock.ExpectRollback()
+
+	var ids []int
+	err := edb.ExportEmployees(context.Background(), ListQuery{Position: "Engineer"}, func(employee Employee) error {
+		ids = append(ids, employee.ID)
+		return nil
+	})
+	if err != nil || len(ids) != exportBatch+1 || ids[exportBatch] != exportBatch+1 {
+		t.Errorf("ExportEmployees() read %d employees, %v, want %d", len(ids), err, exportBatch+1)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
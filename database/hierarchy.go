@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Report is an employee under a manager, Depth levels down: 1 for a direct
+// report, 2 for theirs and so on.
+type Report struct {
+	Employee
+	Depth int
+}
+
+var (
+	// ErrUnknownDepartment is returned when an employee is assigned a
+	// department that does not exist.
+	ErrUnknownDepartment = fmt.Errorf("unknown department: %w", ErrConstraint)
+	// ErrUnknownManager is returned when an employee is assigned a manager
+	// that does not exist or is deleted.
+	ErrUnknownManager = fmt.Errorf("unknown manager: %w", ErrConstraint)
+	// ErrReportingCycle is returned when an employee is assigned a manager
+	// that reports to it, directly or not, or itself.
+	ErrReportingCycle = fmt.Errorf("reporting cycle: %w", ErrConstraint)
+	// ErrHasReports is returned when deleting an employee others still
+	// report to.
+	ErrHasReports = fmt.Errorf("employee has direct reports: %w", ErrConflict)
+)
+
+// hierarchyLockKey is the Postgres advisory lock held by the transactions
+// that change who reports to whom, so that two of them cannot each close
+// half of a cycle, or assign a report to a manager being deleted.
+const hierarchyLockKey = 7_321_004_118
+
+// lockHierarchy takes the hierarchy lock until tx ends. SQLite allows a
+// single writer, so there the transaction is enough.
+func (e *employeeDB) lockHierarchy(ctx context.Context, tx *sql.Tx) error {
+	if e.dialect != Postgres {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, hierarchyLockKey)
+	return dbError(ctx, err)
+}
+
+// checkRelations checks the department and manager employee is about to be
+// written with in tx. They are checked ahead of the write, whose foreign
+// keys would refuse a missing one without telling which.
+func (e *employeeDB) checkRelations(ctx context.Context, tx *sql.Tx, employee Employee) error {
+	if employee.DepartmentID != 0 {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id=$1)`, employee.DepartmentID).Scan(&exists); err != nil {
+			return dbError(ctx, err)
+		}
+		if !exists {
+			return ErrUnknownDepartment
+		}
+	}
+	if employee.ManagerID == 0 {
+		return nil
+	}
+	if err := e.lockHierarchy(ctx, tx); err != nil {
+		return err
+	}
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE id=$1 AND deleted_at IS NULL)`, employee.ManagerID).Scan(&exists)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	if !exists {
+		return ErrUnknownManager
+	}
+	var cycle bool
+	// The walk up from the new manager ends at the head of the hierarchy,
+	// which has no cycle yet, unless it comes across the employee.
+	err = tx.QueryRowContext(ctx, `WITH RECURSIVE chain (id) AS (
+			SELECT id FROM employees WHERE id=$1
+			UNION ALL
+			SELECT e.manager_id FROM employees e JOIN chain c ON e.id = c.id WHERE e.manager_id IS NOT NULL AND c.id <> $2
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE id=$2)`, employee.ManagerID, employee.ID).Scan(&cycle)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	if cycle {
+		return ErrReportingCycle
+	}
+	return nil
+}
+
+// checkNoReports refuses to delete employee id, locked in tx, while others
+// report to it.
+func (e *employeeDB) checkNoReports(ctx context.Context, tx *sql.Tx, id int) error {
+	if err := e.lockHierarchy(ctx, tx); err != nil {
+		return err
+	}
+	var reports bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE manager_id=$1 AND deleted_at IS NULL)`, id).Scan(&reports)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	if reports {
+		return ErrHasReports
+	}
+	return nil
+}
+
+func (e *employeeDB) ListDirectReports(ctx context.Context, managerID int) ([]Employee, error) {
+	reports, err := e.hierarchy(ctx, managerID, `SELECT `+employeeColumns+`, 1 FROM `+salaryOn("employees", 2, "")+` employees
+		WHERE manager_id=$1 AND deleted_at IS NULL ORDER BY id`)
+	return employees(reports), err
+}
+
+func (e *employeeDB) ListReportingChain(ctx context.Context, id int) ([]Employee, error) {
+	// Depth counts up from the employee here.
+	managers, err := e.hierarchy(ctx, id, `WITH RECURSIVE chain (id, depth) AS (
+			SELECT manager_id, 1 FROM employees WHERE id=$1
+			UNION ALL
+			SELECT e.manager_id, c.depth + 1 FROM employees e JOIN chain c ON e.id = c.id
+		)
+		SELECT `+qualified("e")+`, c.depth FROM `+salaryOn("employees", 2, "")+` e JOIN chain c ON e.id = c.id ORDER BY c.depth`)
+	return employees(managers), err
+}
+
+func (e *employeeDB) ListReports(ctx context.Context, managerID int) ([]Report, error) {
+	return e.hierarchy(ctx, managerID, `WITH RECURSIVE tree (id, depth) AS (
+			SELECT id, 1 FROM employees WHERE manager_id=$1 AND deleted_at IS NULL
+			UNION ALL
+			SELECT e.id, t.depth + 1 FROM employees e JOIN tree t ON e.manager_id = t.id WHERE e.deleted_at IS NULL
+		)
+		SELECT `+qualified("e")+`, t.depth FROM `+salaryOn("employees", 2, "")+` e JOIN tree t ON e.id = t.id ORDER BY t.depth, e.id`)
+}
+
+// hierarchy runs query, which selects employees and their depth relative to
+// employee id, once that employee is found. Its placeholders are id and
+// today, for salaryOn.
+func (e *employeeDB) hierarchy(ctx context.Context, id int, query string) ([]Report, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var reports []Report
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE id=$1 AND deleted_at IS NULL)`, id).Scan(&exists)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		if !exists {
+			return errEmployeeNotFound
+		}
+		rows, err := tx.QueryContext(ctx, query, id, dateValue(today()))
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var r Report
+			if err := scanEmployee(rows, &r.Employee, &r.Depth); err != nil {
+				return dbError(ctx, err)
+			}
+			reports = append(reports, r)
+		}
+		return dbError(ctx, rows.Err())
+	})
+	return reports, err
+}
+
+// employees strips the depth off reports.
+func employees(reports []Report) []Employee {
+	var list []Employee
+	for _, r := range reports {
+		list = append(list, r.Employee)
+	}
+	return list
+}
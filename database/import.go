@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ImportRow is an employee to import, with the reason its salary may be
+// outside the band of its position. Like a reason set by WithBandOverride,
+// it is only audited if the salary needed it.
+type ImportRow struct {
+	Employee     Employee
+	BandOverride string
+}
+
+// RowError is why an import refused the row at Index in its rows.
+type RowError struct {
+	Index int
+	Err   error
+}
+
+// ImportError lists every row an import refused, in order. Nothing is
+// imported when any row is refused.
+type ImportError struct {
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	first := e.Rows[0]
+	return fmt.Sprintf("%d rows refused, the first at index %d: %v", len(e.Rows), first.Index, first.Err)
+}
+
+// Unwrap exposes the error of each row, so that errors.Is(err,
+// ErrSalaryOutOfBand) reports whether a salary was out of band.
+func (e *ImportError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for i, r := range e.Rows {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// rowError reports whether err, from checking a row, refuses that row
+// rather than the whole import.
+func rowError(err error) bool {
+	return errors.Is(err, ErrConstraint)
+}
+
+// importColumns are the columns COPY fills for an imported employee.
+var importColumns = []string{"id", "name", "position", "salary_minor", "currency", "department_id", "manager_id", "position_id"}
+
+func (e *employeeDB) ImportEmployees(ctx context.Context, rows []ImportRow, dryRun bool) ([]Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var employees []Employee
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var audits []context.Context
+		var err error
+		if employees, audits, err = e.checkImport(ctx, tx, rows); err != nil || dryRun {
+			return err
+		}
+		if err := e.insertEmployees(ctx, tx, employees); err != nil {
+			return err
+		}
+		at := now()
+		versions := make([][]any, len(employees))
+		hires := make([][]any, len(employees))
+		entries := make([]AuditEntry, len(employees))
+		for i, employee := range employees {
+			versions[i] = []any{employee.ID, employee.Name, employee.Position, employee.Salary.Amount, employee.Salary.Currency, employee.Version,
+				nil, "", nullID(employee.DepartmentID), nullID(employee.ManagerID), nullID(employee.PositionID), nil, nil, at}
+			hires[i] = []any{employee.ID, employee.Salary.Amount, employee.Salary.Currency, dateValue(today()), SalaryHire, BandOverrideFrom(audits[i]), ActorFrom(ctx), at}
+			entries[i] = newAuditEntry(audits[i], AuditCreate, nil, &employees[i])
+		}
+		if err := e.copyRows(ctx, tx, "employee_versions", append(strings.Split(employeeColumns, ", "), "valid_from"), versions); err != nil {
+			return err
+		}
+		hireColumns := []string{"employee_id", "salary_minor", "currency", "effective_from", "reason", "band_override", "created_by", "created_at"}
+		if err := e.copyRows(ctx, tx, "salary_history", hireColumns, hires); err != nil {
+			return err
+		}
+		return e.auditAll(ctx, tx, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
+
+// checkImport runs the checks of CreateEmployee on every row in tx. It
+// returns the employees to create, with the titles of their positions, and
+// the contexts to audit each with.
+func (e *employeeDB) checkImport(ctx context.Context, tx *sql.Tx, rows []ImportRow) ([]Employee, []context.Context, error) {
+	// Imports tend to repeat a few departments, managers and positions.
+	relations := make(map[[2]int]error)
+	type lookup struct {
+		position Position
+		err      error
+	}
+	positions := make(map[int]lookup)
+	type titleLookup struct {
+		id  int
+		err error
+	}
+	titles := make(map[string]titleLookup)
+
+	var refused []RowError
+	employees := make([]Employee, len(rows))
+	audits := make([]context.Context, len(rows))
+	for i, row := range rows {
+		employee := row.Employee
+		key := [2]int{employee.DepartmentID, employee.ManagerID}
+		err, checked := relations[key]
+		if !checked {
+			err = e.checkRelations(ctx, tx, Employee{DepartmentID: employee.DepartmentID, ManagerID: employee.ManagerID})
+			relations[key] = err
+		}
+		audits[i] = WithBandOverride(ctx, "")
+		if err == nil && employee.PositionID == 0 {
+			title := strings.ToLower(employee.Position)
+			l, ok := titles[title]
+			if !ok {
+				l.id, l.err = e.positionTitled(ctx, tx, employee.Position)
+				titles[title] = l
+			}
+			employee.PositionID, err = l.id, l.err
+		}
+		if err == nil && employee.PositionID != 0 {
+			l, ok := positions[employee.PositionID]
+			if !ok {
+				l.position, l.err = e.position(ctx, tx, employee.PositionID)
+				positions[employee.PositionID] = l
+			}
+			if err = l.err; err == nil {
+				employee.Position = l.position.Title
+				audits[i], err = checkBand(WithBandOverride(ctx, row.BandOverride), l.position, employee.Salary)
+			}
+		}
+		switch {
+		case err == nil:
+			employees[i] = employee
+		case rowError(err):
+			refused = append(refused, RowError{Index: i, Err: err})
+		default:
+			return nil, nil, err
+		}
+	}
+	if refused != nil {
+		return nil, nil, &ImportError{Rows: refused}
+	}
+	return employees, audits, nil
+}
+
+// insertEmployees inserts employees in tx and sets their IDs and versions.
+// On Postgres the IDs are drawn from the sequence ahead of a single COPY.
+func (e *employeeDB) insertEmployees(ctx context.Context, tx *sql.Tx, employees []Employee) error {
+	if e.dialect != Postgres {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO employees (`+strings.Join(importColumns[1:], ", ")+`) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, version`)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer stmt.Close()
+		for i, employee := range employees {
+			err := stmt.QueryRowContext(ctx, employee.Name, employee.Position, employee.Salary.Amount, employee.Salary.Currency,
+				nullID(employee.DepartmentID), nullID(employee.ManagerID), nullID(employee.PositionID)).Scan(&employees[i].ID, &employees[i].Version)
+			if err != nil {
+				return dbError(ctx, err)
+			}
+		}
+		return nil
+	}
+
+	ids, err := tx.QueryContext(ctx, `SELECT nextval(pg_get_serial_sequence('employees', 'id')) FROM generate_series(1, $1)`, len(employees))
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	for i := 0; ids.Next() && i < len(employees); i++ {
+		if err := ids.Scan(&employees[i].ID); err != nil {
+			ids.Close()
+			return dbError(ctx, err)
+		}
+		employees[i].Version = 1
+	}
+	ids.Close()
+	if err := ids.Err(); err != nil {
+		return dbError(ctx, err)
+	}
+	values := make([][]any, len(employees))
+	for i, employee := range employees {
+		values[i] = []any{employee.ID, employee.Name, employee.Position, employee.Salary.Amount, employee.Salary.Currency,
+			nullID(employee.DepartmentID), nullID(employee.ManagerID), nullID(employee.PositionID)}
+	}
+	return e.copyRows(ctx, tx, "employees", importColumns, values)
+}
+
+// copyRows inserts rows of values for columns into table in tx: with COPY
+// on Postgres, where it beats an INSERT per row by far, and a prepared
+// INSERT on SQLite.
+func (e *employeeDB) copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+	query := pq.CopyIn(table, columns...)
+	if e.dialect != Postgres {
+		placeholders := make([]string, len(columns))
+		for i := range placeholders {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		query = fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return dbError(ctx, err)
+		}
+	}
+	if e.dialect == Postgres {
+		// An Exec without values ends the COPY.
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			return dbError(ctx, err)
+		}
+	}
+	return nil
+}
+
+// auditAll records entries in tx like audit does one at a time, chaining
+// each to the one before.
+func (e *employeeDB) auditAll(ctx context.Context, tx *sql.Tx, entries []AuditEntry) error {
+	prev, err := e.lastAuditHash(ctx, tx)
+	if err != nil {
+		return err
+	}
+	rows := make([][]any, len(entries))
+	for i, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		entry.Hash = chainHash(prev, entry)
+		prev = entry.Hash
+		rows[i] = []any{entry.EmployeeID, entry.Action, entry.Actor, entry.RequestID, string(changes), entry.At, entry.Hash}
+	}
+	columns := []string{"employee_id", "action", "actor", "request_id", "changes", "created_at", "hash"}
+	return e.copyRows(ctx, tx, "audit_log", columns, rows)
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectCopy expects a COPY of rows into table, followed by the Exec that
+// ends it.
+func expectCopy(mock sqlmock.Sqlmock, table string, rows int) {
+	prepare := mock.ExpectPrepare(`COPY "` + table + `"`)
+	for range rows {
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, int64(rows)))
+}
+
+func TestImportEmployees(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	edb := NewEmployee(db)
+	rows := []ImportRow{
+		{Employee: Employee{Name: "John Doe", Position: "Engineer", Salary: usd(50000)}},
+		{Employee: Employee{Name: "Jane Doe", Position: "Manager", Salary: usd(60000), DepartmentID: 3}},
+	}
+
+	t.Run("copies every row in one transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectNoCatalog(mock, "Engineer")
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM departments WHERE id=\$1\)`).WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		expectNoCatalog(mock, "Manager")
+		mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('employees', 'id'\)\) FROM generate_series\(1, \$1\)`).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(7).AddRow(8))
+		expectCopy(mock, "employees", 2)
+		expectCopy(mock, "employee_versions", 2)
+		expectCopy(mock, "salary_history", 2)
+		mock.ExpectExec(`LOCK TABLE audit_log IN EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+		expectCopy(mock, "audit_log", 2)
+		mock.ExpectCommit()
+
+		employees, err := edb.ImportEmployees(context.Background(), rows, false)
+		if err != nil || len(employees) != 2 || employees[0].ID != 7 || employees[1].ID != 8 || employees[1].Version != 1 {
+			t.Errorf("ImportEmployees() = %+v, %v, want employees 7 and 8", employees, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("dry run only checks", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectNoCatalog(mock, "Engineer")
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM departments WHERE id=\$1\)`).WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		_, err := edb.ImportEmployees(context.Background(), rows, true)
+		var importErr *ImportError
+		if !errors.As(err, &importErr) || len(importErr.Rows) != 1 || importErr.Rows[0].Index != 1 || !errors.Is(err, ErrUnknownDepartment) {
+			t.Errorf("ImportEmployees() error = %v, want row 1 refused for its department", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
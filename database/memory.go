@@ -0,0 +1,1147 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryDB is an in-memory EmployeeDB. It mirrors the behaviour of the
+// Postgres implementation and is intended for tests and local development.
+// Operations never block, so a context is only checked on entry.
+type memoryDB struct {
+	mu          sync.RWMutex
+	nextID      int
+	employees   map[int]Employee
+	audit       []AuditEntry
+	checkpoints []AuditCheckpoint
+	// salaries holds the salary history of each employee, by effective
+	// date.
+	salaries     map[int][]SalaryChange
+	nextSalaryID int64
+	// versions holds every version of each employee, oldest first,
+	// including the purged ones.
+	versions         map[int][]employeeVersion
+	departments      map[int]Department
+	nextDepartmentID int
+	positions        map[int]Position
+	nextPositionID   int
+}
+
+// employeeVersion is an employee as it was from from until to, or until now
+// while to is zero.
+type employeeVersion struct {
+	Employee
+	from, to time.Time
+}
+
+func NewMemoryEmployee() EmployeeDB {
+	return &memoryDB{nextID: 1, employees: make(map[int]Employee), salaries: make(map[int][]SalaryChange), versions: make(map[int][]employeeVersion),
+		departments: make(map[int]Department), nextDepartmentID: 1, positions: make(map[int]Position), nextPositionID: 1}
+}
+
+func (m *memoryDB) CreateEmployee(ctx context.Context, employee Employee) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := employee.DeletedAt != nil
+	employee.DeletedAt, employee.DeletedBy = nil, ""
+	if err := m.checkRelations(employee); err != nil {
+		return Employee{}, err
+	}
+	if err := m.checkIdentity(employee); err != nil {
+		return Employee{}, err
+	}
+	wctx, err := m.checkPosition(ctx, nil, &employee)
+	if err != nil {
+		return Employee{}, err
+	}
+	employee.ID = m.nextID
+	employee.Version = 1
+	m.nextID++
+	m.put(employee)
+	m.addSalary(ctx, SalaryChange{EmployeeID: employee.ID, Salary: employee.Salary, EffectiveFrom: today(), Reason: SalaryHire, BandOverride: BandOverrideFrom(wctx)}, false)
+	m.record(wctx, AuditCreate, nil, &employee)
+	if deleted {
+		employee = m.markDeleted(ctx, employee)
+	}
+	return employee, nil
+}
+
+func (m *memoryDB) GetEmployeeByID(ctx context.Context, id int) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	employee, err := m.lookup(id, 0)
+	if err != nil {
+		return Employee{}, err
+	}
+	return m.withSalary(employee, time.Time{}), nil
+}
+
+func (m *memoryDB) GetEmployeesByIDs(ctx context.Context, ids []int) ([]Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var employees []Employee
+	for _, id := range ids {
+		if employee, err := m.lookup(id, 0); err == nil {
+			employees = append(employees, m.withSalary(employee, time.Time{}))
+		}
+	}
+	return employees, nil
+}
+
+func (m *memoryDB) GetEmployeeAsOf(ctx context.Context, id int, asOf time.Time) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	employee, ok := m.versionAsOf(id, asOf)
+	if !ok || employee.DeletedAt != nil {
+		return Employee{}, errEmployeeNotFound
+	}
+	return m.withSalary(employee, asOf), nil
+}
+
+// put stores employee as its version current from now, in the manner of
+// employeeDB.recordVersion. The caller must hold m.mu.
+func (m *memoryDB) put(employee Employee) {
+	at := now()
+	versions := m.versions[employee.ID]
+	if n := len(versions); n > 0 {
+		versions[n-1].to = at
+	}
+	m.employees[employee.ID] = employee
+	m.versions[employee.ID] = append(versions, employeeVersion{Employee: employee, from: at})
+}
+
+// versionAsOf returns the version of employee id that was current at asOf.
+// The caller must hold m.mu.
+func (m *memoryDB) versionAsOf(id int, asOf time.Time) (Employee, bool) {
+	for _, v := range m.versions[id] {
+		if !v.from.After(asOf) && (v.to.IsZero() || v.to.After(asOf)) {
+			return v.Employee, true
+		}
+	}
+	return Employee{}, false
+}
+
+func (m *memoryDB) UpdateEmployee(ctx context.Context, employee Employee) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, err := m.lookup(employee.ID, employee.Version)
+	if err != nil {
+		return Employee{}, err
+	}
+	// The update starts from the salary reads show, as in employeeDB.updateTx.
+	due := m.withSalary(stored, time.Time{})
+	if err := m.checkRelations(employee); err != nil {
+		return Employee{}, err
+	}
+	wctx, err := m.checkPosition(ctx, &due, &employee)
+	if err != nil {
+		return Employee{}, err
+	}
+	current := m.catchUpSalary(ctx, stored)
+	employee.ExternalID, employee.UserName = current.ExternalID, current.UserName
+	employee.Version = current.Version + 1
+	m.put(employee)
+	m.adjustSalary(wctx, current, employee)
+	m.record(wctx, AuditUpdate, &current, &employee)
+	return employee, nil
+}
+
+func (m *memoryDB) PatchEmployee(ctx context.Context, id int, changes EmployeeChanges) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, err := m.lookup(id, changes.Version)
+	if changes.Deleted != nil {
+		// Deleted employees can be written too.
+		stored, err = m.employees[id], nil
+		switch {
+		case stored.ID == 0:
+			err = errEmployeeNotFound
+		case changes.Version != 0 && changes.Version != stored.Version:
+			err = errEmployeeChanged
+		}
+	}
+	if err != nil {
+		return Employee{}, err
+	}
+	deleted := stored.DeletedAt != nil
+	wantDeleted := deleted
+	if changes.Deleted != nil {
+		wantDeleted = *changes.Deleted
+	}
+	unchanged := changes == (EmployeeChanges{Version: changes.Version, Deleted: changes.Deleted})
+	if unchanged && deleted == wantDeleted {
+		if changes.Deleted == nil {
+			return m.withSalary(stored, time.Time{}), nil
+		}
+		return stored, nil
+	}
+	// As in UpdateEmployee, unless the employee stays deleted.
+	catchUp := !unchanged && (!deleted || !wantDeleted)
+	due := stored
+	if catchUp {
+		due = m.withSalary(stored, time.Time{})
+	}
+	employee := due
+	if changes.Name != nil {
+		employee.Name = *changes.Name
+	}
+	if changes.Position != nil {
+		employee.Position = *changes.Position
+	}
+	if changes.Salary != nil {
+		employee.Salary = *changes.Salary
+	}
+	if changes.DepartmentID != nil {
+		employee.DepartmentID = *changes.DepartmentID
+	}
+	if changes.ManagerID != nil {
+		employee.ManagerID = *changes.ManagerID
+	}
+	if changes.PositionID != nil {
+		employee.PositionID = *changes.PositionID
+	}
+	if changes.ExternalID != nil {
+		employee.ExternalID = *changes.ExternalID
+	}
+	if changes.UserName != nil {
+		employee.UserName = *changes.UserName
+	}
+	// Every step is checked before the first is written, so that they are
+	// all or nothing, like the transaction of employeeDB.patchDeleted.
+	if (deleted && !wantDeleted) || employee.DepartmentID != stored.DepartmentID || employee.ManagerID != stored.ManagerID {
+		if err := m.checkRelations(employee); err != nil {
+			return Employee{}, err
+		}
+	}
+	if err := m.checkIdentity(employee); err != nil {
+		return Employee{}, err
+	}
+	wctx, err := m.checkPosition(ctx, &due, &employee)
+	if err != nil {
+		return Employee{}, err
+	}
+	if !deleted && wantDeleted && m.hasReports(id) {
+		return Employee{}, ErrHasReports
+	}
+
+	current := stored
+	if deleted && !wantDeleted {
+		current = m.markRestored(ctx, current)
+	}
+	if !unchanged {
+		if catchUp {
+			current = m.catchUpSalary(ctx, current)
+		}
+		employee.DeletedAt, employee.DeletedBy = current.DeletedAt, current.DeletedBy
+		employee.Version = current.Version + 1
+		m.put(employee)
+		m.adjustSalary(wctx, current, employee)
+		m.record(wctx, AuditUpdate, &current, &employee)
+		current = employee
+	}
+	if !deleted && wantDeleted {
+		current = m.markDeleted(ctx, current)
+	}
+	return current, nil
+}
+
+func (m *memoryDB) DeleteEmployee(ctx context.Context, id int, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, err := m.lookup(id, version)
+	if err != nil {
+		return err
+	}
+	if m.hasReports(id) {
+		return ErrHasReports
+	}
+	m.markDeleted(ctx, stored)
+	return nil
+}
+
+// hasReports reports whether employees that are not deleted report to
+// employee id. The caller must hold m.mu.
+func (m *memoryDB) hasReports(id int) bool {
+	for _, other := range m.employees {
+		if other.ManagerID == id && other.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// markDeleted stores stored deleted by the actor of ctx, and markRestored
+// stores it no longer deleted; the caller checks they may. The caller must
+// hold m.mu.
+func (m *memoryDB) markDeleted(ctx context.Context, stored Employee) Employee {
+	employee := stored
+	deletedAt := now()
+	employee.DeletedAt = &deletedAt
+	employee.DeletedBy = ActorFrom(ctx)
+	employee.Version++
+	m.put(employee)
+	m.record(WithBandOverride(ctx, ""), AuditDelete, &stored, &employee)
+	return employee
+}
+
+func (m *memoryDB) markRestored(ctx context.Context, stored Employee) Employee {
+	employee := stored
+	employee.DeletedAt = nil
+	employee.DeletedBy = ""
+	employee.Version++
+	m.put(employee)
+	m.record(WithBandOverride(ctx, ""), AuditRestore, &stored, &employee)
+	return employee
+}
+
+func (m *memoryDB) RestoreEmployee(ctx context.Context, id int) (Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return Employee{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.employees[id]
+	switch {
+	case !ok:
+		return Employee{}, errEmployeeNotFound
+	case stored.DeletedAt == nil:
+		return Employee{}, ErrNotDeleted
+	}
+	if err := m.checkRelations(stored); err != nil {
+		return Employee{}, err
+	}
+	return m.markRestored(ctx, stored), nil
+}
+
+func (m *memoryDB) PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged []Employee
+	at := now()
+	for id, employee := range m.employees {
+		if employee.DeletedAt != nil && employee.DeletedAt.Before(deletedBefore) {
+			delete(m.employees, id)
+			delete(m.salaries, id)
+			// The versions are kept for reads as of earlier times.
+			versions := m.versions[id]
+			versions[len(versions)-1].to = at
+			purged = append(purged, employee)
+		}
+	}
+	// The reports of purged managers lose them, like ON DELETE SET NULL.
+	for id, employee := range m.employees {
+		if _, ok := m.employees[employee.ManagerID]; employee.ManagerID != 0 && !ok {
+			employee.ManagerID = 0
+			m.employees[id] = employee
+		}
+	}
+	slices.SortFunc(purged, func(a, b Employee) int { return cmp.Compare(a.ID, b.ID) })
+	for i := range purged {
+		m.record(ctx, AuditPurge, &purged[i], nil)
+	}
+	return len(purged), nil
+}
+
+// adjustSalary records a salary set directly on an update, effective today,
+// with the override reason of ctx, the context the update is audited with.
+// The caller must hold m.mu.
+func (m *memoryDB) adjustSalary(ctx context.Context, before, after Employee) {
+	if after.Salary != before.Salary {
+		m.addSalary(ctx, SalaryChange{EmployeeID: after.ID, Salary: after.Salary, EffectiveFrom: today(), Reason: SalaryAdjustment, BandOverride: BandOverrideFrom(ctx)}, true)
+	}
+}
+
+// addSalary stores change in the salary history, in the manner of
+// employeeDB.insertSalaryChange. The caller must hold m.mu.
+func (m *memoryDB) addSalary(ctx context.Context, change SalaryChange, replace bool) (SalaryChange, error) {
+	change.CreatedBy = ActorFrom(ctx)
+	change.CreatedAt = now()
+	changes := m.salaries[change.EmployeeID]
+	i, found := slices.BinarySearchFunc(changes, change.EffectiveFrom, func(c SalaryChange, date time.Time) int {
+		return c.EffectiveFrom.Compare(date)
+	})
+	switch {
+	case found && !replace:
+		return SalaryChange{}, fmt.Errorf("%w: a salary change is already effective %s", ErrConflict, dateValue(change.EffectiveFrom))
+	case found:
+		change.ID = changes[i].ID
+		changes[i] = change
+	default:
+		m.nextSalaryID++
+		change.ID = m.nextSalaryID
+		m.salaries[change.EmployeeID] = slices.Insert(changes, i, change)
+	}
+	return change, nil
+}
+
+func (m *memoryDB) ListSalaryChanges(ctx context.Context, employeeID int) ([]SalaryChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, err := m.lookup(employeeID, 0); err != nil {
+		return nil, err
+	}
+	return slices.Clone(m.salaries[employeeID]), nil
+}
+
+func (m *memoryDB) AddSalaryChange(ctx context.Context, change SalaryChange) (SalaryChange, error) {
+	if err := ctx.Err(); err != nil {
+		return SalaryChange{}, err
+	}
+	if err := change.Validate(); err != nil {
+		return SalaryChange{}, err
+	}
+	change.EffectiveFrom = Date(change.EffectiveFrom)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	employee, err := m.lookup(change.EmployeeID, 0)
+	if err != nil {
+		return SalaryChange{}, err
+	}
+	wctx, err := m.checkSalary(ctx, employee, change.Salary)
+	if err != nil {
+		return SalaryChange{}, err
+	}
+	change.BandOverride = BandOverrideFrom(wctx)
+	previous := slices.Clone(m.salaries[employee.ID])
+	if change, err = m.addSalary(ctx, change, false); err != nil {
+		return SalaryChange{}, err
+	}
+	if _, err := m.applySalary(ctx, employee, today()); err != nil {
+		// Undone, as the transaction of employeeDB would be.
+		m.salaries[employee.ID] = previous
+		return SalaryChange{}, err
+	}
+	return change, nil
+}
+
+// checkSalary checks salary against the band of the position of employee,
+// as checkBand does. The caller must hold m.mu.
+func (m *memoryDB) checkSalary(ctx context.Context, employee Employee, salary Money) (context.Context, error) {
+	if employee.PositionID == 0 {
+		return WithBandOverride(ctx, ""), nil
+	}
+	position, ok := m.positions[employee.PositionID]
+	if !ok {
+		return ctx, ErrUnknownPosition
+	}
+	return checkBand(ctx, position, salary)
+}
+
+// applySalary sets the salary of employee to the one in effect on asOf, if
+// that differs, and reports whether it did. The band of the position is
+// checked again, with the override reason the change was added with. The
+// caller must hold m.mu.
+func (m *memoryDB) applySalary(ctx context.Context, stored Employee, asOf time.Time) (bool, error) {
+	current, ok := effectiveSalary(m.salaries[stored.ID], asOf)
+	if !ok || current.Salary == stored.Salary {
+		return false, nil
+	}
+	wctx, err := m.checkSalary(WithBandOverride(ctx, current.BandOverride), stored, current.Salary)
+	if err != nil {
+		return false, err
+	}
+	employee := stored
+	employee.Salary = current.Salary
+	employee.Version++
+	m.put(employee)
+	m.record(wctx, AuditUpdate, &stored, &employee)
+	return true, nil
+}
+
+// salaryAt returns the change that sets the salary of employee now, or at
+// asOf unless it is zero, as employeesAt picks it. The caller must hold
+// m.mu.
+func (m *memoryDB) salaryAt(employee Employee, asOf time.Time) (SalaryChange, bool) {
+	changes, on := m.salaries[employee.ID], today()
+	if !asOf.IsZero() {
+		// Changes recorded after asOf were not known then.
+		changes = slices.DeleteFunc(slices.Clone(changes), func(c SalaryChange) bool { return c.CreatedAt.After(asOf) })
+		on = Date(asOf)
+	}
+	return salaryInEffect(changes, on, m.positions[employee.PositionID])
+}
+
+// withSalary returns employee with its salary as salaryAt picks it. The
+// caller must hold m.mu.
+func (m *memoryDB) withSalary(employee Employee, asOf time.Time) Employee {
+	if current, ok := m.salaryAt(employee, asOf); ok {
+		employee.Salary = current.Salary
+	}
+	return employee
+}
+
+// catchUpSalary is the in-memory equivalent of employeeDB.catchUpSalary.
+// The caller must hold m.mu.
+func (m *memoryDB) catchUpSalary(ctx context.Context, stored Employee) Employee {
+	current, ok := m.salaryAt(stored, time.Time{})
+	if !ok || current.Salary == stored.Salary {
+		return stored
+	}
+	wctx, _ := checkBand(WithBandOverride(ctx, current.BandOverride), m.positions[stored.PositionID], current.Salary)
+	employee := stored
+	employee.Salary = current.Salary
+	employee.Version++
+	m.put(employee)
+	m.record(wctx, AuditUpdate, &stored, &employee)
+	return employee
+}
+
+func (m *memoryDB) ApplySalaryChanges(ctx context.Context, asOf time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int, 0, len(m.employees))
+	for id := range m.employees {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	applied := 0
+	var outOfBand []error
+	for _, id := range ids {
+		employee := m.employees[id]
+		if employee.DeletedAt != nil {
+			continue
+		}
+		switch ok, err := m.applySalary(ctx, employee, Date(asOf)); {
+		case err != nil:
+			outOfBand = append(outOfBand, fmt.Errorf("employee %d: %w", id, err))
+		case ok:
+			applied++
+		}
+	}
+	return applied, errors.Join(outOfBand...)
+}
+
+// record appends the write of action that turned before into after to the
+// audit log. The caller must hold m.mu.
+func (m *memoryDB) record(ctx context.Context, action string, before, after *Employee) {
+	entry := newAuditEntry(ctx, action, before, after)
+	entry.ID = int64(len(m.audit) + 1)
+	var prev string
+	if len(m.audit) > 0 {
+		prev = m.audit[len(m.audit)-1].Hash
+	}
+	entry.Hash = chainHash(prev, entry)
+	m.audit = append(m.audit, entry)
+}
+
+func (m *memoryDB) CheckpointAudit(ctx context.Context, key ed25519.PrivateKey) (AuditCheckpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return AuditCheckpoint{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.audit) == 0 {
+		return AuditCheckpoint{}, errNothingToCheckpoint
+	}
+	last := m.audit[len(m.audit)-1]
+	if len(m.checkpoints) > 0 && m.checkpoints[len(m.checkpoints)-1].EntryID >= last.ID {
+		return AuditCheckpoint{}, errNothingToCheckpoint
+	}
+	checkpoint := newAuditCheckpoint(last.ID, last.Hash, key)
+	checkpoint.ID = int64(len(m.checkpoints) + 1)
+	m.checkpoints = append(m.checkpoints, checkpoint)
+	return checkpoint, nil
+}
+
+func (m *memoryDB) ListAuditCheckpoints(ctx context.Context) ([]AuditCheckpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.checkpoints), nil
+}
+
+func (m *memoryDB) ListAuditEntries(ctx context.Context, q AuditQuery) (AuditPage, error) {
+	if err := ctx.Err(); err != nil {
+		return AuditPage{}, err
+	}
+	if err := q.Validate(); err != nil {
+		return AuditPage{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var page AuditPage
+	for _, entry := range m.audit {
+		if !q.matches(entry) {
+			continue
+		}
+		page.Entries = append(page.Entries, entry)
+		if len(page.Entries) > q.Limit {
+			break
+		}
+	}
+	page.trim(q)
+	return page, nil
+}
+
+// lookup returns employee id, checking its version unless version is zero.
+// Deleted employees are not found. The caller must hold m.mu.
+func (m *memoryDB) lookup(id, version int) (Employee, error) {
+	employee, ok := m.employees[id]
+	switch {
+	case !ok, employee.DeletedAt != nil:
+		return Employee{}, errEmployeeNotFound
+	case version != 0 && version != employee.Version:
+		return Employee{}, errEmployeeChanged
+	}
+	return employee, nil
+}
+
+// ListEmployees follows the SQL query: rows are filtered, ordered and paged
+// like ListQuery describes.
+func (m *memoryDB) ListEmployees(ctx context.Context, q ListQuery) (EmployeePage, error) {
+	if err := ctx.Err(); err != nil {
+		return EmployeePage{}, err
+	}
+	if err := q.Validate(); err != nil {
+		return EmployeePage{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	matched := m.matching(q)
+
+	var page EmployeePage
+	if !q.SkipTotal {
+		page.Total = len(matched)
+	}
+	rows := matched
+	switch {
+	case q.After != nil:
+		i, _ := slices.BinarySearchFunc(matched, *q.After, q.compare)
+		if i < len(matched) && q.compare(matched[i], *q.After) == 0 {
+			i++
+		}
+		rows = matched[i:]
+	case q.Before != nil:
+		i, _ := slices.BinarySearchFunc(matched, *q.Before, q.compare)
+		rows = slices.Clone(matched[:i])
+		slices.Reverse(rows)
+	default:
+		rows = matched[min(max((q.Page-1)*q.PerPage+q.Offset, 0), len(matched)):]
+	}
+	page.Employees = slices.Clone(rows[:min(q.PerPage+1, len(rows))])
+	page.trim(q)
+	return page, nil
+}
+
+// ExportEmployees takes its snapshot by copying the matching employees, as
+// the store holds them all in memory anyway.
+func (m *memoryDB) ExportEmployees(ctx context.Context, q ListQuery, fn func(Employee) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.After, q.Before = nil, nil
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	matched := m.matching(q)
+	m.mu.RUnlock()
+	for _, employee := range matched {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(employee); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matching returns the employees matching the filters of q, in its order.
+// The caller must hold m.mu.
+func (m *memoryDB) matching(q ListQuery) []Employee {
+	var matched []Employee
+	if q.AsOf.IsZero() {
+		for _, employee := range m.employees {
+			if employee = m.withSalary(employee, q.AsOf); q.matches(employee) {
+				matched = append(matched, employee)
+			}
+		}
+	} else {
+		// Purged employees have versions but no current row.
+		for id := range m.versions {
+			if employee, ok := m.versionAsOf(id, q.AsOf); ok {
+				if employee = m.withSalary(employee, q.AsOf); q.matches(employee) {
+					matched = append(matched, employee)
+				}
+			}
+		}
+	}
+	slices.SortFunc(matched, q.compare)
+	return matched
+}
+
+// matches is the in-memory equivalent of ListQuery.where.
+func (q ListQuery) matches(e Employee) bool {
+	name, position := strings.ToLower(e.Name), strings.ToLower(e.Position)
+	switch {
+	case !q.IncludeDeleted && e.DeletedAt != nil,
+		q.Name != "" && !strings.HasPrefix(name, strings.ToLower(q.Name)),
+		q.Position != "" && position != strings.ToLower(q.Position),
+		q.Search != "" && !strings.Contains(name, strings.ToLower(q.Search)) && !strings.Contains(position, strings.ToLower(q.Search)),
+		q.DepartmentID != 0 && e.DepartmentID != q.DepartmentID,
+		len(q.ManagerIDs) > 0 && !slices.Contains(q.ManagerIDs, e.ManagerID),
+		q.Currency != "" && e.Salary.Currency != q.Currency,
+		q.MinSalary != nil && e.Salary.Amount < *q.MinSalary,
+		q.MaxSalary != nil && e.Salary.Amount > *q.MaxSalary,
+		q.ID != 0 && e.ID != q.ID,
+		q.ExternalID != "" && e.ExternalID != q.ExternalID,
+		q.UserName != "" && !strings.EqualFold(e.UserName, q.UserName),
+		q.HasUserName && e.UserName == "":
+		return false
+	}
+	return true
+}
+
+// compare is the in-memory equivalent of ListQuery.orderBy.
+func (q ListQuery) compare(a, b Employee) int {
+	for _, s := range q.Sort {
+		var c int
+		switch s.Field {
+		case "id":
+			c = cmp.Compare(a.ID, b.ID)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "position":
+			c = strings.Compare(a.Position, b.Position)
+		case "salary":
+			c = cmp.Or(strings.Compare(a.Salary.Currency, b.Salary.Currency), cmp.Compare(a.Salary.Amount, b.Salary.Amount))
+		}
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// checkRelations is the in-memory equivalent of employeeDB.checkRelations,
+// run before employee is stored. The caller must hold m.mu.
+func (m *memoryDB) checkRelations(employee Employee) error {
+	if _, ok := m.departments[employee.DepartmentID]; employee.DepartmentID != 0 && !ok {
+		return ErrUnknownDepartment
+	}
+	if employee.ManagerID == 0 {
+		return nil
+	}
+	if _, err := m.lookup(employee.ManagerID, 0); err != nil {
+		return ErrUnknownManager
+	}
+	for id := employee.ManagerID; id != 0; id = m.employees[id].ManagerID {
+		if id == employee.ID {
+			return ErrReportingCycle
+		}
+	}
+	return nil
+}
+
+func (m *memoryDB) ListDirectReports(ctx context.Context, managerID int) ([]Employee, error) {
+	reports, err := m.ListReports(ctx, managerID)
+	var direct []Employee
+	for _, r := range reports {
+		if r.Depth == 1 {
+			direct = append(direct, r.Employee)
+		}
+	}
+	return direct, err
+}
+
+func (m *memoryDB) ListReportingChain(ctx context.Context, id int) ([]Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	employee, err := m.lookup(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	var chain []Employee
+	for employee.ManagerID != 0 {
+		employee = m.employees[employee.ManagerID]
+		chain = append(chain, m.withSalary(employee, time.Time{}))
+	}
+	return chain, nil
+}
+
+func (m *memoryDB) ListReports(ctx context.Context, managerID int) ([]Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, err := m.lookup(managerID, 0); err != nil {
+		return nil, err
+	}
+	var reports []Report
+	level := []int{managerID}
+	for depth := 1; len(level) > 0; depth++ {
+		var below []Report
+		for _, employee := range m.employees {
+			if employee.DeletedAt == nil && slices.Contains(level, employee.ManagerID) {
+				below = append(below, Report{Employee: m.withSalary(employee, time.Time{}), Depth: depth})
+			}
+		}
+		slices.SortFunc(below, func(a, b Report) int { return cmp.Compare(a.ID, b.ID) })
+		reports = append(reports, below...)
+		level = level[:0]
+		for _, r := range below {
+			level = append(level, r.ID)
+		}
+	}
+	return reports, nil
+}
+
+func (m *memoryDB) CreateDepartment(ctx context.Context, department Department) (Department, error) {
+	if err := ctx.Err(); err != nil {
+		return Department{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkDepartmentName(department); err != nil {
+		return Department{}, err
+	}
+	department.ID = m.nextDepartmentID
+	m.nextDepartmentID++
+	m.departments[department.ID] = department
+	return department, nil
+}
+
+func (m *memoryDB) GetDepartment(ctx context.Context, id int) (Department, error) {
+	if err := ctx.Err(); err != nil {
+		return Department{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	department, ok := m.departments[id]
+	if !ok {
+		return Department{}, errDepartmentNotFound
+	}
+	return department, nil
+}
+
+func (m *memoryDB) ListDepartments(ctx context.Context) ([]Department, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var departments []Department
+	for _, department := range m.departments {
+		departments = append(departments, department)
+	}
+	slices.SortFunc(departments, func(a, b Department) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return departments, nil
+}
+
+func (m *memoryDB) UpdateDepartment(ctx context.Context, department Department) (Department, error) {
+	if err := ctx.Err(); err != nil {
+		return Department{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.departments[department.ID]; !ok {
+		return Department{}, errDepartmentNotFound
+	}
+	if err := m.checkDepartmentName(department); err != nil {
+		return Department{}, err
+	}
+	m.departments[department.ID] = department
+	return department, nil
+}
+
+func (m *memoryDB) DeleteDepartment(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.departments[id]; !ok {
+		return errDepartmentNotFound
+	}
+	for _, employee := range m.employees {
+		if employee.DepartmentID == id {
+			return ErrDepartmentInUse
+		}
+	}
+	delete(m.departments, id)
+	return nil
+}
+
+// checkIdentity stands in for the unique external IDs and user names of
+// employees, deleted or not. The caller must hold m.mu.
+func (m *memoryDB) checkIdentity(employee Employee) error {
+	for _, other := range m.employees {
+		switch {
+		case other.ID == employee.ID:
+		case employee.ExternalID != "" && other.ExternalID == employee.ExternalID:
+			return fmt.Errorf("%w: external ID %q is taken", ErrConflict, employee.ExternalID)
+		case employee.UserName != "" && strings.EqualFold(other.UserName, employee.UserName):
+			return fmt.Errorf("%w: user name %q is taken", ErrConflict, employee.UserName)
+		}
+	}
+	return nil
+}
+
+// checkDepartmentName stands in for the unique name of a department. The
+// caller must hold m.mu.
+func (m *memoryDB) checkDepartmentName(department Department) error {
+	for _, other := range m.departments {
+		if other.Name == department.Name && other.ID != department.ID {
+			return fmt.Errorf("%w: department %q already exists", ErrConflict, department.Name)
+		}
+	}
+	return nil
+}
+
+func (m *memoryDB) ImportEmployees(ctx context.Context, rows []ImportRow, dryRun bool) ([]Employee, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var refused []RowError
+	employees := make([]Employee, len(rows))
+	audits := make([]context.Context, len(rows))
+	for i, row := range rows {
+		employee := row.Employee
+		err := m.checkRelations(employee)
+		if err == nil {
+			audits[i], err = m.checkPosition(WithBandOverride(ctx, row.BandOverride), nil, &employee)
+		}
+		if err != nil {
+			refused = append(refused, RowError{Index: i, Err: err})
+		}
+		employees[i] = employee
+	}
+	if refused != nil {
+		return nil, &ImportError{Rows: refused}
+	}
+	if dryRun {
+		return employees, nil
+	}
+	for i := range employees {
+		employees[i].ID = m.nextID
+		employees[i].Version = 1
+		m.nextID++
+		m.put(employees[i])
+		m.addSalary(ctx, SalaryChange{EmployeeID: employees[i].ID, Salary: employees[i].Salary, EffectiveFrom: today(), Reason: SalaryHire, BandOverride: BandOverrideFrom(audits[i])}, false)
+		m.record(audits[i], AuditCreate, nil, &employees[i])
+	}
+	return employees, nil
+}
+
+// checkPosition is the in-memory equivalent of the position checks of
+// employeeDB, run before employee is stored: it gives employee the title
+// of its position and checks its salary against the band if either
+// changed since stored, which is nil on create. A position given by title
+// alone is linked to the catalog first, unless it is left as stored. It
+// returns ctx as the write should be audited with. The caller must hold
+// m.mu.
+func (m *memoryDB) checkPosition(ctx context.Context, stored, employee *Employee) (context.Context, error) {
+	if employee.PositionID == 0 && (stored == nil || stored.Position != employee.Position) {
+		id, err := m.positionTitled(employee.Position)
+		if err != nil {
+			return ctx, err
+		}
+		employee.PositionID = id
+	}
+	if employee.PositionID == 0 {
+		return WithBandOverride(ctx, ""), nil
+	}
+	position, ok := m.positions[employee.PositionID]
+	if !ok {
+		return ctx, ErrUnknownPosition
+	}
+	employee.Position = position.Title
+	if stored != nil && stored.PositionID == employee.PositionID && stored.Salary == employee.Salary {
+		return WithBandOverride(ctx, ""), nil
+	}
+	return checkBand(ctx, position, employee.Salary)
+}
+
+// positionTitled is the in-memory equivalent of employeeDB.positionTitled.
+// The caller must hold m.mu.
+func (m *memoryDB) positionTitled(title string) (int, error) {
+	if len(m.positions) == 0 {
+		return 0, nil
+	}
+	var ids []int
+	for id, position := range m.positions {
+		if strings.EqualFold(position.Title, title) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 1 {
+		return 0, ErrUnmatchedPosition
+	}
+	return ids[0], nil
+}
+
+func (m *memoryDB) CreatePosition(ctx context.Context, position Position) (Position, error) {
+	if err := ctx.Err(); err != nil {
+		return Position{}, err
+	}
+	if err := position.Validate(); err != nil {
+		return Position{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkPositionCode(position); err != nil {
+		return Position{}, err
+	}
+	position.ID = m.nextPositionID
+	m.nextPositionID++
+	return m.putPosition(position), nil
+}
+
+func (m *memoryDB) GetPosition(ctx context.Context, id int) (Position, error) {
+	if err := ctx.Err(); err != nil {
+		return Position{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	position, ok := m.positions[id]
+	if !ok {
+		return Position{}, errPositionNotFound
+	}
+	position.Bands = slices.Clone(position.Bands)
+	return position, nil
+}
+
+func (m *memoryDB) ListPositions(ctx context.Context) ([]Position, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var positions []Position
+	for _, position := range m.positions {
+		position.Bands = slices.Clone(position.Bands)
+		positions = append(positions, position)
+	}
+	slices.SortFunc(positions, func(a, b Position) int {
+		return cmp.Or(cmp.Compare(b.Level, a.Level), strings.Compare(a.Code, b.Code))
+	})
+	return positions, nil
+}
+
+func (m *memoryDB) UpdatePosition(ctx context.Context, position Position) (Position, error) {
+	if err := ctx.Err(); err != nil {
+		return Position{}, err
+	}
+	if err := position.Validate(); err != nil {
+		return Position{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.positions[position.ID]; !ok {
+		return Position{}, errPositionNotFound
+	}
+	if err := m.checkPositionCode(position); err != nil {
+		return Position{}, err
+	}
+	position = m.putPosition(position)
+	ids := make([]int, 0, len(m.employees))
+	for id, employee := range m.employees {
+		if employee.PositionID == position.ID && employee.Position != position.Title {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		stored := m.employees[id]
+		employee := stored
+		employee.Position = position.Title
+		employee.Version++
+		m.put(employee)
+		m.record(WithBandOverride(ctx, ""), AuditUpdate, &stored, &employee)
+	}
+	return position, nil
+}
+
+func (m *memoryDB) DeletePosition(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.positions[id]; !ok {
+		return errPositionNotFound
+	}
+	for _, employee := range m.employees {
+		if employee.PositionID == id {
+			return ErrPositionInUse
+		}
+	}
+	delete(m.positions, id)
+	return nil
+}
+
+// putPosition stores position with its bands by currency, as
+// employeeDB.getPosition reads them, and returns a copy of what it stored.
+// The caller must hold m.mu.
+func (m *memoryDB) putPosition(position Position) Position {
+	position.Bands = slices.Clone(position.Bands)
+	slices.SortFunc(position.Bands, func(a, b SalaryBand) int { return strings.Compare(a.Currency, b.Currency) })
+	m.positions[position.ID] = position
+	position.Bands = slices.Clone(position.Bands)
+	return position
+}
+
+// checkPositionCode stands in for the unique code of a position. The
+// caller must hold m.mu.
+func (m *memoryDB) checkPositionCode(position Position) error {
+	for _, other := range m.positions {
+		if other.Code == position.Code && other.ID != position.ID {
+			return fmt.Errorf("%w: position %q already exists", ErrConflict, position.Code)
+		}
+	}
+	return nil
+}
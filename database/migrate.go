@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// migrationLockKey is the Postgres advisory lock held while migrating, so
+// that instances starting together apply each migration once.
+const migrationLockKey = 7_321_004_117
+
+// Migration is a single schema change, read from a pair of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for the migrations embedded for dialect.
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	sub, err := fs.Sub(migrationsFS, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(db, dialect, sub)
+}
+
+func newMigrator(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if entry.IsDir() || !strings.HasSuffix(file, ".sql") || !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.(up|down).sql", file)
+		}
+		num, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", file, num)
+		}
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d: missing up or down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version of the newest known migration, or 0 if there
+// are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every known migration along with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var status []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &at
+			}
+			status = append(status, s)
+		}
+		return nil
+	})
+	return status, err
+}
+
+// Version returns the highest applied migration version, or 0 if none have
+// been applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		var err error
+		version, err = currentVersion(ctx, conn)
+		return err
+	})
+	return version, err
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// To migrates up or down until version is the newest applied migration.
+// Version 0 reverts everything.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.revert(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+			return fmt.Errorf("apply migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		// The primary key on version makes a racing instance fail here
+		// rather than record the same migration twice.
+		_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, time.Now().UTC())
+		return err
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			return fmt.Errorf("revert migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version=$1`, mig.Version)
+		return err
+	})
+}
+
+// withConn runs fn on a single connection after making sure the
+// schema_migrations table exists.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.conn(ctx, false, fn)
+}
+
+// withLock is withConn while holding the migration lock. On Postgres this
+// is a session advisory lock. SQLite already serialises writers, and the
+// schema_migrations primary key stops two instances applying the same step.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.conn(ctx, true, fn)
+}
+
+func (m *Migrator) conn(ctx context.Context, lock bool, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if lock && m.dialect == Postgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	if err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var version sql.NullInt64
+	err := conn.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	return version.Int64, err
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
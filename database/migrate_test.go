@@ -0,0 +1,224 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestMigrator(t *testing.T) (*Migrator, func(table string) bool) {
+	t.Helper()
+	db, dialect, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := newMigrator(db, dialect, fstest.MapFS{
+		"0001_create_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INTEGER)`)},
+		"0001_create_a.down.sql": {Data: []byte(`DROP TABLE a`)},
+		"0002_create_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER)`)},
+		"0002_create_b.down.sql": {Data: []byte(`DROP TABLE b`)},
+		"0003_create_c.up.sql":   {Data: []byte(`CREATE TABLE c (id INTEGER)`)},
+		"0003_create_c.down.sql": {Data: []byte(`DROP TABLE c`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(table string) bool {
+		var n int
+		err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=$1`, table).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n == 1
+	}
+	return m, exists
+}
+
+func TestMigrator(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(ctx context.Context, m *Migrator) error
+		wantVersion int64
+		wantTables  map[string]bool
+	}{
+		{
+			name:        "up applies everything",
+			run:         func(ctx context.Context, m *Migrator) error { return m.Up(ctx) },
+			wantVersion: 3,
+			wantTables:  map[string]bool{"a": true, "b": true, "c": true},
+		},
+		{
+			name: "down reverts steps",
+			run: func(ctx context.Context, m *Migrator) error {
+				if err := m.Up(ctx); err != nil {
+					return err
+				}
+				return m.Down(ctx, 2)
+			},
+			wantVersion: 1,
+			wantTables:  map[string]bool{"a": true, "b": false, "c": false},
+		},
+		{
+			name:        "to migrates up to version",
+			run:         func(ctx context.Context, m *Migrator) error { return m.To(ctx, 2) },
+			wantVersion: 2,
+			wantTables:  map[string]bool{"a": true, "b": true, "c": false},
+		},
+		{
+			name: "to migrates down to version",
+			run: func(ctx context.Context, m *Migrator) error {
+				if err := m.Up(ctx); err != nil {
+					return err
+				}
+				return m.To(ctx, 0)
+			},
+			wantVersion: 0,
+			wantTables:  map[string]bool{"a": false, "b": false, "c": false},
+		},
+		{
+			name: "up is idempotent",
+			run: func(ctx context.Context, m *Migrator) error {
+				if err := m.Up(ctx); err != nil {
+					return err
+				}
+				return m.Up(ctx)
+			},
+			wantVersion: 3,
+			wantTables:  map[string]bool{"a": true, "b": true, "c": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, exists := newTestMigrator(t)
+			if err := tt.run(ctx, m); err != nil {
+				t.Fatalf("migrate error = %v", err)
+			}
+			version, err := m.Version(ctx)
+			if err != nil {
+				t.Fatalf("Version() error = %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("Version() = %d, want %d", version, tt.wantVersion)
+			}
+			for table, want := range tt.wantTables {
+				if got := exists(table); got != want {
+					t.Errorf("table %s exists = %v, want %v", table, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMigratorStatus(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMigrator(t)
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(status) != 3 {
+		t.Fatalf("Status() returned %d migrations, want 3", len(status))
+	}
+	for i, s := range status {
+		if applied := s.AppliedAt != nil; applied != (i == 0) {
+			t.Errorf("migration %d applied = %v, want %v", s.Version, applied, i == 0)
+		}
+	}
+}
+
+func TestMigratorUnknownVersion(t *testing.T) {
+	m, _ := newTestMigrator(t)
+	if err := m.To(context.Background(), 7); err == nil {
+		t.Error("To() unknown version returned no error")
+	}
+}
+
+// TestEmbeddedMigrations keeps the per-dialect migration sets in step.
+func TestEmbeddedMigrations(t *testing.T) {
+	pg, err := NewMigrator(nil, Postgres)
+	if err != nil {
+		t.Fatalf("NewMigrator(postgres) error = %v", err)
+	}
+	lite, err := NewMigrator(nil, SQLite)
+	if err != nil {
+		t.Fatalf("NewMigrator(sqlite) error = %v", err)
+	}
+	if len(pg.migrations) == 0 || len(pg.migrations) != len(lite.migrations) {
+		t.Fatalf("found %d postgres and %d sqlite migrations", len(pg.migrations), len(lite.migrations))
+	}
+	for i := range pg.migrations {
+		if pg.migrations[i].Version != lite.migrations[i].Version || pg.migrations[i].Name != lite.migrations[i].Name {
+			t.Errorf("migration %d_%s has no sqlite counterpart", pg.migrations[i].Version, pg.migrations[i].Name)
+		}
+	}
+}
+
+func TestExactSalaryMigration(t *testing.T) {
+	ctx := context.Background()
+	db, dialect, err := NewDatabase("sqlite://:memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m, err := NewMigrator(db, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	salaries := []float64{50000.75, 12345.67, 0.1, 99999999.99}
+	for _, s := range salaries {
+		if _, err := db.Exec(`INSERT INTO employees (name, position, salary) VALUES ('John Doe', 'Engineer', $1)`, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.To(ctx, 2); err != nil {
+		t.Fatalf("migrate up error = %v", err)
+	}
+	// Read the columns directly, the EmployeeDB expects the latest schema.
+	rows, err := db.Query(`SELECT salary_minor, currency FROM employees ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Money
+	for rows.Next() {
+		var m Money
+		if err := rows.Scan(&m.Amount, &m.Currency); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, m)
+	}
+	rows.Close()
+	want := []Money{{5000075, "USD"}, {1234567, "USD"}, {10, "USD"}, {9999999999, "USD"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("salaries = %+v, want %+v", got, want)
+	}
+
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatalf("migrate down error = %v", err)
+	}
+	rows, err = db.Query(`SELECT salary FROM employees ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for i := 0; rows.Next(); i++ {
+		var got float64
+		if err := rows.Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != salaries[i] {
+			t.Errorf("salary after down = %v, want %v", got, salaries[i])
+		}
+	}
+}
@@ -0,0 +1,9 @@
+ALTER TABLE employees ADD COLUMN salary REAL NOT NULL DEFAULT 0;
+UPDATE employees SET salary = salary_minor::numeric / CASE
+    WHEN currency IN ('CLP', 'ISK', 'JPY', 'KRW', 'UGX', 'VND') THEN 1
+    WHEN currency IN ('BHD', 'JOD', 'KWD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END;
+ALTER TABLE employees ALTER COLUMN salary DROP DEFAULT;
+ALTER TABLE employees DROP COLUMN currency;
+ALTER TABLE employees DROP COLUMN salary_minor;
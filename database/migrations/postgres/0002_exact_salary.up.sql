@@ -0,0 +1,10 @@
+-- Salaries move from REAL to integer minor units with an ISO 4217 currency.
+-- Every existing salary is in USD. Going through float8 keeps the exact
+-- float4 value; casting float4 straight to numeric would round it to six
+-- significant digits.
+ALTER TABLE employees ADD COLUMN salary_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE employees ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+UPDATE employees SET salary_minor = ROUND(salary::float8::numeric * 100);
+ALTER TABLE employees ALTER COLUMN salary_minor DROP DEFAULT;
+ALTER TABLE employees ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE employees DROP COLUMN salary;
@@ -0,0 +1,3 @@
+DROP TABLE audit_checkpoints;
+DROP FUNCTION audit_checkpoints_append_only;
+ALTER TABLE audit_log DROP COLUMN hash;
@@ -0,0 +1,21 @@
+-- Each audit entry carries the hash of its contents chained to the hash of
+-- the entry before it; entries written before this migration have none.
+-- audit_checkpoints holds the signed hashes that anchor the chain.
+ALTER TABLE audit_log ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE audit_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL,
+    hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE FUNCTION audit_checkpoints_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_checkpoints is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_checkpoints_append_only BEFORE UPDATE OR DELETE ON audit_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION audit_checkpoints_append_only();
@@ -0,0 +1 @@
+DROP TABLE salary_history;
@@ -0,0 +1,17 @@
+-- salary_history holds every salary of an employee with the date it takes
+-- effect; employees.salary_minor and currency keep the one in effect today.
+-- Existing salaries are recorded as effective from the day of the migration.
+CREATE TABLE salary_history (
+    id BIGSERIAL PRIMARY KEY,
+    employee_id BIGINT NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    salary_minor BIGINT NOT NULL CHECK (salary_minor > 0),
+    currency CHAR(3) NOT NULL,
+    effective_from DATE NOT NULL,
+    reason TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (employee_id, effective_from)
+);
+
+INSERT INTO salary_history (employee_id, salary_minor, currency, effective_from, reason, created_by, created_at)
+SELECT id, salary_minor, currency, CURRENT_DATE, 'adjustment', '', NOW() FROM employees WHERE salary_minor > 0;
@@ -0,0 +1,24 @@
+-- employee_versions keeps every version of every employee row with the time
+-- span it was current: from valid_from until valid_to, or still current
+-- while valid_to is NULL. Versions go with their employee when it is purged.
+-- The current rows are recorded as valid since their last audit entry, or
+-- since the migration for employees written before the audit log existed.
+CREATE TABLE employee_versions (
+    id BIGINT NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    position TEXT NOT NULL,
+    salary_minor BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    deleted_by TEXT NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ,
+    PRIMARY KEY (id, version)
+);
+CREATE INDEX employee_versions_valid ON employee_versions (valid_from, valid_to);
+
+INSERT INTO employee_versions (id, version, name, position, salary_minor, currency, deleted_at, deleted_by, valid_from)
+SELECT e.id, e.version, e.name, e.position, e.salary_minor, e.currency, e.deleted_at, e.deleted_by,
+    COALESCE((SELECT MAX(created_at) FROM audit_log WHERE employee_id = e.id), NOW())
+FROM employees e;
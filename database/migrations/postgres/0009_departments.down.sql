@@ -0,0 +1,5 @@
+ALTER TABLE employee_versions DROP COLUMN manager_id;
+ALTER TABLE employee_versions DROP COLUMN department_id;
+ALTER TABLE employees DROP COLUMN manager_id;
+ALTER TABLE employees DROP COLUMN department_id;
+DROP TABLE departments;
@@ -0,0 +1,22 @@
+-- A catalog of positions, each with at most one salary band per currency.
+-- Employees reference a position by ID and keep its title in position, so
+-- that filters and sorts on the position work for every employee. Free-text
+-- positions remain for employees without one.
+CREATE TABLE positions (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    level INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE salary_bands (
+    position_id INTEGER NOT NULL REFERENCES positions (id) ON DELETE CASCADE,
+    currency TEXT NOT NULL,
+    min_minor BIGINT NOT NULL,
+    mid_minor BIGINT NOT NULL,
+    max_minor BIGINT NOT NULL,
+    PRIMARY KEY (position_id, currency),
+    CHECK (0 < min_minor AND min_minor <= mid_minor AND mid_minor <= max_minor)
+);
+ALTER TABLE employees ADD COLUMN position_id INTEGER REFERENCES positions (id);
+CREATE INDEX employees_position_id ON employees (position_id);
+ALTER TABLE employee_versions ADD COLUMN position_id INTEGER;
@@ -0,0 +1,6 @@
+ALTER TABLE employee_versions DROP COLUMN user_name;
+ALTER TABLE employee_versions DROP COLUMN external_id;
+DROP INDEX employees_user_name;
+DROP INDEX employees_external_id;
+ALTER TABLE employees DROP COLUMN user_name;
+ALTER TABLE employees DROP COLUMN external_id;
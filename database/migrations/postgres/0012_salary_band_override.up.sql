@@ -0,0 +1,4 @@
+-- The reason a salary change may be outside the band of the position, kept
+-- so that the band is checked again when a scheduled change takes effect.
+-- Empty for changes within the band.
+ALTER TABLE salary_history ADD COLUMN band_override TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DELETE FROM employee_versions WHERE id NOT IN (SELECT id FROM employees);
+ALTER TABLE employee_versions ADD CONSTRAINT employee_versions_id_fkey FOREIGN KEY (id) REFERENCES employees (id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Versions outlive the purge of their employee, as audit entries do, so
+-- that reads as of a time before the purge still find it. Purge closes the
+-- span of the last version instead.
+ALTER TABLE employee_versions DROP CONSTRAINT employee_versions_id_fkey;
@@ -0,0 +1,8 @@
+ALTER TABLE employees ADD COLUMN salary REAL NOT NULL DEFAULT 0;
+UPDATE employees SET salary = CAST(salary_minor AS REAL) / CASE
+    WHEN currency IN ('CLP', 'ISK', 'JPY', 'KRW', 'UGX', 'VND') THEN 1
+    WHEN currency IN ('BHD', 'JOD', 'KWD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END;
+ALTER TABLE employees DROP COLUMN currency;
+ALTER TABLE employees DROP COLUMN salary_minor;
@@ -0,0 +1,6 @@
+-- Salaries move from REAL to integer minor units with an ISO 4217 currency.
+-- Every existing salary is in USD.
+ALTER TABLE employees ADD COLUMN salary_minor INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE employees ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE employees SET salary_minor = CAST(ROUND(salary * 100) AS INTEGER);
+ALTER TABLE employees DROP COLUMN salary;
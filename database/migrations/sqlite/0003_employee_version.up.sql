@@ -0,0 +1,2 @@
+-- version counts the writes to a row and backs the ETag of an employee.
+ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Without the columns deleted employees would reappear, so they go for good.
+DELETE FROM employees WHERE deleted_at IS NOT NULL;
+DROP INDEX employees_deleted_at;
+ALTER TABLE employees DROP COLUMN deleted_by;
+ALTER TABLE employees DROP COLUMN deleted_at;
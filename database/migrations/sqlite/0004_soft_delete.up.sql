@@ -0,0 +1,5 @@
+-- Deleted employees keep their row until the purge removes them for good.
+-- deleted_by names the actor who deleted the employee.
+ALTER TABLE employees ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE employees ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+CREATE INDEX employees_deleted_at ON employees (deleted_at) WHERE deleted_at IS NOT NULL;
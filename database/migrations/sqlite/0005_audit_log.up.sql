@@ -0,0 +1,23 @@
+-- audit_log records every write to an employee, in the transaction of the
+-- write. It has no foreign key so the history outlives purged employees, and
+-- triggers refuse to change or remove an entry once written.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX audit_log_employee_id ON audit_log (employee_id, id);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,2 @@
+DROP TABLE audit_checkpoints;
+ALTER TABLE audit_log DROP COLUMN hash;
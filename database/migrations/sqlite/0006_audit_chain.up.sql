@@ -0,0 +1,22 @@
+-- Each audit entry carries the hash of its contents chained to the hash of
+-- the entry before it; entries written before this migration have none.
+-- audit_checkpoints holds the signed hashes that anchor the chain.
+ALTER TABLE audit_log ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE audit_checkpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entry_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TRIGGER audit_checkpoints_no_update BEFORE UPDATE ON audit_checkpoints
+BEGIN
+    SELECT RAISE(ABORT, 'audit_checkpoints is append-only');
+END;
+
+CREATE TRIGGER audit_checkpoints_no_delete BEFORE DELETE ON audit_checkpoints
+BEGIN
+    SELECT RAISE(ABORT, 'audit_checkpoints is append-only');
+END;
@@ -0,0 +1,18 @@
+-- salary_history holds every salary of an employee with the date it takes
+-- effect; employees.salary_minor and currency keep the one in effect today.
+-- Existing salaries are recorded as effective from the day of the migration.
+-- Dates are stored as YYYY-MM-DD text, which sorts chronologically.
+CREATE TABLE salary_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    salary_minor INTEGER NOT NULL CHECK (salary_minor > 0),
+    currency TEXT NOT NULL,
+    effective_from DATE NOT NULL,
+    reason TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (employee_id, effective_from)
+);
+
+INSERT INTO salary_history (employee_id, salary_minor, currency, effective_from, reason, created_by, created_at)
+SELECT id, salary_minor, currency, DATE('now'), 'adjustment', '', STRFTIME('%Y-%m-%d %H:%M:%f+00:00', 'now') FROM employees WHERE salary_minor > 0;
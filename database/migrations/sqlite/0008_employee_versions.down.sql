@@ -0,0 +1 @@
+DROP TABLE employee_versions;
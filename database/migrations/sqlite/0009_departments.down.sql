@@ -0,0 +1,7 @@
+ALTER TABLE employee_versions DROP COLUMN manager_id;
+ALTER TABLE employee_versions DROP COLUMN department_id;
+DROP INDEX employees_manager_id;
+DROP INDEX employees_department_id;
+ALTER TABLE employees DROP COLUMN manager_id;
+ALTER TABLE employees DROP COLUMN department_id;
+DROP TABLE departments;
@@ -0,0 +1,14 @@
+-- Employees belong to at most one department and report to at most one
+-- manager; those without a manager head the hierarchy. A department cannot
+-- be dropped while employees belong to it, and the reports of a purged
+-- manager lose their manager. Cycles are refused by the application.
+CREATE TABLE departments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+ALTER TABLE employees ADD COLUMN department_id INTEGER REFERENCES departments (id);
+ALTER TABLE employees ADD COLUMN manager_id INTEGER REFERENCES employees (id) ON DELETE SET NULL CHECK (manager_id <> id);
+CREATE INDEX employees_department_id ON employees (department_id);
+CREATE INDEX employees_manager_id ON employees (manager_id);
+ALTER TABLE employee_versions ADD COLUMN department_id INTEGER;
+ALTER TABLE employee_versions ADD COLUMN manager_id INTEGER;
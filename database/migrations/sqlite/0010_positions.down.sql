@@ -0,0 +1,5 @@
+ALTER TABLE employee_versions DROP COLUMN position_id;
+DROP INDEX employees_position_id;
+ALTER TABLE employees DROP COLUMN position_id;
+DROP TABLE salary_bands;
+DROP TABLE positions;
@@ -0,0 +1,9 @@
+-- The identity of an employee at the identity provider that provisions it
+-- over SCIM: its ID there and its user name. Both are NULL for employees it
+-- does not know, and unique otherwise, user names ignoring case.
+ALTER TABLE employees ADD COLUMN external_id TEXT;
+ALTER TABLE employees ADD COLUMN user_name TEXT;
+CREATE UNIQUE INDEX employees_external_id ON employees (external_id);
+CREATE UNIQUE INDEX employees_user_name ON employees (LOWER(user_name));
+ALTER TABLE employee_versions ADD COLUMN external_id TEXT;
+ALTER TABLE employee_versions ADD COLUMN user_name TEXT;
@@ -0,0 +1 @@
+ALTER TABLE salary_history DROP COLUMN band_override;
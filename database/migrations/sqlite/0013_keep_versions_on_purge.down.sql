@@ -0,0 +1,26 @@
+CREATE TABLE employee_versions_new (
+    id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    position TEXT NOT NULL,
+    salary_minor INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    deleted_at TIMESTAMP,
+    deleted_by TEXT NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP,
+    department_id INTEGER,
+    manager_id INTEGER,
+    position_id INTEGER,
+    external_id TEXT,
+    user_name TEXT,
+    PRIMARY KEY (id, version)
+);
+INSERT INTO employee_versions_new (id, version, name, position, salary_minor, currency, deleted_at, deleted_by, valid_from, valid_to,
+    department_id, manager_id, position_id, external_id, user_name)
+SELECT id, version, name, position, salary_minor, currency, deleted_at, deleted_by, valid_from, valid_to,
+    department_id, manager_id, position_id, external_id, user_name
+FROM employee_versions WHERE id IN (SELECT id FROM employees);
+DROP TABLE employee_versions;
+ALTER TABLE employee_versions_new RENAME TO employee_versions;
+CREATE INDEX employee_versions_valid ON employee_versions (valid_from, valid_to);
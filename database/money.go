@@ -0,0 +1,94 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed for salaries given without a currency, and was
+// the currency of every row before currencies were recorded.
+const DefaultCurrency = "USD"
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// currencyExponents maps the ISO 4217 codes we accept to the number of
+// digits in their minor unit. Keep it in step with the CASE expressions in
+// the exact_salary migrations.
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2,
+	"IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2,
+	"PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2,
+	"TWD": 2, "UGX": 0, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of minor unit digits of an ISO 4217
+// currency code.
+func CurrencyExponent(currency string) (int, bool) {
+	exp, ok := currencyExponents[currency]
+	return exp, ok
+}
+
+// Money is an exact amount of a currency, counted in its minor unit (cents
+// for USD, yen for JPY).
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ParseMoney parses a plain decimal such as "50000.75" in currency. It fails
+// if the amount has more fractional digits than the currency's minor unit.
+func ParseMoney(amount, currency string) (Money, error) {
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	neg := strings.HasPrefix(whole, "-")
+	whole = strings.TrimPrefix(whole, "-")
+	if whole == "" || len(frac) > exp || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, amount, currency)
+	}
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, amount, currency)
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a plain decimal with exactly as many
+// fractional digits as the currency's minor unit, e.g. "50000.75".
+func (m Money) String() string {
+	exp, ok := CurrencyExponent(m.Currency)
+	if !ok || exp == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1
+	}
+	unit := uint64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/unit, exp, abs%unit)
+}
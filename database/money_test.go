@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{name: "cents", amount: "50000.75", currency: "USD", want: Money{Amount: 5000075, Currency: "USD"}},
+		{name: "whole", amount: "50000", currency: "USD", want: Money{Amount: 5000000, Currency: "USD"}},
+		{name: "one fractional digit", amount: "0.5", currency: "EUR", want: Money{Amount: 50, Currency: "EUR"}},
+		{name: "negative", amount: "-12.30", currency: "USD", want: Money{Amount: -1230, Currency: "USD"}},
+		{name: "zero exponent", amount: "6000000", currency: "JPY", want: Money{Amount: 6000000, Currency: "JPY"}},
+		{name: "three digit exponent", amount: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{name: "too precise", amount: "50000.755", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "fraction for zero exponent", amount: "100.5", currency: "JPY", wantErr: ErrInvalidAmount},
+		{name: "exponent notation", amount: "5e4", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "empty", amount: "", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "overflow", amount: "92233720368547758.08", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "unknown currency", amount: "10", currency: "XYZ", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMoney() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: Money{Amount: 5000075, Currency: "USD"}, want: "50000.75"},
+		{money: Money{Amount: 5000000, Currency: "USD"}, want: "50000.00"},
+		{money: Money{Amount: 5, Currency: "USD"}, want: "0.05"},
+		{money: Money{Amount: -1230, Currency: "USD"}, want: "-12.30"},
+		{money: Money{Amount: 6000000, Currency: "JPY"}, want: "6000000"},
+		{money: Money{Amount: 1234, Currency: "KWD"}, want: "1.234"},
+		{money: Money{Amount: -9223372036854775808, Currency: "USD"}, want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
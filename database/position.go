@@ -0,0 +1,322 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Position is an entry of the position catalog. Codes are unique; Level
+// ranks positions, higher being more senior.
+type Position struct {
+	ID    int          `json:"id"`
+	Code  string       `json:"code"`
+	Title string       `json:"title"`
+	Level int          `json:"level"`
+	Bands []SalaryBand `json:"bands,omitempty"`
+}
+
+// SalaryBand bounds the salaries of a position in Currency, in its minor
+// unit. A position with bands only allows salaries in their currencies;
+// one without any does not bound salaries.
+type SalaryBand struct {
+	Currency string `json:"currency"`
+	Min      int64  `json:"min"`
+	Mid      int64  `json:"mid"`
+	Max      int64  `json:"max"`
+}
+
+// Band returns the band of p for currency, if it has one.
+func (p Position) Band(currency string) (SalaryBand, bool) {
+	for _, b := range p.Bands {
+		if b.Currency == currency {
+			return b, true
+		}
+	}
+	return SalaryBand{}, false
+}
+
+// Validate reports a position that cannot be stored.
+func (p Position) Validate() error {
+	seen := make(map[string]bool)
+	for _, b := range p.Bands {
+		switch {
+		case seen[b.Currency]:
+			return fmt.Errorf("%w: more than one %s band", ErrConstraint, b.Currency)
+		case b.Min <= 0 || b.Mid < b.Min || b.Max < b.Mid:
+			return fmt.Errorf("%w: a band must have 0 < min <= mid <= max", ErrConstraint)
+		}
+		seen[b.Currency] = true
+	}
+	return nil
+}
+
+// Contains reports whether salary, in the currency of the band, is within
+// it.
+func (b SalaryBand) Contains(salary int64) bool {
+	return b.Min <= salary && salary <= b.Max
+}
+
+var (
+	errPositionNotFound = fmt.Errorf("position %w", ErrNotFound)
+	// ErrUnknownPosition is returned when an employee is assigned a
+	// position that is not in the catalog.
+	ErrUnknownPosition = fmt.Errorf("unknown position: %w", ErrConstraint)
+	// ErrUnmatchedPosition is returned when an employee is given a position
+	// by title alone, and the title matches no position of the catalog, or
+	// more than one, ignoring case.
+	ErrUnmatchedPosition = fmt.Errorf("position matches no single title of the catalog: %w", ErrConstraint)
+	// ErrSalaryOutOfBand is returned when the salary of an employee is
+	// outside the band of its position and the write has no override
+	// reason, see WithBandOverride.
+	ErrSalaryOutOfBand = fmt.Errorf("salary outside the band of the position: %w", ErrConstraint)
+	// ErrPositionInUse is returned when deleting a position employees hold,
+	// including deleted employees that are not purged yet.
+	ErrPositionInUse = fmt.Errorf("position has employees: %w", ErrConflict)
+)
+
+// checkBand checks salary against the band of position, which must have
+// one in its currency if it has any. It returns ctx as the write should be
+// audited with: without the override reason, unless the salary needed it.
+func checkBand(ctx context.Context, position Position, salary Money) (context.Context, error) {
+	if len(position.Bands) == 0 {
+		return WithBandOverride(ctx, ""), nil
+	}
+	band, ok := position.Band(salary.Currency)
+	if ok && band.Contains(salary.Amount) {
+		return WithBandOverride(ctx, ""), nil
+	}
+	if BandOverrideFrom(ctx) != "" {
+		return ctx, nil
+	}
+	if !ok {
+		return ctx, fmt.Errorf("%w: the position has no band in %s", ErrSalaryOutOfBand, salary.Currency)
+	}
+	return ctx, fmt.Errorf("%w: %s is not between %s and %s", ErrSalaryOutOfBand, salary,
+		Money{band.Min, band.Currency}, Money{band.Max, band.Currency})
+}
+
+// position reads position id and its bands in tx, for an employee to be
+// written with it.
+func (e *employeeDB) position(ctx context.Context, tx *sql.Tx, id int) (Position, error) {
+	position, err := e.getPosition(ctx, tx, id)
+	if err == errPositionNotFound {
+		return position, ErrUnknownPosition
+	}
+	return position, err
+}
+
+// positionTitled returns the ID of the position whose title is title,
+// ignoring case, for an employee written in tx with a position by title
+// alone. It returns zero while the catalog is empty, since positions are
+// then free text.
+func (e *employeeDB) positionTitled(ctx context.Context, tx *sql.Tx, title string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM positions WHERE LOWER(title) = LOWER($1) LIMIT 2`, title)
+	if err != nil {
+		return 0, dbError(ctx, err)
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, dbError(ctx, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, dbError(ctx, err)
+	}
+	if len(ids) == 1 {
+		return ids[0], nil
+	}
+	var catalog bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM positions)`).Scan(&catalog); err != nil {
+		return 0, dbError(ctx, err)
+	}
+	if catalog {
+		return 0, ErrUnmatchedPosition
+	}
+	return 0, nil
+}
+
+func (e *employeeDB) getPosition(ctx context.Context, tx *sql.Tx, id int) (Position, error) {
+	var p Position
+	err := tx.QueryRowContext(ctx, `SELECT id, code, title, level FROM positions WHERE id=$1`, id).Scan(&p.ID, &p.Code, &p.Title, &p.Level)
+	if err == sql.ErrNoRows {
+		return p, errPositionNotFound
+	}
+	if err != nil {
+		return p, dbError(ctx, err)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT currency, min_minor, mid_minor, max_minor FROM salary_bands WHERE position_id=$1 ORDER BY currency`, id)
+	if err != nil {
+		return p, dbError(ctx, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b SalaryBand
+		if err := rows.Scan(&b.Currency, &b.Min, &b.Mid, &b.Max); err != nil {
+			return p, dbError(ctx, err)
+		}
+		p.Bands = append(p.Bands, b)
+	}
+	return p, dbError(ctx, rows.Err())
+}
+
+// writeBands replaces the bands of position in tx.
+func (e *employeeDB) writeBands(ctx context.Context, tx *sql.Tx, position Position) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM salary_bands WHERE position_id=$1`, position.ID); err != nil {
+		return dbError(ctx, err)
+	}
+	for _, b := range position.Bands {
+		_, err := tx.ExecContext(ctx, `INSERT INTO salary_bands (position_id, currency, min_minor, mid_minor, max_minor) VALUES ($1, $2, $3, $4, $5)`,
+			position.ID, b.Currency, b.Min, b.Mid, b.Max)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+	}
+	return nil
+}
+
+func (e *employeeDB) CreatePosition(ctx context.Context, position Position) (Position, error) {
+	if err := position.Validate(); err != nil {
+		return position, err
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO positions (code, title, level) VALUES ($1, $2, $3) RETURNING id`,
+			position.Code, position.Title, position.Level).Scan(&position.ID)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		return e.writeBands(ctx, tx, position)
+	})
+	return position, err
+}
+
+func (e *employeeDB) GetPosition(ctx context.Context, id int) (Position, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var position Position
+	err := e.inTx(ctx, func(tx *sql.Tx) (err error) {
+		position, err = e.getPosition(ctx, tx, id)
+		return err
+	})
+	return position, err
+}
+
+func (e *employeeDB) ListPositions(ctx context.Context) ([]Position, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	rows, err := e.db.QueryContext(ctx, `SELECT p.id, p.code, p.title, p.level, b.currency, b.min_minor, b.mid_minor, b.max_minor
+		FROM positions p LEFT JOIN salary_bands b ON b.position_id = p.id ORDER BY p.level DESC, p.code, b.currency`)
+	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	defer rows.Close()
+	var positions []Position
+	for rows.Next() {
+		var p Position
+		var currency sql.NullString
+		var low, mid, high sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Code, &p.Title, &p.Level, &currency, &low, &mid, &high); err != nil {
+			return nil, dbError(ctx, err)
+		}
+		if n := len(positions); n == 0 || positions[n-1].ID != p.ID {
+			positions = append(positions, p)
+		}
+		if currency.Valid {
+			last := &positions[len(positions)-1]
+			last.Bands = append(last.Bands, SalaryBand{Currency: currency.String, Min: low.Int64, Mid: mid.Int64, Max: high.Int64})
+		}
+	}
+	return positions, dbError(ctx, rows.Err())
+}
+
+// UpdatePosition also renames the employees that hold the position, so
+// that their Position stays its title. New bands only apply to later
+// writes: salaries already outside them are left alone.
+func (e *employeeDB) UpdatePosition(ctx context.Context, position Position) (Position, error) {
+	if err := position.Validate(); err != nil {
+		return position, err
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE positions SET code=$1, title=$2, level=$3 WHERE id=$4`,
+			position.Code, position.Title, position.Level, position.ID)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return errPositionNotFound
+		}
+		if err := e.writeBands(ctx, tx, position); err != nil {
+			return err
+		}
+		return e.retitle(ctx, tx, position)
+	})
+	return position, err
+}
+
+// retitle sets the Position of the employees holding position to its title,
+// as audited updates.
+//
+// Every row is locked, in order, before the first write: the audit takes an
+// exclusive lock of its own, and locking rows after it would deadlock with
+// a concurrent write to one of them waiting for the audit.
+func (e *employeeDB) retitle(ctx context.Context, tx *sql.Tx, position Position) error {
+	query := `SELECT ` + employeeColumns + ` FROM employees WHERE position_id=$1 AND position <> $2 ORDER BY id`
+	if e.dialect == Postgres {
+		query += ` FOR NO KEY UPDATE`
+	}
+	rows, err := tx.QueryContext(ctx, query, position.ID, position.Title)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	var employees []Employee
+	for rows.Next() {
+		var employee Employee
+		if err := scanEmployee(rows, &employee); err != nil {
+			rows.Close()
+			return dbError(ctx, err)
+		}
+		employees = append(employees, employee)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return dbError(ctx, err)
+	}
+	ctx = WithBandOverride(ctx, "")
+	for _, before := range employees {
+		if _, err := e.write(ctx, tx, AuditUpdate, before, []column{{"position", position.Title}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *employeeDB) DeletePosition(ctx context.Context, id int) error {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	return e.inTx(ctx, func(tx *sql.Tx) error {
+		// The foreign key would refuse the delete too, but not say why.
+		var inUse bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE position_id=$1)`, id).Scan(&inUse); err != nil {
+			return dbError(ctx, err)
+		}
+		if inUse {
+			return ErrPositionInUse
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM positions WHERE id=$1`, id)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return errPositionNotFound
+		}
+		return nil
+	})
+}
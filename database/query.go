@@ -0,0 +1,290 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ListQuery selects, orders and pages the employees returned by
+// ListEmployees. Zero-valued filters match every employee.
+//
+// A page is either the Page-th run of PerPage employees, or, with After or
+// Before set, the PerPage employees that sort right after or right before a
+// given employee. The latter keyset mode does not slow down on later pages
+// and does not skip or repeat rows when employees are added in between.
+type ListQuery struct {
+	Page    int
+	PerPage int
+	// Offset skips that many more employees before a page, which then
+	// starts at employee (Page-1)*PerPage+Offset, counting from 0.
+	Offset int
+
+	// After and Before hold the employee a keyset page continues from.
+	// Only its ID and the fields named in Sort are used.
+	After  *Employee
+	Before *Employee
+
+	// SkipTotal leaves EmployeePage.Total at zero, saving a count of every
+	// matching employee.
+	SkipTotal bool
+
+	// IncludeDeleted lists deleted employees along with the others.
+	IncludeDeleted bool
+
+	// AsOf lists the employees as they were at that time rather than now,
+	// unless it is zero.
+	AsOf time.Time
+
+	// Name matches employees whose name starts with it, ignoring case.
+	Name string
+	// Position matches employees with exactly this position, ignoring case.
+	Position string
+	// Search matches employees whose name or position contains it,
+	// ignoring case.
+	Search string
+	// DepartmentID matches the employees of a department.
+	DepartmentID int
+	// ManagerIDs matches the employees reporting to any of these managers.
+	ManagerIDs []int
+	// Currency matches salaries paid in it. MinSalary and MaxSalary are
+	// inclusive bounds in its minor unit and require a currency, since
+	// amounts in different currencies are not comparable.
+	Currency  string
+	MinSalary *int64
+	MaxSalary *int64
+	// ID matches the employee with this ID, ExternalID the one with this
+	// external ID, and UserName the one with this user name, ignoring case.
+	ID         int
+	ExternalID string
+	UserName   string
+	// HasUserName matches only employees with a user name.
+	HasUserName bool
+
+	// Sort orders the employees; ties, and an empty Sort, fall back to id.
+	Sort []Sort
+}
+
+// EmployeePage is one page of a listing.
+type EmployeePage struct {
+	Employees []Employee
+	// Total counts the employees matching the filters, across all pages.
+	Total int
+	// More reports whether further employees follow the page in the
+	// direction it was read: after it, or before it for a Before page.
+	More bool
+}
+
+// trim drops the extra row read to fill More, and puts a Before page, which
+// was read backwards, back in order.
+func (p *EmployeePage) trim(q ListQuery) {
+	if len(p.Employees) > q.PerPage {
+		p.Employees = p.Employees[:q.PerPage]
+		p.More = true
+	}
+	if q.Before != nil {
+		slices.Reverse(p.Employees)
+	}
+}
+
+// Sort orders employees by one field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumns whitelists the fields employees can be sorted by. Salaries
+// sort by currency first, for the same reason they filter by it.
+var sortColumns = map[string][]string{
+	"id":       {"id"},
+	"name":     {"name"},
+	"position": {"position"},
+	"salary":   {"currency", "salary_minor"},
+}
+
+var (
+	ErrInvalidSort  = errors.New("invalid sort")
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// ParseSort parses a comma-separated list of fields such as "-salary,name",
+// where a leading "-" sorts that field in descending order.
+func ParseSort(s string) ([]Sort, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sorts []Sort
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if _, ok := sortColumns[field]; !ok {
+			return nil, fmt.Errorf("%w field %q", ErrInvalidSort, field)
+		}
+		sorts = append(sorts, Sort{Field: field, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Validate reports a query that cannot be run.
+func (q ListQuery) Validate() error {
+	for _, s := range q.Sort {
+		if _, ok := sortColumns[s.Field]; !ok {
+			return fmt.Errorf("%w field %q", ErrInvalidSort, s.Field)
+		}
+	}
+	if (q.MinSalary != nil || q.MaxSalary != nil) && q.Currency == "" {
+		return fmt.Errorf("%w: a salary range needs a currency", ErrInvalidQuery)
+	}
+	if q.After != nil && q.Before != nil {
+		return fmt.Errorf("%w: a page cannot be both after and before an employee", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// term is one column of an ORDER BY clause.
+type term struct {
+	column string
+	desc   bool
+}
+
+// terms lists the columns q sorts by, always ending with id so the order is
+// total and pages are stable.
+func (q ListQuery) terms() []term {
+	var terms []term
+	for _, s := range q.Sort {
+		for _, column := range sortColumns[s.Field] {
+			terms = append(terms, term{column, s.Desc})
+		}
+	}
+	if !slices.ContainsFunc(q.Sort, func(s Sort) bool { return s.Field == "id" }) {
+		terms = append(terms, term{"id", false})
+	}
+	return terms
+}
+
+// key returns the value of column for employee e.
+func key(e *Employee, column string) any {
+	switch column {
+	case "name":
+		return e.Name
+	case "position":
+		return e.Position
+	case "currency":
+		return e.Salary.Currency
+	case "salary_minor":
+		return e.Salary.Amount
+	}
+	return e.ID
+}
+
+// filters renders the filters of q as conditions with $n placeholders,
+// numbered after the args already in args. Text is compared in lower case;
+// on SQLite LOWER only folds ASCII letters.
+func (q ListQuery) filters(args []any) ([]string, []any) {
+	var conds []string
+	arg := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !q.IncludeDeleted {
+		conds = append(conds, `deleted_at IS NULL`)
+	}
+	if q.Name != "" {
+		arg(`LOWER(name) LIKE $%d ESCAPE '\'`, escapeLike(strings.ToLower(q.Name))+"%")
+	}
+	if q.Position != "" {
+		arg(`LOWER(position) = $%d`, strings.ToLower(q.Position))
+	}
+	if q.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q.Search)) + "%"
+		args = append(args, pattern)
+		conds = append(conds, fmt.Sprintf(`(LOWER(name) LIKE $%[1]d ESCAPE '\' OR LOWER(position) LIKE $%[1]d ESCAPE '\')`, len(args)))
+	}
+	if q.DepartmentID != 0 {
+		arg(`department_id = $%d`, q.DepartmentID)
+	}
+	if len(q.ManagerIDs) > 0 {
+		placeholders := make([]string, len(q.ManagerIDs))
+		for i, id := range q.ManagerIDs {
+			args = append(args, id)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conds = append(conds, `manager_id IN (`+strings.Join(placeholders, ", ")+`)`)
+	}
+	if q.Currency != "" {
+		arg(`currency = $%d`, q.Currency)
+	}
+	if q.MinSalary != nil {
+		arg(`salary_minor >= $%d`, *q.MinSalary)
+	}
+	if q.MaxSalary != nil {
+		arg(`salary_minor <= $%d`, *q.MaxSalary)
+	}
+	if q.ID != 0 {
+		arg(`id = $%d`, q.ID)
+	}
+	if q.ExternalID != "" {
+		arg(`external_id = $%d`, q.ExternalID)
+	}
+	if q.UserName != "" {
+		arg(`LOWER(user_name) = $%d`, strings.ToLower(q.UserName))
+	}
+	if q.HasUserName {
+		conds = append(conds, `user_name IS NOT NULL`)
+	}
+	return conds, args
+}
+
+// where joins conditions into a WHERE clause.
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+// orderBy renders the sort of q, reversed for a Before page, which is read
+// backwards from the employee it continues from.
+func (q ListQuery) orderBy() string {
+	var columns []string
+	for _, t := range q.terms() {
+		if t.desc != (q.Before != nil) {
+			t.column += " DESC"
+		}
+		columns = append(columns, t.column)
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
+
+// keyset renders the condition selecting the employees that sort after
+// q.After or before q.Before, numbering placeholders after args. For terms
+// a, b it is (a > $1) OR (a = $1 AND b > $2), with < for descending terms
+// and for Before.
+func (q ListQuery) keyset(args []any) (string, []any) {
+	from := cmp.Or(q.After, q.Before)
+	if from == nil {
+		return "", args
+	}
+	var ors, equal []string
+	for _, t := range q.terms() {
+		args = append(args, key(from, t.column))
+		op := ">"
+		if t.desc != (q.Before != nil) {
+			op = "<"
+		}
+		ors = append(ors, "("+strings.Join(append(equal, fmt.Sprintf("%s %s $%d", t.column, op, len(args))), " AND ")+")")
+		equal = append(equal, fmt.Sprintf("%s = $%d", t.column, len(args)))
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
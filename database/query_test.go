@@ -0,0 +1,52 @@
+package database
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []Sort
+		wantErr error
+	}{
+		{name: "empty", sort: "", want: nil},
+		{name: "ascending", sort: "name", want: []Sort{{Field: "name"}}},
+		{name: "descending", sort: "-salary", want: []Sort{{Field: "salary", Desc: true}}},
+		{name: "several fields", sort: "-salary, name", want: []Sort{{Field: "salary", Desc: true}, {Field: "name"}}},
+		{name: "unknown field", sort: "password", wantErr: ErrInvalidSort},
+		{name: "column outside whitelist", sort: "salary_minor", wantErr: ErrInvalidSort},
+		{name: "empty field", sort: "name,", wantErr: ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.sort)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSort() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseSort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQueryOrderBy(t *testing.T) {
+	tests := []struct {
+		sort []Sort
+		want string
+	}{
+		{sort: nil, want: "ORDER BY id"},
+		{sort: []Sort{{Field: "salary", Desc: true}}, want: "ORDER BY currency DESC, salary_minor DESC, id"},
+		{sort: []Sort{{Field: "id", Desc: true}, {Field: "name"}}, want: "ORDER BY id DESC, name"},
+	}
+	for _, tt := range tests {
+		if got := (ListQuery{Sort: tt.sort}).orderBy(); got != tt.want {
+			t.Errorf("orderBy(%+v) = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+}
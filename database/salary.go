@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Reasons for a salary change.
+const (
+	SalaryHire        = "hire"
+	SalaryPromotion   = "promotion"
+	SalaryMerit       = "merit"
+	SalaryAdjustment  = "adjustment"
+	SalaryCorrection  = "correction"
+	SalaryDemotion    = "demotion"
+	SalaryRestructure = "restructure"
+)
+
+// SalaryReasons are the valid reasons for a salary change.
+var SalaryReasons = []string{SalaryHire, SalaryPromotion, SalaryMerit, SalaryAdjustment, SalaryCorrection, SalaryDemotion, SalaryRestructure}
+
+// SalaryChange is a row of the salary history of an employee: the salary
+// paid from EffectiveFrom until the next change takes effect.
+type SalaryChange struct {
+	ID         int64
+	EmployeeID int
+	Salary     Money
+	// EffectiveFrom is a date, at midnight UTC.
+	EffectiveFrom time.Time
+	Reason        string
+	// BandOverride is the reason the salary may be outside the band of
+	// the position of the employee, taken from the context it was added
+	// with, see WithBandOverride. It is kept to check the band again when
+	// the change takes effect, and empty if the salary was within it.
+	BandOverride string
+	CreatedBy    string
+	CreatedAt    time.Time
+}
+
+// Validate reports a change that cannot be stored.
+func (c SalaryChange) Validate() error {
+	if !slices.Contains(SalaryReasons, c.Reason) {
+		return fmt.Errorf("%w: unknown salary change reason %q", ErrConstraint, c.Reason)
+	}
+	if c.Salary.Amount <= 0 {
+		return fmt.Errorf("%w: a salary must be positive", ErrConstraint)
+	}
+	return nil
+}
+
+// today is the current date, at midnight UTC.
+func today() time.Time {
+	return Date(time.Now())
+}
+
+// Date truncates t to its date in UTC.
+func Date(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dateValue is the value a date is stored as. Both dialects order ISO 8601
+// dates correctly, including SQLite, which compares them as text.
+func dateValue(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// effectiveSalary returns the change in effect on asOf among changes, which
+// are sorted by effective date.
+func effectiveSalary(changes []SalaryChange, asOf time.Time) (SalaryChange, bool) {
+	var current SalaryChange
+	var found bool
+	for _, c := range changes {
+		if c.EffectiveFrom.After(asOf) {
+			break
+		}
+		current, found = c, true
+	}
+	return current, found
+}
+
+// salaryInEffect returns the change among changes, which are sorted by
+// effective date, that sets the salary of an employee with position on
+// asOf: the latest one in effect by then, skipping those outside the band
+// of the position without an override, as ApplySalaryChanges refuses them.
+// The bands are the current ones, even for a past asOf.
+func salaryInEffect(changes []SalaryChange, asOf time.Time, position Position) (SalaryChange, bool) {
+	changes = slices.DeleteFunc(slices.Clone(changes), func(c SalaryChange) bool {
+		_, err := checkBand(WithBandOverride(context.Background(), c.BandOverride), position, c.Salary)
+		return err != nil
+	})
+	return effectiveSalary(changes, asOf)
+}
+
+const salaryColumns = `id, employee_id, salary_minor, currency, effective_from, reason, band_override, created_by, created_at`
+
+func scanSalaryChange(row interface{ Scan(...any) error }, c *SalaryChange) error {
+	err := row.Scan(&c.ID, &c.EmployeeID, &c.Salary.Amount, &c.Salary.Currency, &c.EffectiveFrom, &c.Reason, &c.BandOverride, &c.CreatedBy, &c.CreatedAt)
+	c.EffectiveFrom = Date(c.EffectiveFrom)
+	c.CreatedAt = c.CreatedAt.UTC()
+	return err
+}
+
+// insertSalaryChange stores change in tx. With replace, a change of the
+// employee effective the same day is overwritten rather than a conflict.
+func (e *employeeDB) insertSalaryChange(ctx context.Context, tx *sql.Tx, change SalaryChange, replace bool) (SalaryChange, error) {
+	change.CreatedBy = ActorFrom(ctx)
+	change.CreatedAt = now()
+	query := `INSERT INTO salary_history (employee_id, salary_minor, currency, effective_from, reason, band_override, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	if replace {
+		query += ` ON CONFLICT (employee_id, effective_from) DO UPDATE SET salary_minor=excluded.salary_minor, currency=excluded.currency,` +
+			` reason=excluded.reason, band_override=excluded.band_override, created_by=excluded.created_by, created_at=excluded.created_at`
+	}
+	query += ` RETURNING id`
+	err := tx.QueryRowContext(ctx, query, change.EmployeeID, change.Salary.Amount, change.Salary.Currency,
+		dateValue(change.EffectiveFrom), change.Reason, change.BandOverride, change.CreatedBy, change.CreatedAt).Scan(&change.ID)
+	return change, dbError(ctx, err)
+}
+
+func (e *employeeDB) ListSalaryChanges(ctx context.Context, employeeID int) ([]SalaryChange, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var changes []SalaryChange
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		changes, err = e.salaryChanges(ctx, tx, employeeID)
+		return err
+	})
+	return changes, err
+}
+
+// salaryChanges reads the salary history of an employee that is not deleted.
+func (e *employeeDB) salaryChanges(ctx context.Context, tx *sql.Tx, employeeID int) ([]SalaryChange, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM employees WHERE id=$1 AND deleted_at IS NULL)`, employeeID).Scan(&exists)
+	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	if !exists {
+		return nil, errEmployeeNotFound
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT `+salaryColumns+` FROM salary_history WHERE employee_id=$1 ORDER BY effective_from`, employeeID)
+	if err != nil {
+		return nil, dbError(ctx, err)
+	}
+	defer rows.Close()
+	var changes []SalaryChange
+	for rows.Next() {
+		var c SalaryChange
+		if err := scanSalaryChange(rows, &c); err != nil {
+			return nil, dbError(ctx, err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, dbError(ctx, rows.Err())
+}
+
+func (e *employeeDB) AddSalaryChange(ctx context.Context, change SalaryChange) (SalaryChange, error) {
+	if err := change.Validate(); err != nil {
+		return SalaryChange{}, err
+	}
+	change.EffectiveFrom = Date(change.EffectiveFrom)
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	err := e.inTx(ctx, func(tx *sql.Tx) error {
+		employee, err := e.lock(ctx, tx, change.EmployeeID)
+		if err != nil {
+			return err
+		}
+		if employee.DeletedAt != nil {
+			return errEmployeeNotFound
+		}
+		wctx, err := e.checkSalary(ctx, tx, employee, change.Salary)
+		if err != nil {
+			return err
+		}
+		change.BandOverride = BandOverrideFrom(wctx)
+		if change, err = e.insertSalaryChange(ctx, tx, change, false); err != nil {
+			return err
+		}
+		return e.applySalary(ctx, tx, employee, today())
+	})
+	return change, err
+}
+
+// checkSalary checks salary against the band of the position of employee,
+// read in tx, as checkBand does.
+func (e *employeeDB) checkSalary(ctx context.Context, tx *sql.Tx, employee Employee, salary Money) (context.Context, error) {
+	if employee.PositionID == 0 {
+		return WithBandOverride(ctx, ""), nil
+	}
+	position, err := e.position(ctx, tx, employee.PositionID)
+	if err != nil {
+		return ctx, err
+	}
+	return checkBand(ctx, position, salary)
+}
+
+// applySalary sets the salary of employee, locked in tx, to the one in
+// effect on asOf, if that differs. The band of the position is checked
+// again, with the override reason the change was added with.
+func (e *employeeDB) applySalary(ctx context.Context, tx *sql.Tx, employee Employee, asOf time.Time) error {
+	changes, err := e.salaryChanges(ctx, tx, employee.ID)
+	if err != nil {
+		return err
+	}
+	current, ok := effectiveSalary(changes, asOf)
+	if !ok || current.Salary == employee.Salary {
+		return nil
+	}
+	wctx, err := e.checkSalary(WithBandOverride(ctx, current.BandOverride), tx, employee, current.Salary)
+	if err != nil {
+		return err
+	}
+	_, err = e.write(wctx, tx, AuditUpdate, employee, []column{
+		{"salary_minor", current.Salary.Amount},
+		{"currency", current.Salary.Currency},
+	})
+	return err
+}
+
+// catchUpSalary sets the salary of employee, locked in tx, to the one reads
+// show today, if that differs, and returns the employee as it is then.
+func (e *employeeDB) catchUpSalary(ctx context.Context, tx *sql.Tx, employee Employee) (Employee, error) {
+	changes, err := e.salaryChanges(ctx, tx, employee.ID)
+	if err != nil {
+		return employee, err
+	}
+	var position Position
+	if employee.PositionID != 0 {
+		if position, err = e.position(ctx, tx, employee.PositionID); err != nil {
+			return employee, err
+		}
+	}
+	current, ok := salaryInEffect(changes, today(), position)
+	if !ok || current.Salary == employee.Salary {
+		return employee, nil
+	}
+	wctx, _ := checkBand(WithBandOverride(ctx, current.BandOverride), position, current.Salary)
+	return e.write(wctx, tx, AuditUpdate, employee, []column{
+		{"salary_minor", current.Salary.Amount},
+		{"currency", current.Salary.Currency},
+	})
+}
+
+func (e *employeeDB) ApplySalaryChanges(ctx context.Context, asOf time.Time) (int, error) {
+	asOf = Date(asOf)
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	// The employees whose latest effective change differs from their
+	// salary; each is then brought in line in a transaction of its own.
+	rows, err := e.db.QueryContext(ctx, `SELECT e.id FROM employees e JOIN salary_history h ON h.employee_id = e.id
+		WHERE e.deleted_at IS NULL AND h.effective_from = (
+			SELECT MAX(effective_from) FROM salary_history WHERE employee_id = e.id AND effective_from <= $1
+		) AND (h.salary_minor <> e.salary_minor OR h.currency <> e.currency)
+		ORDER BY e.id`, dateValue(asOf))
+	if err != nil {
+		return 0, dbError(ctx, err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, dbError(ctx, err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, dbError(ctx, err)
+	}
+
+	applied := 0
+	var outOfBand []error
+	for _, id := range ids {
+		err := e.inTx(ctx, func(tx *sql.Tx) error {
+			employee, err := e.lock(ctx, tx, id)
+			if err != nil || employee.DeletedAt != nil {
+				return err
+			}
+			return e.applySalary(ctx, tx, employee, asOf)
+		})
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// Purged meanwhile.
+		case errors.Is(err, ErrSalaryOutOfBand):
+			outOfBand = append(outOfBand, fmt.Errorf("employee %d: %w", id, err))
+		case err != nil:
+			return applied, err
+		default:
+			applied++
+		}
+	}
+	return applied, errors.Join(outOfBand...)
+}
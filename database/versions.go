@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// recordVersion stores employee, just written in tx, as the version current
+// from at, closing the span of the version before it.
+func (e *employeeDB) recordVersion(ctx context.Context, tx *sql.Tx, employee Employee, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `UPDATE employee_versions SET valid_to=$1 WHERE id=$2 AND valid_to IS NULL`, at, employee.ID)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO employee_versions (`+employeeColumns+`, valid_from) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		employee.ID, employee.Name, employee.Position, employee.Salary.Amount, employee.Salary.Currency, employee.Version, employee.DeletedAt, employee.DeletedBy,
+		nullID(employee.DepartmentID), nullID(employee.ManagerID), nullID(employee.PositionID), nullString(employee.ExternalID), nullString(employee.UserName), at)
+	return dbError(ctx, err)
+}
+
+// employeesAt renders a table of the employee rows as they are now, or as
+// they were at asOf unless it is zero, named employees so queries of the
+// current rows work on it unchanged. Its placeholders are numbered after the
+// args already in args.
+func employeesAt(asOf time.Time, args []any) (string, []any) {
+	if asOf.IsZero() {
+		args = append(args, dateValue(today()))
+		return salaryOn("employees", len(args), "") + " employees", args
+	}
+	args = append(args, asOf.UTC(), dateValue(asOf))
+	n := len(args) - 1
+	versions := fmt.Sprintf(`(SELECT %s FROM employee_versions WHERE valid_from <= $%d AND (valid_to IS NULL OR valid_to > $%d))`, employeeColumns, n, n)
+	// Changes recorded after asOf were not known then, even if backdated.
+	return salaryOn(versions, n+1, fmt.Sprintf(" AND c.created_at <= $%d", n)) + " employees", args
+}
+
+// salaryOn renders source, a table of employee rows, with the salary of each
+// replaced by the one its salary history has in effect on the date of
+// placeholder n, among the changes matching known, as salaryInEffect picks
+// it. The stored salary is only brought in line by ApplySalaryChanges some
+// time after a change takes effect, and is kept for employees without
+// history.
+func salaryOn(source string, n int, known string) string {
+	columns := strings.Replace(qualified("e"), "e.salary_minor, e.currency",
+		"COALESCE(h.salary_minor, e.salary_minor) AS salary_minor, COALESCE(h.currency, e.currency) AS currency", 1)
+	return fmt.Sprintf(`(SELECT %s FROM %s e LEFT JOIN salary_history h ON h.employee_id = e.id AND h.effective_from = (
+		SELECT MAX(c.effective_from) FROM salary_history c WHERE c.employee_id = e.id AND c.effective_from <= $%d%s AND (
+			c.band_override <> ''
+			OR NOT EXISTS (SELECT 1 FROM salary_bands WHERE position_id = e.position_id)
+			OR EXISTS (SELECT 1 FROM salary_bands b WHERE b.position_id = e.position_id AND b.currency = c.currency AND c.salary_minor BETWEEN b.min_minor AND b.max_minor)
+		)))`, columns, source, n, known)
+}
+
+func (e *employeeDB) GetEmployeeAsOf(ctx context.Context, id int, asOf time.Time) (Employee, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var employee Employee
+	table, args := employeesAt(asOf, []any{id})
+	query := `SELECT ` + employeeColumns + ` FROM ` + table + ` WHERE id=$1 AND deleted_at IS NULL`
+	err := scanEmployee(e.db.QueryRowContext(ctx, query, args...), &employee)
+	if err == sql.ErrNoRows {
+		return employee, errEmployeeNotFound
+	}
+	return employee, dbError(ctx, err)
+}
@@ -19,29 +19,87 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/employees": {
+        "/audit": {
             "get": {
-                "description": "List employees",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the writes to employees, oldest first: who made them, on behalf of which request, and how each field\nchanged. Pass next_after back as after to read the next page.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "employees"
+                    "audit"
                 ],
-                "summary": "List employees",
+                "summary": "Query the audit log",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
+                        "description": "Employee ID",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Actor who made the change",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete",
+                            "restore",
+                            "purge"
+                        ],
+                        "type": "string",
+                        "description": "Kind of change",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the request that made the change",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "position",
+                            "salary",
+                            "currency",
+                            "deleted_at",
+                            "deleted_by"
+                        ],
+                        "type": "string",
+                        "description": "Field the change touched",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Earliest time of the change, inclusive, in RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Latest time of the change, exclusive, in RFC 3339",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_after of the previous page",
+                        "name": "after",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Number of items per page",
+                        "default": 10,
+                        "description": "Number of entries per page, at most 100",
                         "name": "per_page",
                         "in": "query"
                     }
@@ -50,37 +108,82 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ListEmployeesResponse"
+                            "$ref": "#/definitions/handlers.AuditResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments": {
+            "get": {
+                "description": "List every department, by name. Filter the employee list by department_id for the employees of one.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "List departments",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DepartmentsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new employee",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "employees"
+                    "departments"
                 ],
-                "summary": "Create a new employee",
+                "summary": "Create a department",
                 "parameters": [
                     {
-                        "description": "Employee body",
+                        "description": "Department",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.EmployeeParams"
+                            "$ref": "#/definitions/handlers.DepartmentParams"
                         }
                     }
                 ],
@@ -88,41 +191,50 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                            "$ref": "#/definitions/handlers.DepartmentResponse"
                         }
                     },
                     "400": {
                         "description": "Invalid request payload",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A department with that name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
         },
-        "/employees/{id}": {
+        "/departments/{id}": {
             "get": {
-                "description": "Get an employee by ID",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "employees"
+                    "departments"
                 ],
-                "summary": "Get an employee by ID",
+                "summary": "Get a department by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Employee ID",
+                        "description": "Department ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -132,50 +244,62 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                            "$ref": "#/definitions/handlers.DepartmentResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid employee ID",
+                        "description": "Invalid department ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "404": {
-                        "description": "Employee not found",
+                        "description": "Department not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update an employee",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "employees"
+                    "departments"
                 ],
-                "summary": "Update an employee",
+                "summary": "Rename a department",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Employee ID",
+                        "description": "Department ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Employee object that needs to be updated",
+                        "description": "Department",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.EmployeeParams"
+                            "$ref": "#/definitions/handlers.DepartmentParams"
                         }
                     }
                 ],
@@ -183,39 +307,55 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                            "$ref": "#/definitions/handlers.DepartmentResponse"
                         }
                     },
                     "400": {
                         "description": "Invalid request payload",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "404": {
-                        "description": "Employee not found",
+                        "description": "Department not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A department with that name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete an employee by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete a department no employee belongs to, counting deleted employees until they are purged.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
-                    "employees"
+                    "departments"
                 ],
-                "summary": "Delete an employee by ID",
+                "summary": "Delete a department",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Employee ID",
+                        "description": "Department ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -223,70 +363,2303 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Employee deleted",
+                        "description": "Department deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid employee ID",
+                        "description": "Invalid department ID",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "404": {
-                        "description": "Employee not found",
+                        "description": "Department not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Employees belong to the department",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.EmployeeParams": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "position": {
-                    "type": "string"
-                },
-                "salary": {
-                    "type": "number"
-                }
-            }
-        },
-        "handlers.EmployeeResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "position": {
-                    "type": "string"
-                },
-                "salary": {
-                    "type": "integer"
-                }
-            }
         },
-        "handlers.ListEmployeesResponse": {
-            "type": "object",
-            "properties": {
-                "employees": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handlers.EmployeeResponse"
+        "/employees": {
+            "get": {
+                "description": "List employees, optionally filtered and sorted. Text filters ignore case. A salary range is read in\ncurrency, USD if omitted, and only matches salaries paid in it.\n\nPages are read by keyset: pass next_cursor or prev_cursor back as cursor to get the neighbouring page\nwith the same filters and sort. The Link header (RFC 8288) carries the same links. Passing page instead\nselects the older offset paging.\n\nDeleted employees are left out unless an admin sets include_deleted. With as_of the employees are listed as\nthey were at that time.\n\nThe page is returned as JSON, XML, YAML or CSV, whichever Accept prefers. CSV has a row per employee with\nevery field and no total; the neighbouring pages are in the Link header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "List employees",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor from a previous response; cannot be combined with page, sort or filters",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, for offset paging",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page, at most 100",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Set to false to skip counting the matching employees",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Also list deleted employees; admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Time to list the employees at, in RFC 3339",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name prefix",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact position",
+                        "name": "position",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text to search for in name and position",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency of the salary",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest salary, inclusive",
+                        "name": "salary_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Highest salary, inclusive",
+                        "name": "salary_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the department of the employees",
+                        "name": "department_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-salary,name",
+                        "description": "Comma-separated fields among id, name, position and salary; prefix a field with - to sort it descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListEmployeesResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the first, next and previous pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, sort or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Deleted employees were requested by someone other than an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "None of the accepted types can be produced",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new employee. The body is read as its Content-Type: JSON, XML, YAML, or CSV with a header and\none row named like the columns of an import. The employee is returned in the type Accept prefers.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Create a new employee",
+                "parameters": [
+                    {
+                        "description": "Employee body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "None of the accepted types can be produced",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict with existing data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Constraint violation",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/export": {
+            "get": {
+                "description": "Stream every employee matching the filters of the list endpoint, in its sort order, as CSV, NDJSON (one\nJSON object per line) or an XLSX workbook. The employees are read from one consistent snapshot, however\nlong the export takes, and are not held in memory. fields chooses the columns and their order; by default\nevery field is exported, deleted_at and deleted_by only with include_deleted. Text that a spreadsheet\nwould take for a formula is prefixed with an apostrophe in CSV.\n\nErrors found before the first employee get a problem response. An error after it aborts the response,\nso that a truncated export cannot pass for a complete one.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Export employees",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to export, such as id,name,salary",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by name prefix (case-insensitive)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by exact position (case-insensitive)",
+                        "name": "position",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search name and position (case-insensitive substring)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by department",
+                        "name": "department_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by salary currency",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum salary, in currency or USD",
+                        "name": "salary_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum salary, in currency or USD",
+                        "name": "salary_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields among id, name, position and salary, - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export deleted employees too; admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Time to read the employees at, in RFC 3339",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The employees",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Deleted employees were requested by someone other than an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/import": {
+            "post": {
+                "description": "Create employees from the rows of a CSV file or of the first worksheet of an XLSX workbook, sent as the\nbody or as the file part of a multipart form. The first row is the header. Headers name the fields of\nEmployeeParams, ignoring case, spaces and hyphens, or common aliases such as \"Full Name\" or \"Job Title\";\nmap_\u003cfield\u003e=\u003cheader\u003e maps any other header to a field. Columns that map to no field are ignored and\nlisted in the response.\n\nEvery row is validated like a created employee, and either every employee is imported or, if any row\nis invalid, none is. With dry_run=true the rows are only checked. With Accept: text/csv the response is\na CSV report of the invalid rows, with their errors in a last column, to fix and upload again.",
+                "consumes": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Import employees from a spreadsheet",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file, when sent as a multipart form",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only check the rows",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Header of the column holding the name; likewise map_salary and the other fields",
+                        "name": "map_name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Employees imported",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid file, header or parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Neither CSV nor XLSX",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid rows; nothing was imported",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}": {
+            "get": {
+                "description": "Get an employee by ID. The response carries an ETag; send it back in If-None-Match to get 304 while the\nemployee is unchanged. The employee is returned as JSON, XML, YAML or CSV, whichever Accept prefers; the\nETag is the version followed by the format, such as 3-xml, except for JSON.\n\nWith as_of the employee is returned as it was at that time, and not found if it did not exist yet or was\ndeleted then.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Get an employee by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Time to read the employee at, in RFC 3339",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee, for If-Match"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid employee ID or time",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "None of the accepted types can be produced",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an employee. With If-Match the update only applies if the employee still has that ETag. The\nbody is read as its Content-Type and the employee returned in the type Accept prefers, as on create.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Update an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the employee must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Employee object that needs to be updated",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "None of the accepted types can be produced",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict with existing data",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The employee no longer matches If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Constraint violation",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an employee by ID. With If-Match the delete only applies if the employee still has that ETag.\nDeleted employees can be restored until they are purged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Delete an employee by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the employee must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Employee deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Other employees report to the employee",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The employee no longer matches If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to an employee. The patch applies to the\nfields of EmployeeParams, and the result is validated like a full update. Only changed fields are written.\nWith If-Match the patch only applies if the employee still has that ETag.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Partially update an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the employee must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "JSON Merge Patch document or array of JSON Patch operations",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patch or resulting employee",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A JSON Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "The employee no longer matches If-Match, or changed while the patch was applied",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The patch cannot be applied to the employee",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/chain": {
+            "get": {
+                "description": "List the manager of an employee, the manager of that manager and so on up to the head of the hierarchy.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "hierarchy"
+                ],
+                "summary": "List the managers above an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReportingChainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/history": {
+            "get": {
+                "description": "List the audit entries of an employee, oldest first, including those written before it was deleted or\npurged. Accepts the filters of the audit endpoint other than employee_id.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "List the changes to an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Actor who made the change",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete",
+                            "restore",
+                            "purge"
+                        ],
+                        "type": "string",
+                        "description": "Kind of change",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the request that made the change",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "position",
+                            "salary",
+                            "currency",
+                            "deleted_at",
+                            "deleted_by"
+                        ],
+                        "type": "string",
+                        "description": "Field the change touched",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Earliest time of the change, inclusive, in RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Latest time of the change, exclusive, in RFC 3339",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_after of the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of entries per page, at most 100",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AuditResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID or filter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/reports": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "hierarchy"
+                ],
+                "summary": "List the direct reports of an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReportsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/restore": {
+            "post": {
+                "description": "Undo the deletion of an employee, as long as it has not been purged yet. The employee is returned in the\ntype Accept prefers, as on get.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "text/csv",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "employees"
+                ],
+                "summary": "Restore a deleted employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.EmployeeResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found, or already purged",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "None of the accepted types can be produced",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The employee is not deleted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The manager of the employee is deleted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/salary": {
+            "get": {
+                "description": "List every salary of an employee by the date it takes effect, including scheduled changes. The current\nsalary is the latest one effective today.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "salary"
+                ],
+                "summary": "List the salary history of an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SalaryHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a salary to the history of an employee. A salary effective today or earlier becomes the salary of the\nemployee unless a later one is already in effect; a later one is scheduled and takes effect on its date.\nThe salary must be within the band of the position of the employee unless a band_override_reason is\ngiven, both now and when the change takes effect.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "salary"
+                ],
+                "summary": "Change the salary of an employee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Salary change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SalaryChangeParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SalaryChangeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "The employee already has a salary change effective that day",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The salary is outside the band of the position",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{id}/subtree": {
+            "get": {
+                "description": "List the direct reports of an employee, their reports and so on, by depth and then ID. Depth is 1 for a\ndirect report.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "hierarchy"
+                ],
+                "summary": "List every employee under a manager",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Employee ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubtreeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid employee ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgchart": {
+            "get": {
+                "description": "Draw the reporting lines of the employees who are not deleted, as Graphviz DOT, a Mermaid flowchart or a\nstandalone SVG. Without root the chart starts from every employee who reports to no one. With depth\nonly that many levels of reports are drawn, and each cut-off manager shows how many employees it hides.",
+                "produces": [
+                    "image/svg+xml",
+                    "text/vnd.graphviz",
+                    "text/plain",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "hierarchy"
+                ],
+                "summary": "Draw the org chart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the employee at the top of the chart",
+                        "name": "root",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Levels of reports to draw under the top, all if omitted",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "position"
+                        ],
+                        "type": "string",
+                        "description": "Group the employees by position",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "svg",
+                            "dot",
+                            "mermaid"
+                        ],
+                        "type": "string",
+                        "default": "svg",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The chart",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Root employee not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/positions": {
+            "get": {
+                "description": "List the position catalog, most senior level first and then by code.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "positions"
+                ],
+                "summary": "List positions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a position to the catalog, with a salary band per currency. Employees given the position take its\ntitle, and their salary must be within its band for their currency unless the write gives a\nband_override_reason.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "positions"
+                ],
+                "summary": "Create a position",
+                "parameters": [
+                    {
+                        "description": "Position",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A position with that code already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/positions/{id}": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "positions"
+                ],
+                "summary": "Get a position by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Position ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid position ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Position not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace a position and its bands. A new title is given to every employee holding the position. New bands\napply to later writes only: salaries already outside them are left alone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "positions"
+                ],
+                "summary": "Update a position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Position ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Position",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionParams"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PositionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Position not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "A position with that code already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a position no employee holds, counting deleted employees until they are purged.",
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "positions"
+                ],
+                "summary": "Delete a position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Position ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Position deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid position ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Position not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Employees hold the position",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "database.Change": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AuditEntryResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "jane"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes maps each changed field to its values before and after the\nwrite.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/database.Change"
+                    }
+                },
+                "employee_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "hash": {
+                    "description": "Hash chains the entry to the one before it; see verify-audit.",
+                    "type": "string",
+                    "example": "886764163c366427b46e97add4bbccb346a17b570c5f315b1a0e5e85ee8d0564"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "host/abc123-000001"
+                }
+            }
+        },
+        "handlers.AuditResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AuditEntryResponse"
+                    }
+                },
+                "next_after": {
+                    "description": "NextAfter is passed back as after to read the next page, and is left\nout on the last one.",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.BandParams": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "max": {
+                    "type": "number",
+                    "example": 130000
+                },
+                "mid": {
+                    "type": "number",
+                    "example": 110000
+                },
+                "min": {
+                    "type": "number",
+                    "example": 90000
+                }
+            }
+        },
+        "handlers.BandResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "max": {
+                    "type": "number",
+                    "example": 130000
+                },
+                "mid": {
+                    "type": "number",
+                    "example": 110000
+                },
+                "min": {
+                    "type": "number",
+                    "example": 90000
+                }
+            }
+        },
+        "handlers.DepartmentParams": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Engineering"
+                }
+            }
+        },
+        "handlers.DepartmentResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Engineering"
+                }
+            }
+        },
+        "handlers.DepartmentsResponse": {
+            "type": "object",
+            "properties": {
+                "departments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DepartmentResponse"
+                    }
+                }
+            }
+        },
+        "handlers.EmployeeParams": {
+            "type": "object",
+            "properties": {
+                "band_override_reason": {
+                    "description": "BandOverrideReason lets the salary be outside the band of the\nposition. It is recorded in the audit log rather than stored with the\nemployee.",
+                    "type": "string",
+                    "example": "Retention offer"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "department_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "manager_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "salary": {
+                    "type": "number",
+                    "example": 50000.75
+                }
+            }
+        },
+        "handlers.EmployeeResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt and DeletedBy are only set on deleted employees, which are\nonly listed to admins.",
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "department_id": {
+                    "description": "DepartmentID and ManagerID are left out for an employee outside any\ndepartment or reporting to no one, and PositionID for one whose\nposition is not from the catalog.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manager_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "salary": {
+                    "type": "number",
+                    "example": 50000.75
+                }
+            }
+        },
+        "handlers.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "salary"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be greater than zero"
+                }
+            }
+        },
+        "handlers.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "employees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.EmployeeResponse"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ImportRowError"
+                    }
+                },
+                "ignored_columns": {
+                    "description": "IgnoredColumns are the headers that map to no field.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "rows": {
+                    "description": "Rows counts the employees the file holds, blank rows aside, and Valid\nthose that passed every check.",
+                    "type": "integer",
+                    "example": 250
+                },
+                "valid": {
+                    "type": "integer",
+                    "example": 248
+                }
+            }
+        },
+        "handlers.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "string",
+                    "example": "Annual Salary"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "salary"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be greater than zero"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handlers.ListEmployeesResponse": {
+            "type": "object",
+            "properties": {
+                "employees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.EmployeeResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total is left out when the request set total=false.",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.PositionParams": {
+            "type": "object",
+            "properties": {
+                "bands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BandParams"
+                    }
+                },
+                "code": {
+                    "type": "string",
+                    "example": "ENG-3"
+                },
+                "level": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Senior Engineer"
+                }
+            }
+        },
+        "handlers.PositionResponse": {
+            "type": "object",
+            "properties": {
+                "bands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BandResponse"
+                    }
+                },
+                "code": {
+                    "type": "string",
+                    "example": "ENG-3"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "level": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Senior Engineer"
+                }
+            }
+        },
+        "handlers.PositionsResponse": {
+            "type": "object",
+            "properties": {
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.PositionResponse"
+                    }
+                }
+            }
+        },
+        "handlers.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "The request has 2 invalid fields"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/employees"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "host/abc123-000001"
+                },
+                "rows": {
+                    "description": "Rows lists the invalid rows of a rejected import.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ImportRowError"
+                    }
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:employeemanager:problem:validation"
+                }
+            }
+        },
+        "handlers.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt and DeletedBy are only set on deleted employees, which are\nonly listed to admins.",
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "department_id": {
+                    "description": "DepartmentID and ManagerID are left out for an employee outside any\ndepartment or reporting to no one, and PositionID for one whose\nposition is not from the catalog.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "depth": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manager_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "salary": {
+                    "type": "number",
+                    "example": 50000.75
+                }
+            }
+        },
+        "handlers.ReportingChainResponse": {
+            "type": "object",
+            "properties": {
+                "managers": {
+                    "description": "Managers starts with the manager of the employee and ends with the\nhead of the hierarchy.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.EmployeeResponse"
+                    }
+                }
+            }
+        },
+        "handlers.ReportsResponse": {
+            "type": "object",
+            "properties": {
+                "employees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.EmployeeResponse"
+                    }
+                }
+            }
+        },
+        "handlers.SalaryChangeParams": {
+            "type": "object",
+            "properties": {
+                "band_override_reason": {
+                    "description": "BandOverrideReason lets the salary be outside the band of the\nposition. It is kept with the change, and the band is checked again\nwith it when a scheduled change takes effect.",
+                    "type": "string",
+                    "example": "Retention offer"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "effective_from": {
+                    "description": "EffectiveFrom is the date the salary takes effect, today if omitted.",
+                    "type": "string",
+                    "example": "2024-07-01"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "hire",
+                        "promotion",
+                        "merit",
+                        "adjustment",
+                        "correction",
+                        "demotion",
+                        "restructure"
+                    ],
+                    "example": "promotion"
+                },
+                "salary": {
+                    "type": "number",
+                    "example": 55000
+                }
+            }
+        },
+        "handlers.SalaryChangeResponse": {
+            "type": "object",
+            "properties": {
+                "band_override_reason": {
+                    "description": "BandOverrideReason is the reason the salary may be outside the band\nof the position, if it needed one.",
+                    "type": "string",
+                    "example": "Retention offer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "jane"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "effective_from": {
+                    "type": "string",
+                    "example": "2024-07-01"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "promotion"
+                },
+                "salary": {
+                    "type": "number",
+                    "example": 55000
+                },
+                "status": {
+                    "description": "Status tells whether the salary was paid in the past, is paid today\nor is scheduled to take effect.",
+                    "type": "string",
+                    "enum": [
+                        "past",
+                        "current",
+                        "scheduled"
+                    ]
+                }
+            }
+        },
+        "handlers.SalaryHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SalaryChangeResponse"
+                    }
+                }
+            }
+        },
+        "handlers.SubtreeResponse": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ReportResponse"
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
             }
         }
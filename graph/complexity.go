@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"strconv"
+
+	"github.com/theluckiestsoul/employeemanager/handlers"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// reportsEstimate is the number of reports the complexity of a query counts
+// for each employee, which is more than most managers have.
+const reportsEstimate = 10
+
+// complexity estimates the cost of running the operation named operation of
+// a query, or the only one if operation is empty: each field counts once for
+// every object it may be resolved on, so fields below a page of employees
+// count once per employee. It fails if the query does not parse, since a
+// query whose cost is unknown must not run.
+func complexity(query, operation string, vars map[string]any) (int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, err
+	}
+	cost := 0
+	for _, op := range doc.Operations {
+		if operation == "" || op.Name == operation {
+			c := costOf{doc: doc, op: op, vars: vars, visiting: map[string]bool{}}
+			cost = max(cost, c.selections(op.SelectionSet))
+		}
+	}
+	return cost, nil
+}
+
+type costOf struct {
+	doc  *ast.QueryDocument
+	op   *ast.OperationDefinition
+	vars map[string]any
+	// visiting holds the fragments being counted, so that fragments
+	// spreading each other, which the executor rejects, do not recurse
+	// forever.
+	visiting map[string]bool
+}
+
+func (c *costOf) selections(set ast.SelectionSet) int {
+	cost := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost += 1 + c.multiplier(sel)*c.selections(sel.SelectionSet)
+		case *ast.InlineFragment:
+			cost += c.selections(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment := c.doc.Fragments.ForName(sel.Name)
+			if fragment == nil || c.visiting[sel.Name] {
+				continue
+			}
+			c.visiting[sel.Name] = true
+			cost += c.selections(fragment.SelectionSet)
+			delete(c.visiting, sel.Name)
+		}
+	}
+	return cost
+}
+
+// multiplier returns how many objects the selections of field may be
+// resolved on.
+func (c *costOf) multiplier(field *ast.Field) int {
+	switch field.Name {
+	case "employees":
+		_, n := pageOf(0, c.intArgument(field, "perPage"))
+		return n
+	case "reports":
+		return reportsEstimate
+	}
+	return 1
+}
+
+// intArgument returns the value of an Int argument of field, given directly
+// or by a variable, or zero if it has none or it is not an integer.
+func (c *costOf) intArgument(field *ast.Field, name string) int32 {
+	arg := field.Arguments.ForName(name)
+	if arg == nil {
+		return 0
+	}
+	value := arg.Value
+	if value.Kind == ast.Variable {
+		if v, ok := c.vars[value.Raw]; ok {
+			return toInt32(v)
+		}
+		def := c.op.VariableDefinitions.ForName(value.Raw)
+		if def == nil || def.DefaultValue == nil {
+			return 0
+		}
+		value = def.DefaultValue
+	}
+	if value.Kind != ast.IntValue {
+		return 0
+	}
+	return toInt32(value.Raw)
+}
+
+func toInt32(v any) int32 {
+	var n int64
+	switch v := v.(type) {
+	case float64:
+		n = int64(v)
+	case string:
+		var err error
+		if n, err = strconv.ParseInt(v, 10, 32); err != nil {
+			return 0
+		}
+	default:
+		return 0
+	}
+	// Page sizes out of range fall back to the default or are capped anyway.
+	return int32(max(min(n, handlers.MaxPerPage+1), 0))
+}
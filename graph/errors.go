@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+// Codes of the errors resolvers return, in the code extension of each.
+const (
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeNotFound            = "NOT_FOUND"
+	CodeConstraintViolation = "CONSTRAINT_VIOLATION"
+	CodeConflict            = "CONFLICT"
+	CodePreconditionFailed  = "PRECONDITION_FAILED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeUnavailable         = "UNAVAILABLE"
+	CodeInternal            = "INTERNAL"
+	// CodeTooComplex and CodeParseFailed are the codes of queries the
+	// handler refuses to run.
+	CodeTooComplex  = "TOO_COMPLEX"
+	CodeParseFailed = "PARSE_FAILED"
+)
+
+// Error is an error of a resolver, whose extensions tell clients what went
+// wrong the way the problem types of the REST API do.
+type Error struct {
+	Code    string
+	Message string
+	// Fields lists the invalid fields of a VALIDATION_FAILED error.
+	Fields []handlers.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions holds the code of e, and its invalid fields if it has any.
+func (e *Error) Extensions() map[string]any {
+	ext := map[string]any{"code": e.Code}
+	if len(e.Fields) > 0 {
+		fields := make([]map[string]string, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = map[string]string{"field": fieldName(f.Field), "message": f.Message}
+		}
+		ext["fields"] = fields
+	}
+	return ext
+}
+
+// fieldName returns the name in the schema of a field named in snake case
+// by the REST API, such as bandOverrideReason for band_override_reason.
+func fieldName(field string) string {
+	words := strings.Split(field, "_")
+	for i := 1; i < len(words); i++ {
+		if words[i] == "id" {
+			words[i] = "Id"
+		} else if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// invalid is the VALIDATION_FAILED error of a single invalid argument.
+func invalid(field, message string) *Error {
+	return &Error{
+		Code:    CodeValidationFailed,
+		Message: "Invalid input: " + field + " " + message,
+		Fields:  []handlers.FieldError{{Field: field, Message: message}},
+	}
+}
+
+// toError returns the error of a resolver for an error from validation or
+// the database layer, with the messages the REST API has for it.
+func toError(ctx context.Context, err error) *Error {
+	var gqlErr *Error
+	var errs handlers.ValidationError
+	var code, msg string
+	switch {
+	case errors.As(err, &gqlErr):
+		return gqlErr
+	case errors.As(err, &errs):
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = fieldName(e.Field) + " " + e.Message
+		}
+		return &Error{Code: CodeValidationFailed, Message: "Invalid input: " + strings.Join(msgs, "; "), Fields: errs}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, database.ErrUnavailable):
+		code, msg = CodeUnavailable, "The database is unavailable, try again later"
+	case errors.Is(err, database.ErrNotFound):
+		code, msg = CodeNotFound, "Employee not found"
+	case errors.Is(err, database.ErrUnknownDepartment):
+		code, msg = CodeConstraintViolation, "The department does not exist"
+	case errors.Is(err, database.ErrUnknownManager):
+		code, msg = CodeConstraintViolation, "The manager does not exist or is deleted"
+	case errors.Is(err, database.ErrReportingCycle):
+		code, msg = CodeConstraintViolation, "The manager reports to the employee, directly or not"
+	case errors.Is(err, database.ErrUnknownPosition):
+		code, msg = CodeConstraintViolation, "The position is not in the catalog"
+	case errors.Is(err, database.ErrUnmatchedPosition):
+		code, msg = CodeConstraintViolation, "The position matches no single title of the catalog; give a positionId"
+	case errors.Is(err, database.ErrSalaryOutOfBand):
+		code, msg = CodeConstraintViolation, "The salary is outside the band of the position; give a bandOverrideReason to set it anyway"
+	case errors.Is(err, database.ErrHasReports):
+		code, msg = CodeConflict, "Other employees report to the employee; give them another manager first"
+	case errors.Is(err, database.ErrVersionMismatch):
+		code, msg = CodePreconditionFailed, "The employee has changed since it was read, fetch it again and retry"
+	case errors.Is(err, database.ErrConflict):
+		code, msg = CodeConflict, "The change conflicts with existing data"
+	case errors.Is(err, database.ErrConstraint):
+		code, msg = CodeConstraintViolation, "The change violates a data constraint"
+	default:
+		log.Printf("request %s: %v", database.RequestIDFrom(ctx), err)
+		code, msg = CodeInternal, "Internal server error"
+	}
+	return &Error{Code: code, Message: msg}
+}
@@ -0,0 +1,154 @@
+// Package graph serves employees over GraphQL, with the schema of
+// schema.graphql, on top of the database and the validation of the REST API
+// in package handlers.
+package graph
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+//go:embed schema.graphql
+var schemaDefinition string
+
+//go:embed graphiql.html
+var graphiQL []byte
+
+// Default limits of the queries a handler runs.
+const (
+	DefaultMaxDepth      = 8
+	DefaultMaxComplexity = 1000
+)
+
+// maxBodySize bounds the requests QueryHandler reads.
+const maxBodySize = 1 << 20
+
+type handler struct {
+	emp           database.EmployeeDB
+	admins        []string
+	maxDepth      int
+	maxComplexity int
+	schema        *graphql.Schema
+}
+
+// Option configures a handler.
+type Option func(*handler)
+
+// WithAdmins names the actors allowed to list deleted employees.
+func WithAdmins(actors ...string) Option {
+	return func(h *handler) {
+		h.admins = append(h.admins, actors...)
+	}
+}
+
+// WithMaxDepth sets how deeply fields may be nested in a query.
+func WithMaxDepth(depth int) Option {
+	return func(h *handler) {
+		h.maxDepth = depth
+	}
+}
+
+// WithMaxComplexity sets the largest complexity of the queries run, which
+// counts each field once for every object it may be resolved on.
+func WithMaxComplexity(complexity int) Option {
+	return func(h *handler) {
+		h.maxComplexity = complexity
+	}
+}
+
+func NewHandler(db database.EmployeeDB, opts ...Option) *handler {
+	h := &handler{emp: db, maxDepth: DefaultMaxDepth, maxComplexity: DefaultMaxComplexity}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.schema = graphql.MustParseSchema(schemaDefinition, &resolver{emp: db, admins: h.admins},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(h.maxDepth),
+	)
+	return h
+}
+
+// request is the body of a POST to QueryHandler.
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// QueryHandler runs the GraphQL query in the body of a request, as
+// described by the GraphQL over HTTP specification: errors of the query
+// are reported in the errors of a 200 response, with a code extension, and
+// only requests that are not GraphQL requests at all, or whose query does
+// not parse, fail with a 4xx status.
+// Writes are attributed to the actor of the request, as in the REST API.
+func (h *handler) QueryHandler(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeErrors(w, http.StatusUnsupportedMediaType, &gqlerrors.QueryError{Message: "The request body must be application/json"})
+		return
+	}
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeErrors(w, http.StatusBadRequest, &gqlerrors.QueryError{Message: "The request body must be a JSON object with a query"})
+		return
+	}
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, &gqlerrors.QueryError{Message: "The request has no query"})
+		return
+	}
+	cost, err := complexity(req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, parseError(err))
+		return
+	}
+	if cost > h.maxComplexity {
+		writeErrors(w, http.StatusOK, &gqlerrors.QueryError{
+			Message:    fmt.Sprintf("The query has a complexity of %d, more than the maximum of %d", cost, h.maxComplexity),
+			Extensions: map[string]any{"code": CodeTooComplex, "complexity": cost, "maxComplexity": h.maxComplexity},
+		})
+		return
+	}
+
+	ctx := withLoaders(r.Context(), h.emp)
+	res := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("request %s: %v", middleware.GetReqID(r.Context()), err)
+	}
+}
+
+// parseError returns the error of a query that does not parse.
+func parseError(err error) *gqlerrors.QueryError {
+	qerr := &gqlerrors.QueryError{Message: err.Error(), Extensions: map[string]any{"code": CodeParseFailed}}
+	var gerr *gqlerror.Error
+	if errors.As(err, &gerr) {
+		qerr.Message = gerr.Message
+		for _, loc := range gerr.Locations {
+			qerr.Locations = append(qerr.Locations, gqlerrors.Location{Line: loc.Line, Column: loc.Column})
+		}
+	}
+	return qerr
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...*gqlerrors.QueryError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(graphql.Response{Errors: errs})
+}
+
+// GraphiQLHandler serves GraphiQL, an IDE to explore the schema and run
+// queries from a browser, which QueryHandler answers at the same path.
+func (h *handler) GraphiQLHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(graphiQL)
+}
@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+// countingDB counts the batched lookups of employees and reports.
+type countingDB struct {
+	database.EmployeeDB
+	lookups atomic.Int32
+	exports atomic.Int32
+}
+
+func (c *countingDB) GetEmployeesByIDs(ctx context.Context, ids []int) ([]database.Employee, error) {
+	c.lookups.Add(1)
+	return c.EmployeeDB.GetEmployeesByIDs(ctx, ids)
+}
+
+func (c *countingDB) ExportEmployees(ctx context.Context, q database.ListQuery, fn func(database.Employee) error) error {
+	c.exports.Add(1)
+	return c.EmployeeDB.ExportEmployees(ctx, q, fn)
+}
+
+func newTestDB(t *testing.T) *countingDB {
+	t.Helper()
+	db := &countingDB{EmployeeDB: database.NewMemoryEmployee()}
+	ctx := context.Background()
+	if _, err := db.CreateDepartment(ctx, database.Department{Name: "Engineering"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, employee := range []database.Employee{
+		{Name: "Ada Lovelace", Position: "CTO", Salary: database.Money{Amount: 15000075, Currency: "USD"}, DepartmentID: 1},
+		{Name: "Grace Hopper", Position: "Engineer", Salary: database.Money{Amount: 900000000, Currency: "JPY"}, DepartmentID: 1, ManagerID: 1},
+		{Name: "Alan Turing", Position: "Engineer", Salary: database.Money{Amount: 7000000, Currency: "USD"}, ManagerID: 1},
+		{Name: "Edsger Dijkstra", Position: "Engineer", Salary: database.Money{Amount: 6500025, Currency: "EUR"}, ManagerID: 2},
+	} {
+		if _, err := db.CreateEmployee(ctx, employee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func newRouter(db database.EmployeeDB, opts ...Option) http.Handler {
+	g := NewHandler(db, opts...)
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(handlers.IdentifyActor)
+	r.Get("/graphql", g.GraphiQLHandler)
+	r.Post("/graphql", g.QueryHandler)
+	return r
+}
+
+func post(t *testing.T, h http.Handler, actor, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if actor != "" {
+		req.Header.Set(handlers.ActorHeader, actor)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// query returns the body of a request running query with vars.
+func query(query string, vars map[string]any) string {
+	body, _ := json.Marshal(request{Query: query, Variables: vars})
+	return string(body)
+}
+
+func TestQueryHandler(t *testing.T) {
+	h := newRouter(newTestDB(t), WithAdmins("root"), WithMaxComplexity(300))
+
+	tests := []struct {
+		name           string
+		actor          string
+		body           string
+		expectedStatus int
+	}{
+		{"employee", "", query(`{ employee(id: 2) { id name salary currency version department { name } manager { name } reports { name } } }`, nil), http.StatusOK},
+		{"missing employee", "", query(`{ employee(id: 42) { name } }`, nil), http.StatusOK},
+		{"invalid id", "", query(`{ employee(id: "x") { name } }`, nil), http.StatusOK},
+		{"employees", "", query(`query($filter: EmployeeFilter) { employees(perPage: 2, filter: $filter, sort: "-name") { total hasNextPage nodes { name position manager { name } } } }`,
+			map[string]any{"filter": map[string]any{"position": "engineer"}}), http.StatusOK},
+		{"employees with an invalid filter", "", query(`{ employees(filter: {currency: "XXX", salaryMin: "1"}) { total } }`, nil), http.StatusOK},
+		{"deleted employees for someone other than an admin", "jane", query(`{ employees(includeDeleted: true) { total } }`, nil), http.StatusOK},
+		{"create employee", "jane", query(`mutation($input: EmployeeInput!) { createEmployee(input: $input) { id name salary currency manager { name } } }`,
+			map[string]any{"input": map[string]any{"name": "Barbara Liskov", "position": "Engineer", "salary": "72000.50", "managerId": "1"}}), http.StatusOK},
+		{"create invalid employee", "jane", query(`mutation { createEmployee(input: {name: "", position: "Engineer", salary: "1.234", bandOverrideReason: ""}) { id } }`, nil), http.StatusOK},
+		{"create employee with a missing manager", "jane", query(`mutation { createEmployee(input: {name: "Nobody", position: "Engineer", salary: "1", managerId: "42"}) { id } }`, nil), http.StatusOK},
+		{"update employee", "jane", query(`mutation { updateEmployee(id: 3, version: 1, input: {name: "Alan M. Turing", position: "Engineer", salary: "71000"}) { name salary version } }`, nil), http.StatusOK},
+		{"update stale employee", "jane", query(`mutation { updateEmployee(id: 3, version: 1, input: {name: "Alan", position: "Engineer", salary: "1"}) { name } }`, nil), http.StatusOK},
+		{"delete employee with reports", "jane", query(`mutation { deleteEmployee(id: 1) }`, nil), http.StatusOK},
+		{"delete employee", "jane", query(`mutation { deleteEmployee(id: 4) }`, nil), http.StatusOK},
+		{"deleted employees for an admin", "root", query(`{ employees(includeDeleted: true, filter: {q: "dijkstra"}) { nodes { name deletedBy } } }`, nil), http.StatusOK},
+		{"reports of deleted employees", "root", query(`{ employees(includeDeleted: true) { nodes { id reports { id } } } }`, nil), http.StatusOK},
+		{"too deep", "", query(`{ employee(id: 4) { manager { manager { manager { manager { manager { manager { manager { name } } } } } } } } }`, nil), http.StatusOK},
+		{"too complex", "", query(`query($n: Int) { employees(perPage: $n) { nodes { name reports { name reports { name } } } } }`, map[string]any{"n": 50}), http.StatusOK},
+		{"syntax error", "", query(`{ employees { `, nil), http.StatusBadRequest},
+		{"no query", "", `{}`, http.StatusBadRequest},
+		{"malformed body", "", `{"query":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(t, h, tt.actor, tt.body)
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			cupaloy.SnapshotT(t, rr.Body.String())
+		})
+	}
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`query=%7B%7D`))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnsupportedMediaType)
+		}
+	})
+
+	t.Run("graphiql", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/graphql", nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "GraphiQL.createFetcher") {
+			t.Errorf("GET /graphql = %v: %s", rr.Code, rr.Body)
+		}
+	})
+}
+
+func TestLoaderBatchesLookups(t *testing.T) {
+	db := newTestDB(t)
+	h := newRouter(db)
+	rr := post(t, h, "", query(`{ employees { nodes { name manager { name manager { name } } } } }`, nil))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"errors"`) {
+		t.Fatalf("got %v: %s", rr.Code, rr.Body)
+	}
+	// The managers of the page are looked up at once, and theirs are
+	// among them.
+	if got := db.lookups.Load(); got != 1 {
+		t.Errorf("looked up employees %d times, want 1", got)
+	}
+}
+
+func TestLoaderBatchesReports(t *testing.T) {
+	db := newTestDB(t)
+	h := newRouter(db)
+	rr := post(t, h, "", query(`{ employees(perPage: 5) { nodes { name reports { name reports { name } } } } }`, nil))
+	if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"errors"`) {
+		t.Fatalf("got %v: %s", rr.Code, rr.Body)
+	}
+	// The reports of the page are read at once, and theirs are among them.
+	if got := db.exports.Load(); got != 1 {
+		t.Errorf("read reports %d times, want 1", got)
+	}
+}
+
+func TestReportsAsOf(t *testing.T) {
+	db := newTestDB(t)
+	h := newRouter(db)
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	// Alan moves from Ada to Grace.
+	managerID := 2
+	if _, err := db.PatchEmployee(context.Background(), 3, database.EmployeeChanges{ManagerID: &managerID}); err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct {
+		Data struct {
+			Employee  struct{ Reports []struct{ ID string } }
+			Employees struct {
+				Nodes []struct {
+					ID      string
+					Reports []struct{ ID string }
+				}
+			}
+		}
+		Errors []any
+	}
+	rr := post(t, h, "", query(`query($t: Time) { employee(id: 1, asOf: $t) { reports { id } } employees(asOf: $t, perPage: 1) { nodes { id reports { id } } } }`,
+		map[string]any{"t": before.Format(time.RFC3339Nano)}))
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil || len(res.Errors) != 0 {
+		t.Fatalf("got %v: %s", err, rr.Body)
+	}
+	for _, reports := range [][]struct{ ID string }{res.Data.Employee.Reports, res.Data.Employees.Nodes[0].Reports} {
+		var ids []string
+		for _, report := range reports {
+			ids = append(ids, report.ID)
+		}
+		if !slices.Equal(ids, []string{"2", "3"}) {
+			t.Errorf("reports of Ada as of before the move = %v, want [2 3]", ids)
+		}
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		vars      map[string]any
+		want      int
+	}{
+		{"fields", `{ employee(id: 1) { id name } }`, "", nil, 3},
+		{"default page size", `{ employees { total nodes { name } } }`, "", nil, 1 + 10*3},
+		{"page size argument", `{ employees(perPage: 3) { nodes { name } } }`, "", nil, 1 + 3*2},
+		{"page size variable", `query($n: Int) { employees(perPage: $n) { nodes { name } } }`, "", map[string]any{"n": float64(4)}, 1 + 4*2},
+		{"page size variable default", `query($n: Int = 5) { employees(perPage: $n) { nodes { name } } }`, "", nil, 1 + 5*2},
+		{"page size capped", `{ employees(perPage: 1000) { nodes { name } } }`, "", nil, 1 + 100*2},
+		{"reports", `{ employee(id: 1) { reports { name reports { name } } } }`, "", nil, 1 + 1 + 10*(1+1+10)},
+		{"fragments", `{ employee(id: 1) { ...f } } fragment f on Employee { name manager { ...f } }`, "", nil, 3},
+		{"named operation", `query A { employee(id: 1) { id } } query B { employees { nodes { id } } }`, "A", nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := complexity(tt.query, tt.operation, tt.vars)
+			if err != nil || got != tt.want {
+				t.Errorf("complexity() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
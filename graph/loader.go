@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// Batches of loads are fetched once batchWait has passed since their first
+// load, or as soon as they reach maxBatch keys.
+const (
+	batchWait = time.Millisecond
+	maxBatch  = 100
+)
+
+// loader batches the loads of values by key made within batchWait of each
+// other into one fetch, and remembers the results for the rest of the
+// request. Resolvers of sibling fields run concurrently, so the managers of
+// a page of employees are read with one query rather than one each.
+type loader[K comparable, V any] struct {
+	// fetch returns the values of keys, leaving out those that do not
+	// exist. It may return values of other keys too.
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	batch   *batch[K]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	ok    bool
+	err   error
+}
+
+type batch[K comparable] struct {
+	keys []K
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, results: make(map[K]*result[V])}
+}
+
+// load returns the value of key, and whether it exists.
+func (l *loader[K, V]) load(ctx context.Context, key K) (V, bool, error) {
+	l.mu.Lock()
+	res, ok := l.results[key]
+	if !ok {
+		res = &result[V]{done: make(chan struct{})}
+		l.results[key] = res
+		if l.batch == nil {
+			b := &batch[K]{}
+			l.batch = b
+			time.AfterFunc(batchWait, func() { l.dispatch(ctx, b) })
+		}
+		l.batch.keys = append(l.batch.keys, key)
+		if len(l.batch.keys) == maxBatch {
+			go l.dispatch(ctx, l.batch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.ok, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, false, ctx.Err()
+	}
+}
+
+// dispatch fetches the keys of b, unless they were already.
+func (l *loader[K, V]) dispatch(ctx context.Context, b *batch[K]) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	results := make([]*result[V], len(b.keys))
+	for i, key := range b.keys {
+		results[i] = l.results[key]
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, b.keys)
+	for i, key := range b.keys {
+		res := results[i]
+		res.value, res.ok = values[key]
+		res.err = err
+		close(res.done)
+	}
+}
+
+// loaders are the loaders of a request, which resolvers find in its context.
+type loaders struct {
+	employees   *loader[int, database.Employee]
+	departments *loader[int, database.Department]
+	positions   *loader[int, database.Position]
+	reports     *loader[reportsKey, []database.Employee]
+}
+
+// reportsKey names the reports of a manager as of a time, zero for now.
+type reportsKey struct {
+	managerID int
+	asOf      time.Time
+}
+
+type loadersKey struct{}
+
+// withLoaders returns ctx with new loaders reading from db.
+func withLoaders(ctx context.Context, db database.EmployeeDB) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		employees: newLoader(func(ctx context.Context, ids []int) (map[int]database.Employee, error) {
+			employees, err := db.GetEmployeesByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int]database.Employee, len(employees))
+			for _, employee := range employees {
+				byID[employee.ID] = employee
+			}
+			return byID, nil
+		}),
+		// There are few departments and positions, so a batch reads them
+		// all rather than adding lookups by ID to the database.
+		departments: newLoader(func(ctx context.Context, _ []int) (map[int]database.Department, error) {
+			departments, err := db.ListDepartments(ctx)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int]database.Department, len(departments))
+			for _, department := range departments {
+				byID[department.ID] = department
+			}
+			return byID, nil
+		}),
+		positions: newLoader(func(ctx context.Context, _ []int) (map[int]database.Position, error) {
+			positions, err := db.ListPositions(ctx)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[int]database.Position, len(positions))
+			for _, position := range positions {
+				byID[position.ID] = position
+			}
+			return byID, nil
+		}),
+		// The reports of a batch of managers are read with one query for
+		// each time they are read as of, which is the same for all of them
+		// but in the rarest of queries.
+		reports: newLoader(func(ctx context.Context, keys []reportsKey) (map[reportsKey][]database.Employee, error) {
+			managers := make(map[time.Time][]int)
+			for _, key := range keys {
+				managers[key.asOf] = append(managers[key.asOf], key.managerID)
+			}
+			reports := make(map[reportsKey][]database.Employee)
+			for asOf, ids := range managers {
+				err := db.ExportEmployees(ctx, database.ListQuery{ManagerIDs: ids, AsOf: asOf}, func(report database.Employee) error {
+					key := reportsKey{managerID: report.ManagerID, asOf: asOf}
+					reports[key] = append(reports[key], report)
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			return reports, nil
+		}),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
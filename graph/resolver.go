@@ -0,0 +1,404 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+// resolver resolves the Query and Mutation types of the schema.
+type resolver struct {
+	emp    database.EmployeeDB
+	admins []string
+}
+
+func (r *resolver) Employee(ctx context.Context, args struct {
+	ID   graphql.ID
+	AsOf *graphql.Time
+}) (*employeeResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+	var employee database.Employee
+	var asOf time.Time
+	if args.AsOf == nil {
+		employee, err = r.emp.GetEmployeeByID(ctx, id)
+	} else {
+		asOf = args.AsOf.Time
+		employee, err = r.emp.GetEmployeeAsOf(ctx, id, asOf)
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &employeeResolver{r: r, e: employee, asOf: asOf}, nil
+}
+
+// employeeFilter is the EmployeeFilter input of the schema.
+type employeeFilter struct {
+	Name         *string
+	Position     *string
+	Q            *string
+	DepartmentID *graphql.ID
+	Currency     *string
+	SalaryMin    *string
+	SalaryMax    *string
+}
+
+func (r *resolver) Employees(ctx context.Context, args struct {
+	Page           int32
+	PerPage        int32
+	Filter         *employeeFilter
+	Sort           *string
+	IncludeDeleted bool
+	AsOf           *graphql.Time
+}) (*connectionResolver, error) {
+	// The arguments are read by the parser of the REST API query, so they
+	// are checked the same way.
+	params := url.Values{}
+	set := func(name string, value *string) {
+		if value != nil && *value != "" {
+			params.Set(name, *value)
+		}
+	}
+	if f := args.Filter; f != nil {
+		set("name", f.Name)
+		set("position", f.Position)
+		set("q", f.Q)
+		set("department_id", (*string)(f.DepartmentID))
+		set("currency", f.Currency)
+		set("salary_min", f.SalaryMin)
+		set("salary_max", f.SalaryMax)
+	}
+	set("sort", args.Sort)
+	if args.IncludeDeleted {
+		params.Set("include_deleted", "true")
+	}
+	if args.AsOf != nil {
+		params.Set("as_of", args.AsOf.Format(time.RFC3339Nano))
+	}
+	q, err := handlers.ParseListQuery(params)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	if q.IncludeDeleted && !handlers.IsAdmin(ctx, r.admins) {
+		return nil, &Error{Code: CodeForbidden, Message: "Only admins may list deleted employees"}
+	}
+	q.Page, q.PerPage = pageOf(args.Page, args.PerPage)
+	page, err := r.emp.ListEmployees(ctx, q)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &connectionResolver{r: r, page: page, asOf: q.AsOf}, nil
+}
+
+// pageOf returns the page and page size of a listing. Like the REST API, it
+// falls back to the defaults for bad values and caps page sizes at
+// handlers.MaxPerPage.
+func pageOf(page, perPage int32) (int, int) {
+	p, n := 1, handlers.DefaultPerPage
+	if page > 0 {
+		p = int(page)
+	}
+	if perPage > 0 {
+		n = min(int(perPage), handlers.MaxPerPage)
+	}
+	return p, n
+}
+
+// employeeInput is the EmployeeInput input of the schema.
+type employeeInput struct {
+	Name               string
+	Position           *string
+	Salary             string
+	Currency           *string
+	DepartmentID       *graphql.ID
+	ManagerID          *graphql.ID
+	PositionID         *graphql.ID
+	BandOverrideReason *string
+}
+
+// params returns the parameters of the REST API that in stands for, which
+// validate it the same way.
+func (in employeeInput) params() (handlers.EmployeeParams, error) {
+	params := handlers.EmployeeParams{
+		Name:               in.Name,
+		Position:           deref(in.Position),
+		Salary:             json.Number(in.Salary),
+		Currency:           deref(in.Currency),
+		BandOverrideReason: deref(in.BandOverrideReason),
+	}
+	for _, ref := range []struct {
+		field string
+		id    *graphql.ID
+		dest  *int
+	}{
+		{"departmentId", in.DepartmentID, &params.DepartmentID},
+		{"managerId", in.ManagerID, &params.ManagerID},
+		{"positionId", in.PositionID, &params.PositionID},
+	} {
+		if ref.id == nil {
+			continue
+		}
+		id, err := parseID(ref.field, *ref.id)
+		if err != nil {
+			return params, err
+		}
+		*ref.dest = id
+	}
+	return params, params.Validate()
+}
+
+func (r *resolver) CreateEmployee(ctx context.Context, args struct{ Input employeeInput }) (*employeeResolver, error) {
+	params, err := args.Input.params()
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	employee, err := r.emp.CreateEmployee(params.Context(ctx), params.ToEmployee())
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &employeeResolver{r: r, e: employee}, nil
+}
+
+func (r *resolver) UpdateEmployee(ctx context.Context, args struct {
+	ID      graphql.ID
+	Input   employeeInput
+	Version *int32
+}) (*employeeResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := parseVersion(args.Version)
+	if err != nil {
+		return nil, err
+	}
+	params, err := args.Input.params()
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	employee := params.ToEmployee()
+	employee.ID, employee.Version = id, version
+	updated, err := r.emp.UpdateEmployee(params.Context(ctx), employee)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	return &employeeResolver{r: r, e: updated}, nil
+}
+
+func (r *resolver) DeleteEmployee(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+}) (bool, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return false, err
+	}
+	version, err := parseVersion(args.Version)
+	if err != nil {
+		return false, err
+	}
+	if err := r.emp.DeleteEmployee(ctx, id, version); err != nil {
+		return false, toError(ctx, err)
+	}
+	return true, nil
+}
+
+func parseID(field string, id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, invalid(field, "must be a positive integer")
+	}
+	return n, nil
+}
+
+func parseVersion(version *int32) (int, error) {
+	if version == nil {
+		return 0, nil
+	}
+	if *version < 0 {
+		return 0, invalid("version", "must not be negative")
+	}
+	return int(*version), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+type connectionResolver struct {
+	r    *resolver
+	page database.EmployeePage
+	asOf time.Time
+}
+
+func (c *connectionResolver) Nodes() []*employeeResolver {
+	nodes := make([]*employeeResolver, len(c.page.Employees))
+	for i, employee := range c.page.Employees {
+		nodes[i] = &employeeResolver{r: c.r, e: employee, asOf: c.asOf}
+	}
+	return nodes
+}
+
+func (c *connectionResolver) Total() int32 {
+	return int32(c.page.Total)
+}
+
+func (c *connectionResolver) HasNextPage() bool {
+	return c.page.More
+}
+
+type employeeResolver struct {
+	r *resolver
+	e database.Employee
+	// asOf is the time e was read as of, or zero for now. Its reports are
+	// read as of the same time.
+	asOf time.Time
+}
+
+func (e *employeeResolver) ID() graphql.ID {
+	return toID(e.e.ID)
+}
+
+func (e *employeeResolver) Name() string {
+	return e.e.Name
+}
+
+func (e *employeeResolver) Position() string {
+	return e.e.Position
+}
+
+func (e *employeeResolver) Salary() string {
+	return e.e.Salary.String()
+}
+
+func (e *employeeResolver) Currency() string {
+	return e.e.Salary.Currency
+}
+
+func (e *employeeResolver) Version() int32 {
+	return int32(e.e.Version)
+}
+
+func (e *employeeResolver) Department(ctx context.Context) (*departmentResolver, error) {
+	if e.e.DepartmentID == 0 {
+		return nil, nil
+	}
+	department, ok, err := loadersFrom(ctx).departments.load(ctx, e.e.DepartmentID)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &departmentResolver{department}, nil
+}
+
+// Manager is null for an employee reporting to no one, and for one read as
+// of a time its manager has since been deleted.
+func (e *employeeResolver) Manager(ctx context.Context) (*employeeResolver, error) {
+	if e.e.ManagerID == 0 {
+		return nil, nil
+	}
+	manager, ok, err := loadersFrom(ctx).employees.load(ctx, e.e.ManagerID)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &employeeResolver{r: e.r, e: manager}, nil
+}
+
+func (e *employeeResolver) CatalogPosition(ctx context.Context) (*positionResolver, error) {
+	if e.e.PositionID == 0 {
+		return nil, nil
+	}
+	position, ok, err := loadersFrom(ctx).positions.load(ctx, e.e.PositionID)
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &positionResolver{position}, nil
+}
+
+// Reports lists the employees reporting to the employee at the time it was
+// read as of, which for a deleted employee are none. The complexity limit
+// counts them as a list of reportsEstimate employees.
+func (e *employeeResolver) Reports(ctx context.Context) ([]*employeeResolver, error) {
+	reports, _, err := loadersFrom(ctx).reports.load(ctx, reportsKey{managerID: e.e.ID, asOf: e.asOf})
+	if err != nil {
+		return nil, toError(ctx, err)
+	}
+	resolvers := make([]*employeeResolver, len(reports))
+	for i, report := range reports {
+		resolvers[i] = &employeeResolver{r: e.r, e: report, asOf: e.asOf}
+	}
+	return resolvers, nil
+}
+
+func (e *employeeResolver) DeletedAt() *graphql.Time {
+	if e.e.DeletedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *e.e.DeletedAt}
+}
+
+func (e *employeeResolver) DeletedBy() *string {
+	if e.e.DeletedAt == nil {
+		return nil
+	}
+	return &e.e.DeletedBy
+}
+
+type departmentResolver struct {
+	d database.Department
+}
+
+func (d *departmentResolver) ID() graphql.ID {
+	return toID(d.d.ID)
+}
+
+func (d *departmentResolver) Name() string {
+	return d.d.Name
+}
+
+type positionResolver struct {
+	p database.Position
+}
+
+func (p *positionResolver) ID() graphql.ID {
+	return toID(p.p.ID)
+}
+
+func (p *positionResolver) Code() string {
+	return p.p.Code
+}
+
+func (p *positionResolver) Title() string {
+	return p.p.Title
+}
+
+func (p *positionResolver) Level() int32 {
+	return int32(p.p.Level)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// ActorHeader names whoever sent a request. The API does not authenticate
+// requests itself: it expects the authenticating proxy in front of it to set
+// this header and to drop any value sent by the client.
+const ActorHeader = "X-Forwarded-User"
+
+// IdentifyActor attributes the writes made while serving a request to the
+// actor named by its ActorHeader, and to the request ID set by
+// middleware.RequestID, so the audit log can record both.
+func IdentifyActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			ctx = database.WithActor(ctx, actor)
+		}
+		if id := middleware.GetReqID(ctx); id != "" {
+			ctx = database.WithRequestID(ctx, id)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithAdmins names the actors allowed to see deleted employees.
+func WithAdmins(actors ...string) Option {
+	return func(h *handler) {
+		h.admins = append(h.admins, actors...)
+	}
+}
+
+// IsAdmin reports whether the actor of ctx, see IdentifyActor, is one of
+// admins. The GraphQL and gRPC APIs check their admins with it too.
+func IsAdmin(ctx context.Context, admins []string) bool {
+	actor := database.ActorFrom(ctx)
+	return actor != "" && slices.Contains(admins, actor)
+}
+
+func writeForbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, Problem{
+		Type:   ProblemTypeForbidden,
+		Status: http.StatusForbidden,
+		Detail: detail,
+	})
+}
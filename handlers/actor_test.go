@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestListDeletedEmployees(t *testing.T) {
+	edb := database.NewMemoryEmployee()
+	for _, name := range []string{"John Doe", "Jane Doe"} {
+		if _, err := edb.CreateEmployee(context.Background(), database.Employee{Name: name, Position: "Engineer", Salary: database.Money{Amount: 5000000, Currency: "USD"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandler(edb, WithAdmins("root"))
+	r := chi.NewRouter()
+	r.Use(IdentifyActor)
+	r.Get("/employees", h.ListEmployeesHandler)
+	r.Delete("/employees/{id}", h.DeleteEmployeeHandler)
+
+	req, _ := http.NewRequest("DELETE", "/employees/1", nil)
+	req.Header.Set(ActorHeader, "jane")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /employees/1 returned %d", rr.Code)
+	}
+
+	tests := []struct {
+		name           string
+		actor          string
+		query          string
+		expectedStatus int
+		expectedIDs    []int
+	}{
+		{
+			name:           "admin",
+			actor:          "root",
+			query:          "include_deleted=true",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{1, 2},
+		},
+		{
+			name:           "admin without include_deleted",
+			actor:          "root",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int{2},
+		},
+		{
+			name:           "not an admin",
+			actor:          "jane",
+			query:          "include_deleted=true",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "anonymous",
+			query:          "include_deleted=1",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid include_deleted",
+			actor:          "root",
+			query:          "include_deleted=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/employees?"+tt.query, nil)
+			if tt.actor != "" {
+				req.Header.Set(ActorHeader, tt.actor)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if rr.Code != http.StatusOK {
+				res := rr.Result()
+				defer res.Body.Close()
+				cupaloy.SnapshotT(t, dumpResponse(t, res))
+				return
+			}
+
+			// Deletion times vary, so the listing is checked field by field.
+			var response ListEmployeesResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, emp := range response.Employees {
+				ids = append(ids, emp.ID)
+				if deleted := emp.ID == 1; deleted != (emp.DeletedAt != nil) || deleted != (emp.DeletedBy == "jane") {
+					t.Errorf("employee %d has deleted_at %v and deleted_by %q", emp.ID, emp.DeletedAt, emp.DeletedBy)
+				}
+			}
+			if !slices.Equal(ids, tt.expectedIDs) {
+				t.Errorf("listed employees %v, want %v", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
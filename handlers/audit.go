@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// AuditEntryResponse is one write recorded in the audit log.
+type AuditEntryResponse struct {
+	ID         int64  `json:"id" example:"42"`
+	EmployeeID int    `json:"employee_id" example:"1"`
+	Action     string `json:"action" example:"update"`
+	Actor      string `json:"actor,omitempty" example:"jane"`
+	RequestID  string `json:"request_id,omitempty" example:"host/abc123-000001"`
+	// Changes maps each changed field to its values before and after the
+	// write.
+	Changes map[string]database.Change `json:"changes"`
+	At      time.Time                  `json:"at"`
+	// Hash chains the entry to the one before it; see verify-audit.
+	Hash string `json:"hash,omitempty" example:"886764163c366427b46e97add4bbccb346a17b570c5f315b1a0e5e85ee8d0564"`
+}
+
+type AuditResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	// NextAfter is passed back as after to read the next page, and is left
+	// out on the last one.
+	NextAfter int64 `json:"next_after,omitempty"`
+}
+
+// EmployeeHistoryHandler godoc
+// @Summary List the changes to an employee
+// @Description List the audit entries of an employee, oldest first, including those written before it was deleted or
+// @Description purged. Accepts the filters of the audit endpoint other than employee_id.
+// @Tags audit
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Param actor query string false "Actor who made the change"
+// @Param action query string false "Kind of change" Enums(create, update, delete, restore, purge)
+// @Param request_id query string false "ID of the request that made the change"
+// @Param field query string false "Field the change touched" Enums(name, position, salary, currency, deleted_at, deleted_by)
+// @Param since query string false "Earliest time of the change, inclusive, in RFC 3339" format(date-time)
+// @Param until query string false "Latest time of the change, exclusive, in RFC 3339" format(date-time)
+// @Param after query int false "next_after of the previous page"
+// @Param per_page query int false "Number of entries per page, at most 100" default(10)
+// @Success 200 {object} AuditResponse
+// @Failure 400 {object} Problem "Invalid employee ID or filter"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/history [get]
+func (h *handler) EmployeeHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	params := r.URL.Query()
+	params.Del("employee_id")
+	q, errs := parseAuditQuery(params)
+	if errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	q.EmployeeID = id
+	h.writeAudit(w, r, q)
+}
+
+// ListAuditHandler godoc
+// @Summary Query the audit log
+// @Description List the writes to employees, oldest first: who made them, on behalf of which request, and how each field
+// @Description changed. Pass next_after back as after to read the next page.
+// @Tags audit
+// @Produce json,application/problem+json
+// @Param employee_id query int false "Employee ID"
+// @Param actor query string false "Actor who made the change"
+// @Param action query string false "Kind of change" Enums(create, update, delete, restore, purge)
+// @Param request_id query string false "ID of the request that made the change"
+// @Param field query string false "Field the change touched" Enums(name, position, salary, currency, deleted_at, deleted_by)
+// @Param since query string false "Earliest time of the change, inclusive, in RFC 3339" format(date-time)
+// @Param until query string false "Latest time of the change, exclusive, in RFC 3339" format(date-time)
+// @Param after query int false "next_after of the previous page"
+// @Param per_page query int false "Number of entries per page, at most 100" default(10)
+// @Success 200 {object} AuditResponse
+// @Failure 400 {object} Problem "Invalid filter"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /audit [get]
+func (h *handler) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	q, errs := parseAuditQuery(r.URL.Query())
+	if errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	h.writeAudit(w, r, q)
+}
+
+func (h *handler) writeAudit(w http.ResponseWriter, r *http.Request, q database.AuditQuery) {
+	page, err := h.emp.ListAuditEntries(r.Context(), q)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	response := AuditResponse{Entries: make([]AuditEntryResponse, len(page.Entries))}
+	for i, entry := range page.Entries {
+		response.Entries[i] = AuditEntryResponse{
+			ID:         entry.ID,
+			EmployeeID: entry.EmployeeID,
+			Action:     entry.Action,
+			Actor:      entry.Actor,
+			RequestID:  entry.RequestID,
+			Changes:    entry.Changes,
+			At:         entry.At,
+			Hash:       entry.Hash,
+		}
+	}
+	if page.More {
+		response.NextAfter = page.Entries[len(page.Entries)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseAuditQuery reads the filters of the audit endpoints. Page sizes are
+// handled as in parseListRequest; bad filters are reported.
+func parseAuditQuery(params url.Values) (database.AuditQuery, ValidationError) {
+	q := database.AuditQuery{
+		Actor:     params.Get("actor"),
+		Action:    params.Get("action"),
+		RequestID: params.Get("request_id"),
+		Field:     params.Get("field"),
+		Limit:     DefaultPerPage,
+	}
+	if perPage, err := strconv.Atoi(params.Get("per_page")); err == nil && perPage > 0 {
+		q.Limit = min(perPage, MaxPerPage)
+	}
+
+	var errs ValidationError
+	invalid := func(field, message string) {
+		errs = append(errs, FieldError{Field: field, Message: message, err: ErrInvalidFilter})
+	}
+	if value := params.Get("employee_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			errs = append(errs, FieldError{Field: "employee_id", Message: "must be a positive integer", err: ErrInvalidID})
+		}
+		q.EmployeeID = id
+	}
+	if q.Action != "" && !slices.Contains(database.AuditActions, q.Action) {
+		invalid("action", "must be one of "+strings.Join(database.AuditActions, ", "))
+	}
+	if q.Field != "" && !slices.Contains(database.AuditFields, q.Field) {
+		invalid("field", "must be one of "+strings.Join(database.AuditFields, ", "))
+	}
+	for _, bound := range []struct {
+		param string
+		dest  *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		if value := params.Get(bound.param); value != "" {
+			var err error
+			if *bound.dest, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				invalid(bound.param, "must be an RFC 3339 time")
+			}
+		}
+	}
+	if value := params.Get("after"); value != "" {
+		var err error
+		if q.After, err = strconv.ParseInt(value, 10, 64); err != nil || q.After < 0 {
+			invalid("after", "must be the next_after of a previous page")
+		}
+	}
+	return q, errs
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestAuditHandlers(t *testing.T) {
+	h := NewHandler(database.NewMemoryEmployee())
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(IdentifyActor)
+	r.Post("/employees", h.CreateEmployeeHandler)
+	r.Patch("/employees/{id}", h.PatchEmployeeHandler)
+	r.Delete("/employees/{id}", h.DeleteEmployeeHandler)
+	r.Get("/employees/{id}/history", h.EmployeeHistoryHandler)
+	r.Get("/audit", h.ListAuditHandler)
+
+	for _, write := range []struct {
+		method, path, actor, body string
+	}{
+		{"POST", "/employees", "jane", `{"name":"John Doe","position":"Engineer","salary":50000}`},
+		{"POST", "/employees", "jane", `{"name":"Jane Doe","position":"Manager","salary":60000}`},
+		{"PATCH", "/employees/1", "joe", `{"salary":55000}`},
+		{"DELETE", "/employees/2", "jane", ""},
+	} {
+		req, _ := http.NewRequest(write.method, write.path, bytes.NewBufferString(write.body))
+		req.Header.Set(ActorHeader, write.actor)
+		req.Header.Set(middleware.RequestIDHeader, "write-"+write.actor)
+		if write.method == "PATCH" {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code >= 300 {
+			t.Fatalf("%s %s returned %d: %s", write.method, write.path, rr.Code, rr.Body)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedIDs    []int64
+		expectedNext   int64
+	}{
+		{
+			name:           "history",
+			path:           "/employees/1/history",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 3},
+		},
+		{
+			name:           "history ignores employee_id",
+			path:           "/employees/2/history?employee_id=1",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{2, 4},
+		},
+		{
+			name:           "salary changes",
+			path:           "/audit?field=salary&action=update",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3},
+		},
+		{
+			name:           "by actor",
+			path:           "/audit?actor=jane",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 2, 4},
+		},
+		{
+			name:           "first page",
+			path:           "/audit?per_page=2",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{1, 2},
+			expectedNext:   2,
+		},
+		{
+			name:           "next page",
+			path:           "/audit?per_page=2&after=2",
+			expectedStatus: http.StatusOK,
+			expectedIDs:    []int64{3, 4},
+		},
+		{
+			name:           "invalid filters",
+			path:           "/audit?employee_id=x&action=hire&field=age&since=yesterday&after=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid employee id",
+			path:           "/employees/abc/history",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Header.Set(middleware.RequestIDHeader, "read")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if rr.Code != http.StatusOK {
+				res := rr.Result()
+				defer res.Body.Close()
+				cupaloy.SnapshotT(t, dumpResponse(t, res))
+				return
+			}
+
+			// Times vary, so entries are checked field by field.
+			var response AuditResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			var ids []int64
+			for _, entry := range response.Entries {
+				ids = append(ids, entry.ID)
+				if entry.RequestID != "write-"+entry.Actor || entry.At.IsZero() {
+					t.Errorf("entry %d has actor %q, request ID %q and time %v", entry.ID, entry.Actor, entry.RequestID, entry.At)
+				}
+			}
+			if !slices.Equal(ids, tt.expectedIDs) || response.NextAfter != tt.expectedNext {
+				t.Errorf("listed entries %v, next %d, want %v, next %d", ids, response.NextAfter, tt.expectedIDs, tt.expectedNext)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/audit?field=salary&action=update", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	var response AuditResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	raise := response.Entries[0]
+	if raise.Actor != "joe" || len(raise.Changes) != 1 || raise.Changes["salary"] != (database.Change{From: "50000.00", To: "55000.00"}) {
+		t.Errorf("salary raise entry = %+v, want joe raising the salary from 50000.00 to 55000.00", raise)
+	}
+}
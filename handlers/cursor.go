@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// listing is what a list request selects and how it is sorted, plus, for a
+// keyset page, the employee the page continues from. Cursors carry a
+// listing, so following one keeps the filters and sort of the first request.
+type listing struct {
+	Name      string `json:"name,omitempty"`
+	Position  string `json:"position,omitempty"`
+	Search    string `json:"q,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	MinSalary *int64 `json:"salary_min,omitempty"`
+	MaxSalary *int64 `json:"salary_max,omitempty"`
+	Sort      string `json:"sort,omitempty"`
+
+	DepartmentID int `json:"department_id,omitempty"`
+
+	IncludeDeleted bool       `json:"include_deleted,omitempty"`
+	AsOf           *time.Time `json:"as_of,omitempty"`
+
+	Key    *database.Employee `json:"key,omitempty"`
+	Before bool               `json:"before,omitempty"`
+}
+
+// query turns l into a database query for one page of perPage employees.
+func (l listing) query(perPage int) database.ListQuery {
+	sort, _ := database.ParseSort(l.Sort)
+	q := database.ListQuery{
+		Page:      1,
+		PerPage:   perPage,
+		Name:      l.Name,
+		Position:  l.Position,
+		Search:    l.Search,
+		Currency:  l.Currency,
+		MinSalary: l.MinSalary,
+		MaxSalary: l.MaxSalary,
+		Sort:      sort,
+
+		DepartmentID:   l.DepartmentID,
+		IncludeDeleted: l.IncludeDeleted,
+	}
+	if l.AsOf != nil {
+		q.AsOf = *l.AsOf
+	}
+	if l.Before {
+		q.Before = l.Key
+	} else {
+		q.After = l.Key
+	}
+	return q
+}
+
+// continueFrom returns the listing of the page after emp, or before it.
+func (l listing) continueFrom(emp database.Employee, before bool) listing {
+	// The key only needs the id and the sortable fields.
+	l.Key = &database.Employee{ID: emp.ID, Name: emp.Name, Position: emp.Position, Salary: emp.Salary}
+	l.Before = before
+	return l
+}
+
+// encodeCursor signs l so clients can hand it back but not forge or edit
+// it: a cursor holds values of an employee and bypasses filter validation.
+func (h *handler) encodeCursor(l listing) string {
+	payload, _ := json.Marshal(l)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(h.sign(payload))
+}
+
+func (h *handler) decodeCursor(token string) (listing, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return listing{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return listing{}, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, h.sign(payload)) {
+		return listing{}, ErrInvalidCursor
+	}
+	var l listing
+	if err := json.Unmarshal(payload, &l); err != nil || l.Key == nil {
+		return listing{}, ErrInvalidCursor
+	}
+	return l, nil
+}
+
+func (h *handler) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, h.cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
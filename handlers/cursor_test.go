@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func newListRouter(t *testing.T, salaries ...int64) http.Handler {
+	t.Helper()
+	edb := database.NewMemoryEmployee()
+	for _, salary := range salaries {
+		_, err := edb.CreateEmployee(context.Background(), database.Employee{
+			Name:     "John Doe",
+			Position: "Engineer",
+			Salary:   database.Money{Amount: salary * 100, Currency: "USD"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandler(edb, WithCursorSecret([]byte("test-secret")))
+	r := chi.NewRouter()
+	r.Get("/employees", h.ListEmployeesHandler)
+	return r
+}
+
+func listPage(t *testing.T, r http.Handler, query string) (ListEmployeesResponse, *httptest.ResponseRecorder) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/employees?"+query, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	var response ListEmployeesResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return response, rr
+}
+
+func TestListEmployeesHandlerCursors(t *testing.T) {
+	// By salary descending, then id: 2, 4, 3, 1, 5.
+	r := newListRouter(t, 50000, 70000, 60000, 70000, 40000)
+
+	var ids []int
+	var pages []ListEmployeesResponse
+	query := "per_page=2&sort=-salary"
+	for query != "" {
+		page, rr := listPage(t, r, query)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET /employees?%s returned %d", query, rr.Code)
+		}
+		for _, emp := range page.Employees {
+			ids = append(ids, emp.ID)
+		}
+		pages = append(pages, page)
+		query = ""
+		if page.NextCursor != "" {
+			query = url.Values{"cursor": {page.NextCursor}, "per_page": {"2"}}.Encode()
+		}
+	}
+	if want := []int{2, 4, 3, 1, 5}; !slices.Equal(ids, want) {
+		t.Errorf("following next_cursor listed %v, want %v", ids, want)
+	}
+	if len(pages) != 3 || pages[0].PrevCursor != "" {
+		t.Fatalf("got %d pages, first prev_cursor %q, want 3 pages and no prev_cursor", len(pages), pages[0].PrevCursor)
+	}
+
+	back, _ := listPage(t, r, url.Values{"cursor": {pages[2].PrevCursor}, "per_page": {"2"}}.Encode())
+	var backIDs []int
+	for _, emp := range back.Employees {
+		backIDs = append(backIDs, emp.ID)
+	}
+	if want := []int{3, 1}; !slices.Equal(backIDs, want) || back.NextCursor == "" || back.PrevCursor == "" {
+		t.Errorf("prev_cursor of the last page listed %v, next %q, prev %q, want %v with both cursors", backIDs, back.NextCursor, back.PrevCursor, want)
+	}
+}
+
+func TestListEmployeesHandlerPaging(t *testing.T) {
+	r := newListRouter(t, 50000, 70000, 60000)
+	cursor := func() string {
+		page, _ := listPage(t, r, "per_page=1&sort=-salary")
+		return page.NextCursor
+	}()
+	tampered := strings.Replace(cursor, cursor[:4], "eyJu", 1)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "first keyset page",
+			query:          "per_page=1&sort=-salary",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "next keyset page",
+			query:          url.Values{"cursor": {cursor}, "per_page": {"1"}}.Encode(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "offset page",
+			query:          "page=2&per_page=1&sort=-salary",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "without total",
+			query:          "per_page=1&total=false",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "tampered cursor",
+			query:          url.Values{"cursor": {tampered}}.Encode(),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "cursor with filters",
+			query:          url.Values{"cursor": {cursor}, "name": {"jo"}}.Encode(),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rr := listPage(t, r, tt.query)
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
+
+func TestParseListRequestPerPage(t *testing.T) {
+	h := NewHandler(database.NewMemoryEmployee())
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "", want: DefaultPerPage},
+		{query: "per_page=0", want: DefaultPerPage},
+		{query: "per_page=25", want: 25},
+		{query: "per_page=1000", want: MaxPerPage},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/employees?"+tt.query, nil)
+		got, errs := h.parseListRequest(req)
+		if errs != nil || got.perPage != tt.want {
+			t.Errorf("parseListRequest(%q) per page = %d, %v, want %d", tt.query, got.perPage, errs, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+var ErrInvalidDepartmentName = errors.New("invalid department name")
+
+// DepartmentParams defines the body of CreateDepartmentHandler and
+// UpdateDepartmentHandler.
+type DepartmentParams struct {
+	Name string `json:"name" example:"Engineering"`
+}
+
+func (p DepartmentParams) validate() ValidationError {
+	if strings.TrimSpace(p.Name) == "" {
+		return ValidationError{{Field: "name", Message: "must not be empty", err: ErrInvalidDepartmentName}}
+	}
+	return nil
+}
+
+type DepartmentResponse struct {
+	ID   int    `json:"id" example:"3"`
+	Name string `json:"name" example:"Engineering"`
+}
+
+type DepartmentsResponse struct {
+	Departments []DepartmentResponse `json:"departments"`
+}
+
+// CreateDepartmentHandler godoc
+// @Summary Create a department
+// @Tags departments
+// @Accept json
+// @Produce json,application/problem+json
+// @Param body body DepartmentParams true "Department"
+// @Success 201 {object} DepartmentResponse
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 409 {object} Problem "A department with that name already exists"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /departments [post]
+func (h *handler) CreateDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	var params DepartmentParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+	if errs := params.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	department, err := h.emp.CreateDepartment(r.Context(), database.Department{Name: strings.TrimSpace(params.Name)})
+	if err != nil {
+		writeDepartmentError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", r.URL.Path+"/"+strconv.Itoa(department.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(DepartmentResponse(department))
+}
+
+// ListDepartmentsHandler godoc
+// @Summary List departments
+// @Description List every department, by name. Filter the employee list by department_id for the employees of one.
+// @Tags departments
+// @Produce json,application/problem+json
+// @Success 200 {object} DepartmentsResponse
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /departments [get]
+func (h *handler) ListDepartmentsHandler(w http.ResponseWriter, r *http.Request) {
+	departments, err := h.emp.ListDepartments(r.Context())
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	response := DepartmentsResponse{Departments: make([]DepartmentResponse, len(departments))}
+	for i, d := range departments {
+		response.Departments[i] = DepartmentResponse(d)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDepartmentHandler godoc
+// @Summary Get a department by ID
+// @Tags departments
+// @Produce json,application/problem+json
+// @Param id path int true "Department ID"
+// @Success 200 {object} DepartmentResponse
+// @Failure 400 {object} Problem "Invalid department ID"
+// @Failure 404 {object} Problem "Department not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /departments/{id} [get]
+func (h *handler) GetDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	department, err := h.emp.GetDepartment(r.Context(), id)
+	if err != nil {
+		writeDepartmentError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DepartmentResponse(department))
+}
+
+// UpdateDepartmentHandler godoc
+// @Summary Rename a department
+// @Tags departments
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path int true "Department ID"
+// @Param body body DepartmentParams true "Department"
+// @Success 200 {object} DepartmentResponse
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 404 {object} Problem "Department not found"
+// @Failure 409 {object} Problem "A department with that name already exists"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /departments/{id} [put]
+func (h *handler) UpdateDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	var params DepartmentParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+	if errs := params.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	department, err := h.emp.UpdateDepartment(r.Context(), database.Department{ID: id, Name: strings.TrimSpace(params.Name)})
+	if err != nil {
+		writeDepartmentError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DepartmentResponse(department))
+}
+
+// DeleteDepartmentHandler godoc
+// @Summary Delete a department
+// @Description Delete a department no employee belongs to, counting deleted employees until they are purged.
+// @Tags departments
+// @Produce json,application/problem+json
+// @Param id path int true "Department ID"
+// @Success 204 {string} string "Department deleted"
+// @Failure 400 {object} Problem "Invalid department ID"
+// @Failure 404 {object} Problem "Department not found"
+// @Failure 409 {object} Problem "Employees belong to the department"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /departments/{id} [delete]
+func (h *handler) DeleteDepartmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	if err := h.emp.DeleteDepartment(r.Context(), id); err != nil {
+		writeDepartmentError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeDepartmentError is writeDBError for the department endpoints, whose
+// not found and conflicts are about departments.
+func writeDepartmentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		writeProblem(w, r, Problem{Type: ProblemTypeNotFound, Status: http.StatusNotFound, Detail: "Department not found"})
+	case errors.Is(err, database.ErrDepartmentInUse):
+		writeProblem(w, r, Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "Employees belong to the department; move them to another first"})
+	case errors.Is(err, database.ErrConflict):
+		writeProblem(w, r, Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "A department with that name already exists"})
+	default:
+		writeDBError(w, r, err)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestDepartmentHandlers(t *testing.T) {
+	newRouter := func() http.Handler {
+		h := NewHandler(database.NewMemoryEmployee())
+		r := chi.NewRouter()
+		r.Use(middleware.RequestID)
+		r.Post("/employees", h.CreateEmployeeHandler)
+		r.Get("/employees", h.ListEmployeesHandler)
+		r.Post("/departments", h.CreateDepartmentHandler)
+		r.Get("/departments", h.ListDepartmentsHandler)
+		r.Get("/departments/{id}", h.GetDepartmentHandler)
+		r.Put("/departments/{id}", h.UpdateDepartmentHandler)
+		r.Delete("/departments/{id}", h.DeleteDepartmentHandler)
+		return r
+	}
+	type step struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}
+	request := func(tt step) *http.Request {
+		req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set(middleware.RequestIDHeader, "department")
+		return req
+	}
+
+	// The steps build on each other, so each runs on a store of its own
+	// after the steps before it.
+	tests := []step{
+		{"create", "POST", "/departments", `{"name":"Engineering"}`, http.StatusCreated},
+		{"create another", "POST", "/departments", `{"name":"Sales"}`, http.StatusCreated},
+		{"duplicate name", "POST", "/departments", `{"name":"Sales"}`, http.StatusConflict},
+		{"empty name", "POST", "/departments", `{"name":" "}`, http.StatusBadRequest},
+		{"rename", "PUT", "/departments/2", `{"name":"Account Management"}`, http.StatusOK},
+		{"rename missing", "PUT", "/departments/9", `{"name":"Legal"}`, http.StatusNotFound},
+		{"get", "GET", "/departments/1", "", http.StatusOK},
+		{"list", "GET", "/departments", "", http.StatusOK},
+		{"hire into department", "POST", "/employees", `{"name":"John Doe","position":"Engineer","salary":50000,"department_id":1}`, http.StatusCreated},
+		{"hire into missing department", "POST", "/employees", `{"name":"Jane Doe","position":"Engineer","salary":50000,"department_id":9}`, http.StatusUnprocessableEntity},
+		{"list employees of department", "GET", "/employees?department_id=1", "", http.StatusOK},
+		{"delete department in use", "DELETE", "/departments/1", "", http.StatusConflict},
+		{"delete", "DELETE", "/departments/2", "", http.StatusNoContent},
+		{"get deleted", "GET", "/departments/2", "", http.StatusNotFound},
+		{"invalid id", "GET", "/departments/abc", "", http.StatusBadRequest},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter()
+			for _, before := range tests[:i] {
+				r.ServeHTTP(httptest.NewRecorder(), request(before))
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request(tt))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+
+	r := newRouter()
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), request(tt))
+	}
+	var response DepartmentsResponse
+	req, _ := http.NewRequest("GET", "/departments", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if len(response.Departments) != 1 || response.Departments[0] != (DepartmentResponse{ID: 1, Name: "Engineering"}) {
+		t.Errorf("listed departments %+v, want only Engineering", response.Departments)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// etag is the entity tag of an employee rendered in mediaType, one of
+// representations. It is strong because the version changes on every write,
+// and names the format, but for JSON, so that a cache validating one format
+// is never given another.
+func etag(emp database.Employee, mediaType string) string {
+	tag := strconv.Itoa(emp.Version)
+	if format := formatOf(mediaType); format != "json" {
+		tag += "-" + format
+	}
+	return `"` + tag + `"`
+}
+
+// ifMatches reports whether header, an If-Match list, contains "*" or the
+// tag of emp in any format, since an employee read in one format may be
+// written back in another.
+func ifMatches(header string, emp database.Employee) bool {
+	for _, rep := range representations {
+		if etagMatches(header, etag(emp, rep.mediaType), false) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches reports whether header, a comma-separated If-Match or
+// If-None-Match list, contains "*" or tag. Weak comparison, used for
+// If-None-Match, ignores the W/ prefix; strong comparison, used for If-Match,
+// never matches a weak tag.
+func etagMatches(header, tag string, weak bool) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" {
+			return true
+		}
+		if strings.HasPrefix(t, "W/") {
+			if !weak {
+				continue
+			}
+			t = strings.TrimPrefix(t, "W/")
+		}
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version a write to employee id must be
+// conditional on: zero without an If-Match header, otherwise the current
+// version if If-Match lists it. When the precondition fails, or the employee
+// cannot be read, it writes the error response and returns false.
+func (h *handler) ifMatchVersion(w http.ResponseWriter, r *http.Request, id int) (int, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return 0, true
+	}
+	current, err := h.emp.GetEmployeeByID(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return 0, false
+	}
+	if !ifMatches(ifMatch, current) {
+		writePreconditionFailed(w, r)
+		return 0, false
+	}
+	return current.Version, true
+}
+
+func writePreconditionFailed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, Problem{
+		Type:   ProblemTypePreconditionFailed,
+		Status: http.StatusPreconditionFailed,
+		Detail: "The employee has changed since it was read, fetch it again and retry",
+	})
+}
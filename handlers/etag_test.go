@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{header: `"3"`, want: true},
+		{header: `"2", "3"`, want: true},
+		{header: `*`, want: true},
+		{header: `"2"`, want: false},
+		{header: `W/"3"`, weak: false, want: false},
+		{header: `W/"3"`, weak: true, want: true},
+		{header: `3`, weak: true, want: false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"3"`, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%q, weak=%v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		accept         string
+		header         string
+		value          string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "get if none match current",
+			method:         "GET",
+			path:           "/employees/1",
+			header:         "If-None-Match",
+			value:          `"1"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "get if none match weak",
+			method:         "GET",
+			path:           "/employees/1",
+			header:         "If-None-Match",
+			value:          `W/"1"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "get if none match as xml",
+			method:         "GET",
+			path:           "/employees/1",
+			accept:         XMLContentType,
+			header:         "If-None-Match",
+			value:          `"1-xml"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "get if none match another format",
+			method:         "GET",
+			path:           "/employees/1",
+			accept:         XMLContentType,
+			header:         "If-None-Match",
+			value:          `"1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "get if none match stale",
+			method:         "GET",
+			path:           "/employees/1",
+			header:         "If-None-Match",
+			value:          `"0"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "put if match current",
+			method:         "PUT",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"1"`,
+			body:           `{"name": "John Doe", "position": "Manager", "salary": 60000}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "put if match stale",
+			method:         "PUT",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"7"`,
+			body:           `{"name": "John Doe", "position": "Manager", "salary": 60000}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "put if match weak",
+			method:         "PUT",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `W/"1"`,
+			body:           `{"name": "John Doe", "position": "Manager", "salary": 60000}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "put if match read as yaml",
+			method:         "PUT",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"1-yaml"`,
+			body:           `{"name": "John Doe", "position": "Manager", "salary": 60000}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "put if match any",
+			method:         "PUT",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `*`,
+			body:           `{"name": "John Doe", "position": "Manager", "salary": 60000}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "patch if match current",
+			method:         "PATCH",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"1"`,
+			body:           `{"position": "Manager"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "patch if match stale",
+			method:         "PATCH",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"7"`,
+			body:           `{"position": "Manager"}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "delete if match current",
+			method:         "DELETE",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"1"`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "delete if match stale",
+			method:         "DELETE",
+			path:           "/employees/1",
+			header:         "If-Match",
+			value:          `"7"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "delete if match missing employee",
+			method:         "DELETE",
+			path:           "/employees/2",
+			header:         "If-Match",
+			value:          `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edb := database.NewMemoryEmployee()
+			_, err := edb.CreateEmployee(context.Background(), database.Employee{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   database.Money{Amount: 5000000, Currency: "USD"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := NewHandler(edb)
+
+			r := chi.NewRouter()
+			r.Get("/employees/{id}", h.GetEmployeeHandler)
+			r.Put("/employees/{id}", h.UpdateEmployeeHandler)
+			r.Patch("/employees/{id}", h.PatchEmployeeHandler)
+			r.Delete("/employees/{id}", h.DeleteEmployeeHandler)
+
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			req.Header.Set(tt.header, tt.value)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
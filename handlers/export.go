@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/spreadsheet"
+)
+
+// Formats of ExportEmployeesHandler.
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportXLSX   = "xlsx"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON.
+const NDJSONContentType = "application/x-ndjson"
+
+var exportFormats = []string{ExportCSV, ExportNDJSON, ExportXLSX}
+
+// exportFields are the fields an export may have, in their default order.
+// The last two only default to being exported along with deleted employees.
+var exportFields = []string{"id", "name", "position", "salary", "currency", "department_id", "manager_id", "position_id", "deleted_at", "deleted_by"}
+
+// ExportEmployeesHandler godoc
+// @Summary Export employees
+// @Description Stream every employee matching the filters of the list endpoint, in its sort order, as CSV, NDJSON (one
+// @Description JSON object per line) or an XLSX workbook. The employees are read from one consistent snapshot, however
+// @Description long the export takes, and are not held in memory. fields chooses the columns and their order; by default
+// @Description every field is exported, deleted_at and deleted_by only with include_deleted. Text that a spreadsheet
+// @Description would take for a formula is prefixed with an apostrophe in CSV.
+// @Description
+// @Description Errors found before the first employee get a problem response. An error after it aborts the response,
+// @Description so that a truncated export cannot pass for a complete one.
+// @Tags employees
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/problem+json
+// @Param format query string false "Format" Enums(csv, ndjson, xlsx) default(csv)
+// @Param fields query string false "Comma-separated fields to export, such as id,name,salary"
+// @Param name query string false "Filter by name prefix (case-insensitive)"
+// @Param position query string false "Filter by exact position (case-insensitive)"
+// @Param q query string false "Search name and position (case-insensitive substring)"
+// @Param department_id query int false "Filter by department"
+// @Param currency query string false "Filter by salary currency"
+// @Param salary_min query number false "Minimum salary, in currency or USD"
+// @Param salary_max query number false "Maximum salary, in currency or USD"
+// @Param sort query string false "Comma-separated fields among id, name, position and salary, - for descending"
+// @Param include_deleted query bool false "Export deleted employees too; admins only"
+// @Param as_of query string false "Time to read the employees at, in RFC 3339" format(date-time)
+// @Success 200 {string} string "The employees"
+// @Failure 400 {object} Problem "Invalid parameters"
+// @Failure 403 {object} Problem "Deleted employees were requested by someone other than an admin"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/export [get]
+func (h *handler) ExportEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	l, errs := parseListing(params)
+	format := cmp.Or(strings.ToLower(params.Get("format")), ExportCSV)
+	if !slices.Contains(exportFormats, format) {
+		errs = append(errs, FieldError{Field: "format", Message: "must be one of " + strings.Join(exportFormats, ", "), err: ErrInvalidFilter})
+	}
+	fields, fieldErrs := parseExportFields(params.Get("fields"), l.IncludeDeleted)
+	if errs = append(errs, fieldErrs...); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	if l.IncludeDeleted && !IsAdmin(r.Context(), h.admins) {
+		writeForbidden(w, r, "Only admins may export deleted employees")
+		return
+	}
+
+	// The response starts with the first employee, so that errors before it
+	// can still be reported.
+	var out spreadsheet.Writer
+	start := func() (err error) {
+		out, err = startExport(w, format, fields)
+		return err
+	}
+	err := h.emp.ExportEmployees(r.Context(), l.query(0), func(employee database.Employee) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return out.WriteRow(toEmployeeResponse(employee).values(fields))
+	})
+	if err != nil && out == nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		log.Printf("request %s: export aborted: %v", middleware.GetReqID(r.Context()), err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// parseExportFields reads the fields parameter of an export.
+func parseExportFields(value string, includeDeleted bool) ([]string, ValidationError) {
+	if value == "" {
+		if includeDeleted {
+			return exportFields, nil
+		}
+		return exportFields[:len(exportFields)-2], nil
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(exportFields, field) || slices.Contains(fields, field) {
+			msg := "must list distinct fields among " + strings.Join(exportFields, ", ")
+			return nil, ValidationError{{Field: "fields", Message: msg, err: ErrInvalidFilter}}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// startExport sets the headers of an export in format and returns the
+// writer of its rows, having written the header row of formats that have
+// one.
+func startExport(w http.ResponseWriter, format string, fields []string) (spreadsheet.Writer, error) {
+	contentType := map[string]string{
+		ExportCSV:    spreadsheet.CSVContentType + "; charset=utf-8",
+		ExportNDJSON: NDJSONContentType,
+		ExportXLSX:   spreadsheet.XLSXContentType,
+	}[format]
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="employees.`+format+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if format == ExportNDJSON {
+		return &ndjsonWriter{w: w, fields: fields}, nil
+	}
+	out := spreadsheet.NewCSVWriter(w)
+	if format == ExportXLSX {
+		var err error
+		if out, err = spreadsheet.NewXLSXWriter(w, "Employees"); err != nil {
+			return nil, err
+		}
+	}
+	header := make([]any, len(fields))
+	for i, field := range fields {
+		header[i] = field
+	}
+	return out, out.WriteRow(header)
+}
+
+// values returns the fields of e, as values of a spreadsheet.Writer.
+// Missing IDs and deletion details are nil.
+func (e EmployeeResponse) values(fields []string) []any {
+	id := func(id int) any {
+		if id == 0 {
+			return nil
+		}
+		return id
+	}
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "id":
+			values[i] = e.ID
+		case "name":
+			values[i] = e.Name
+		case "position":
+			values[i] = e.Position
+		case "salary":
+			values[i] = spreadsheet.Number(e.Salary)
+		case "currency":
+			values[i] = e.Currency
+		case "department_id":
+			values[i] = id(e.DepartmentID)
+		case "manager_id":
+			values[i] = id(e.ManagerID)
+		case "position_id":
+			values[i] = id(e.PositionID)
+		case "deleted_at":
+			if e.DeletedAt != nil {
+				values[i] = e.DeletedAt.UTC().Format(time.RFC3339Nano)
+			}
+		case "deleted_by":
+			if e.DeletedAt != nil {
+				values[i] = e.DeletedBy
+			}
+		}
+	}
+	return values
+}
+
+// ndjsonWriter writes rows as JSON objects keyed by fields, one per line,
+// with the keys in the order of the fields.
+type ndjsonWriter struct {
+	w      io.Writer
+	fields []string
+	buf    bytes.Buffer
+}
+
+func (n *ndjsonWriter) WriteRow(values []any) error {
+	n.buf.Reset()
+	n.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			n.buf.WriteByte(',')
+		}
+		if number, ok := v.(spreadsheet.Number); ok {
+			v = json.Number(number)
+		}
+		key, _ := json.Marshal(n.fields[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		n.buf.Write(key)
+		n.buf.WriteByte(':')
+		n.buf.Write(value)
+	}
+	n.buf.WriteString("}\n")
+	_, err := n.w.Write(n.buf.Bytes())
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
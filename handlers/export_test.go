@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/spreadsheet"
+)
+
+func TestExportEmployeesHandler(t *testing.T) {
+	edb := database.NewMemoryEmployee()
+	ctx := context.Background()
+	engineering, _ := edb.CreateDepartment(ctx, database.Department{Name: "Engineering"})
+	for _, employee := range []database.Employee{
+		{Name: "Ada Lovelace", Position: "CTO", Salary: database.Money{Amount: 15000000, Currency: "USD"}, DepartmentID: engineering.ID},
+		{Name: "=cmd|' /C calc'!A0", Position: "Engineer", Salary: database.Money{Amount: 5000075, Currency: "USD"}, ManagerID: 1},
+		{Name: "Grace Hopper", Position: "Engineer", Salary: database.Money{Amount: 900000000, Currency: "JPY"}, ManagerID: 1},
+	} {
+		if _, err := edb.CreateEmployee(ctx, employee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := edb.DeleteEmployee(database.WithActor(ctx, "root"), 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(edb, WithAdmins("root"))
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(IdentifyActor)
+	r.Get("/employees/export", h.ExportEmployeesHandler)
+
+	tests := []struct {
+		name           string
+		query          string
+		actor          string
+		expectedStatus int
+	}{
+		{"csv", "", "", http.StatusOK},
+		{"ndjson with fields", "?format=ndjson&fields=name,salary,currency,manager_id&sort=-name", "", http.StatusOK},
+		{"filtered", "?position=engineer&include_deleted=true&fields=id,name,deleted_by", "root", http.StatusOK},
+		{"nothing matches", "?name=zed&fields=id,name", "", http.StatusOK},
+		{"invalid", "?format=pdf&fields=id,id&department_id=x", "", http.StatusBadRequest},
+		{"deleted for someone other than an admin", "?include_deleted=true", "jane", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/employees/export"+tt.query, nil)
+			req.Header.Set(middleware.RequestIDHeader, "export")
+			if tt.actor != "" {
+				req.Header.Set(ActorHeader, tt.actor)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+
+	t.Run("xlsx", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/employees/export?format=xlsx&fields=id,name,salary,department_id", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != spreadsheet.XLSXContentType {
+			t.Fatalf("got status %d and type %q: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body)
+		}
+		rows, err := spreadsheet.ReadXLSX(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		want := [][]string{
+			{"id", "name", "salary", "department_id"},
+			{"1", "Ada Lovelace", "150000.00", "1"},
+			{"2", "=cmd|' /C calc'!A0", "50000.75"},
+		}
+		if err != nil || !reflect.DeepEqual(rows, want) {
+			t.Errorf("exported workbook = %q, %v, want %q", rows, err, want)
+		}
+	})
+}
@@ -1,237 +1,577 @@
 package handlers
 
 import (
+	"cmp"
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/theluckiestsoul/employeemanager/database"
 )
 
 var (
+	ErrInvalidID       = errors.New("invalid employee id")
 	ErrInvalidName     = errors.New("invalid name")
 	ErrInvalidPosition = errors.New("invalid position")
 	ErrInvalidSalary   = errors.New("invalid salary")
+	ErrInvalidCurrency = errors.New("invalid currency")
+	ErrInvalidRelation = errors.New("invalid department or manager")
+	ErrInvalidOverride = errors.New("invalid band override reason")
+
+	// ErrServerShutdown is the cause the server cancels in-flight requests
+	// with once its shutdown grace period runs out.
+	ErrServerShutdown = errors.New("server shutting down")
 )
 
+// StatusClientClosedRequest is the non-standard status, popularised by
+// nginx, for requests the client abandoned before a response was ready.
+const StatusClientClosedRequest = 499
+
 type handler struct {
-	emp database.EmployeeDB
+	emp          database.EmployeeDB
+	cursorSecret []byte
+	admins       []string
+}
+
+// Option configures a handler.
+type Option func(*handler)
+
+// WithCursorSecret sets the key list cursors are signed with. Instances
+// behind the same load balancer need the same secret to accept each other's
+// cursors. Without it a random key is used, and cursors stop working when the
+// process restarts.
+func WithCursorSecret(secret []byte) Option {
+	return func(h *handler) {
+		h.cursorSecret = secret
+	}
 }
 
-func NewHandler(db database.EmployeeDB) *handler {
-	return &handler{emp: db}
+func NewHandler(db database.EmployeeDB, opts ...Option) *handler {
+	h := &handler{emp: db}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if len(h.cursorSecret) == 0 {
+		h.cursorSecret = make([]byte, 32)
+		rand.Read(h.cursorSecret)
+	}
+	return h
 }
 
 // EmployeeResponse defines the response structure for an employee
 type EmployeeResponse struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Position string `json:"position"`
-	Salary   int    `json:"salary"`
+	XMLName  xml.Name    `json:"-" xml:"employee" yaml:"-"`
+	ID       int         `json:"id" xml:"id" yaml:"id"`
+	Name     string      `json:"name" xml:"name" yaml:"name"`
+	Position string      `json:"position" xml:"position" yaml:"position"`
+	Salary   json.Number `json:"salary" xml:"salary" yaml:"salary" swaggertype:"number" example:"50000.75"`
+	Currency string      `json:"currency" xml:"currency" yaml:"currency" example:"USD"`
+	// DepartmentID and ManagerID are left out for an employee outside any
+	// department or reporting to no one, and PositionID for one whose
+	// position is not from the catalog.
+	DepartmentID int `json:"department_id,omitempty" xml:"department_id,omitempty" yaml:"department_id,omitempty" example:"3"`
+	ManagerID    int `json:"manager_id,omitempty" xml:"manager_id,omitempty" yaml:"manager_id,omitempty" example:"7"`
+	PositionID   int `json:"position_id,omitempty" xml:"position_id,omitempty" yaml:"position_id,omitempty" example:"4"`
+	// DeletedAt and DeletedBy are only set on deleted employees, which are
+	// only listed to admins.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty" xml:"deleted_by,omitempty" yaml:"deleted_by,omitempty"`
 }
 
 // EmployeeParams defines the body parameters for the CreateEmployeeHandler and UpdateEmployeeHandler
 // @Param name body string true "Employee name"
-// @Param position body string true "Employee position"
-// @Param salary body float64 true "Employee salary"
+// @Param position body string false "Employee position, required unless position_id is given"
+// @Param salary body number true "Employee salary, with no more decimals than the currency allows"
+// @Param currency body string false "ISO 4217 currency code, USD if omitted"
+// @Param department_id body int false "ID of the department of the employee"
+// @Param manager_id body int false "ID of the employee the employee reports to"
+// @Param position_id body int false "ID of the catalog position of the employee, whose title replaces position"
+// @Param band_override_reason body string false "Why the salary may be outside the band of the position; audited"
 type EmployeeParams struct {
-	Name     string  `json:"name"`
-	Position string  `json:"position"`
-	Salary   float64 `json:"salary"`
+	XMLName      xml.Name    `json:"-" xml:"employee" yaml:"-"`
+	Name         string      `json:"name" xml:"name" yaml:"name"`
+	Position     string      `json:"position,omitempty" xml:"position,omitempty" yaml:"position,omitempty"`
+	Salary       json.Number `json:"salary" xml:"salary" yaml:"salary" swaggertype:"number" example:"50000.75"`
+	Currency     string      `json:"currency,omitempty" xml:"currency,omitempty" yaml:"currency,omitempty" example:"USD"`
+	DepartmentID int         `json:"department_id,omitempty" xml:"department_id,omitempty" yaml:"department_id,omitempty" example:"3"`
+	ManagerID    int         `json:"manager_id,omitempty" xml:"manager_id,omitempty" yaml:"manager_id,omitempty" example:"7"`
+	PositionID   int         `json:"position_id,omitempty" xml:"position_id,omitempty" yaml:"position_id,omitempty" example:"4"`
+	// BandOverrideReason lets the salary be outside the band of the
+	// position. It is recorded in the audit log rather than stored with the
+	// employee.
+	BandOverrideReason string `json:"band_override_reason,omitempty" xml:"band_override_reason,omitempty" yaml:"band_override_reason,omitempty" example:"Retention offer"`
 }
 
-func (e EmployeeParams) toEmployee() database.Employee {
+// ToEmployee returns the employee e describes, which must be valid.
+func (e EmployeeParams) ToEmployee() database.Employee {
+	salary, _ := e.salary()
 	return database.Employee{
-		Name:     e.Name,
-		Position: e.Position,
-		Salary:   e.Salary,
+		Name:         e.Name,
+		Position:     e.Position,
+		Salary:       salary,
+		DepartmentID: e.DepartmentID,
+		ManagerID:    e.ManagerID,
+		PositionID:   e.PositionID,
 	}
 }
 
-func (e EmployeeParams) validate() error {
-	if e.Name == "" {
-		return ErrInvalidName
+// Context returns ctx with the band override reason of e, if it has one.
+func (e EmployeeParams) Context(ctx context.Context) context.Context {
+	if reason := strings.TrimSpace(e.BandOverrideReason); reason != "" {
+		return database.WithBandOverride(ctx, reason)
 	}
-	if e.Position == "" {
-		return ErrInvalidPosition
+	return ctx
+}
+
+// salary parses the salary exactly, so 50000.75 stays 50000.75 and a
+// USD salary with three decimals is rejected rather than rounded.
+func (e EmployeeParams) salary() (database.Money, error) {
+	return parseSalary(e.Salary, e.Currency)
+}
+
+func parseSalary(amount json.Number, currency string) (database.Money, error) {
+	currency = strings.ToUpper(cmp.Or(currency, database.DefaultCurrency))
+	return database.ParseMoney(amount.String(), currency)
+}
+
+// validateSalary reports what is wrong with a salary in currency, if
+// anything.
+func validateSalary(amount json.Number, currency string) ValidationError {
+	salary, err := parseSalary(amount, currency)
+	switch {
+	case errors.Is(err, database.ErrUnknownCurrency):
+		return ValidationError{{Field: "currency", Message: "must be a supported ISO 4217 currency code", err: ErrInvalidCurrency}}
+	case err != nil:
+		currency := strings.ToUpper(cmp.Or(currency, database.DefaultCurrency))
+		exp, _ := database.CurrencyExponent(currency)
+		msg := fmt.Sprintf("must be a decimal number with at most %d decimal places for %s", exp, currency)
+		return ValidationError{{Field: "salary", Message: msg, err: ErrInvalidSalary}}
+	case salary.Amount <= 0:
+		return ValidationError{{Field: "salary", Message: "must be greater than zero", err: ErrInvalidSalary}}
 	}
-	if e.Salary <= 0 {
-		return ErrInvalidSalary
+	return nil
+}
+
+// maxOverrideReason bounds the band override reason kept in the audit log.
+const maxOverrideReason = 500
+
+// Validate returns a ValidationError listing every invalid field of e, or
+// nil. It lets the other APIs of the service accept employees on the same
+// terms as this one.
+func (e EmployeeParams) Validate() error {
+	if errs := e.validate(); errs != nil {
+		return errs
 	}
 	return nil
 }
 
+// validate reports every invalid field rather than stopping at the first.
+func (e EmployeeParams) validate() ValidationError {
+	var errs ValidationError
+	if e.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "must not be empty", err: ErrInvalidName})
+	}
+	if e.Position == "" && e.PositionID == 0 {
+		errs = append(errs, FieldError{Field: "position", Message: "must not be empty without a position_id", err: ErrInvalidPosition})
+	}
+	if e.DepartmentID < 0 {
+		errs = append(errs, FieldError{Field: "department_id", Message: "must be a positive integer", err: ErrInvalidRelation})
+	}
+	if e.ManagerID < 0 {
+		errs = append(errs, FieldError{Field: "manager_id", Message: "must be a positive integer", err: ErrInvalidRelation})
+	}
+	if e.PositionID < 0 {
+		errs = append(errs, FieldError{Field: "position_id", Message: "must be a positive integer", err: ErrInvalidRelation})
+	}
+	if len(e.BandOverrideReason) > maxOverrideReason {
+		msg := fmt.Sprintf("must be at most %d bytes", maxOverrideReason)
+		errs = append(errs, FieldError{Field: "band_override_reason", Message: msg, err: ErrInvalidOverride})
+	}
+	return append(errs, validateSalary(e.Salary, e.Currency)...)
+}
+
 // CreateEmployeeHandler creates a new employee
 // @Summary Create a new employee
-// @Description Create a new employee
+// @Description Create a new employee. The body is read as its Content-Type: JSON, XML, YAML, or CSV with a header and
+// @Description one row named like the columns of an import. The employee is returned in the type Accept prefers.
 // @Tags employees
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/yaml,text/csv
+// @Produce json,xml,application/yaml,text/csv,application/problem+json
 // @Param body body EmployeeParams true "Employee body"
 // @Success 201 {object} EmployeeResponse
-// @Failure 400 {string} string "Invalid request payload"
-// @Failure 500 {string} string "Internal server error"
+// @Header 201 {string} ETag "Version of the employee, for If-Match"
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 406 {object} Problem "None of the accepted types can be produced"
+// @Failure 409 {object} Problem "Conflict with existing data"
+// @Failure 415 {object} Problem "Unsupported Content-Type"
+// @Failure 422 {object} Problem "Constraint violation"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
 // @Router /employees [post]
 func (h *handler) CreateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
-	var employee EmployeeParams
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	mediaType := negotiate(r)
+	if mediaType == "" {
+		writeNotAcceptable(w, r)
 		return
 	}
-	if err := employee.validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	employee, err := decodeEmployeeParams(r)
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
-	emp, err := h.emp.CreateEmployee(employee.toEmployee())
+	if errs := employee.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	emp, err := h.emp.CreateEmployee(employee.Context(r.Context()), employee.ToEmployee())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeDBError(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Location", r.URL.Path+"/"+strconv.Itoa(emp.ID))
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(toEmployeeResponse(emp))
-
+	w.Header().Set("ETag", etag(emp, mediaType))
+	render(w, http.StatusCreated, mediaType, toEmployeeResponse(emp))
 }
 
 // GetEmployeeHandler retrieves an employee by ID.
 // @Summary Get an employee by ID
-// @Description Get an employee by ID
+// @Description Get an employee by ID. The response carries an ETag; send it back in If-None-Match to get 304 while the
+// @Description employee is unchanged. The employee is returned as JSON, XML, YAML or CSV, whichever Accept prefers; the
+// @Description ETag is the version followed by the format, such as 3-xml, except for JSON.
+// @Description
+// @Description With as_of the employee is returned as it was at that time, and not found if it did not exist yet or was
+// @Description deleted then.
 // @Tags employees
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/yaml,text/csv,application/problem+json
 // @Param id path int true "Employee ID"
+// @Param as_of query string false "Time to read the employee at, in RFC 3339" format(date-time)
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} EmployeeResponse
-// @Failure 400 {string} string "Invalid employee ID"
-// @Failure 404 {string} string "Employee not found"
+// @Header 200 {string} ETag "Version of the employee, for If-Match"
+// @Success 304 "Not modified"
+// @Failure 400 {object} Problem "Invalid employee ID or time"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 406 {object} Problem "None of the accepted types can be produced"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
 // @Router /employees/{id} [get]
 func (h *handler) GetEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil || id <= 0 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeInvalidID(w, r)
+		return
+	}
+	mediaType := negotiate(r)
+	if mediaType == "" {
+		writeNotAcceptable(w, r)
+		return
+	}
+	asOf, errs := parseAsOf(r.URL.Query())
+	if errs != nil {
+		writeValidationError(w, r, errs)
 		return
 	}
-	employee, err := h.emp.GetEmployeeByID(id)
+	var employee database.Employee
+	if asOf.IsZero() {
+		employee, err = h.emp.GetEmployeeByID(r.Context(), id)
+	} else {
+		employee, err = h.emp.GetEmployeeAsOf(r.Context(), id, asOf)
+	}
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(employee, mediaType))
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag(employee, mediaType), true) {
+		w.Header().Set("Vary", "Accept")
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(toEmployeeResponse(employee))
+	render(w, http.StatusOK, mediaType, toEmployeeResponse(employee))
 }
 
 // UpdateEmployeeHandler updates an employee.
 // @Summary Update an employee
-// @Description Update an employee
+// @Description Update an employee. With If-Match the update only applies if the employee still has that ETag. The
+// @Description body is read as its Content-Type and the employee returned in the type Accept prefers, as on create.
 // @Tags employees
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/yaml,text/csv
+// @Produce json,xml,application/yaml,text/csv,application/problem+json
 // @Param id path int true "Employee ID"
+// @Param If-Match header string false "ETag the employee must still have"
 // @Param body body EmployeeParams true "Employee object that needs to be updated"
 // @Success 200 {object} EmployeeResponse
-// @Failure 400 {string} string "Invalid request payload"
-// @Failure 404 {string} string "Employee not found"
+// @Header 200 {string} ETag "Version of the employee, for If-Match"
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 406 {object} Problem "None of the accepted types can be produced"
+// @Failure 409 {object} Problem "Conflict with existing data"
+// @Failure 412 {object} Problem "The employee no longer matches If-Match"
+// @Failure 415 {object} Problem "Unsupported Content-Type"
+// @Failure 422 {object} Problem "Constraint violation"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
 // @Router /employees/{id} [put]
 func (h *handler) UpdateEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil || id <= 0 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeInvalidID(w, r)
+		return
+	}
+	mediaType := negotiate(r)
+	if mediaType == "" {
+		writeNotAcceptable(w, r)
+		return
+	}
+	emp, err := decodeEmployeeParams(r)
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
-	var emp EmployeeParams
-	if err := json.NewDecoder(r.Body).Decode(&emp); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if errs := emp.validate(); errs != nil {
+		writeValidationError(w, r, errs)
 		return
 	}
-	if err := emp.validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+
+	version, ok := h.ifMatchVersion(w, r, id)
+	if !ok {
 		return
 	}
 
-	empToUpdate := emp.toEmployee()
+	empToUpdate := emp.ToEmployee()
 	empToUpdate.ID = id
-	err = h.emp.UpdateEmployee(empToUpdate)
+	empToUpdate.Version = version
+	updated, err := h.emp.UpdateEmployee(emp.Context(r.Context()), empToUpdate)
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeDBError(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(toEmployeeResponse(empToUpdate))
+	w.Header().Set("ETag", etag(updated, mediaType))
+	render(w, http.StatusOK, mediaType, toEmployeeResponse(updated))
 }
 
 // DeleteEmployeeHandler deletes an employee by ID.
 // @Summary Delete an employee by ID
-// @Description Delete an employee by ID
+// @Description Delete an employee by ID. With If-Match the delete only applies if the employee still has that ETag.
+// @Description Deleted employees can be restored until they are purged.
 // @Tags employees
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param id path int true "Employee ID"
+// @Param If-Match header string false "ETag the employee must still have"
 // @Success 204 {string} string "Employee deleted"
-// @Failure 400 {string} string "Invalid employee ID"
-// @Failure 404 {string} string "Employee not found"
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 409 {object} Problem "Other employees report to the employee"
+// @Failure 412 {object} Problem "The employee no longer matches If-Match"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
 // @Router /employees/{id} [delete]
 func (h *handler) DeleteEmployeeHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil || id <= 0 {
-		http.Error(w, "Invalid employee ID", http.StatusBadRequest)
+		writeInvalidID(w, r)
 		return
 	}
-	if err := h.emp.DeleteEmployee(id); err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+	version, ok := h.ifMatchVersion(w, r, id)
+	if !ok {
+		return
+	}
+	err = h.emp.DeleteEmployee(r.Context(), id, version)
+	if err != nil {
+		writeDBError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreEmployeeHandler restores a deleted employee.
+// @Summary Restore a deleted employee
+// @Description Undo the deletion of an employee, as long as it has not been purged yet. The employee is returned in the
+// @Description type Accept prefers, as on get.
+// @Tags employees
+// @Produce json,xml,application/yaml,text/csv,application/problem+json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} EmployeeResponse
+// @Header 200 {string} ETag "Version of the employee, for If-Match"
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found, or already purged"
+// @Failure 406 {object} Problem "None of the accepted types can be produced"
+// @Failure 409 {object} Problem "The employee is not deleted"
+// @Failure 422 {object} Problem "The manager of the employee is deleted"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/restore [post]
+func (h *handler) RestoreEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	mediaType := negotiate(r)
+	if mediaType == "" {
+		writeNotAcceptable(w, r)
+		return
+	}
+	restored, err := h.emp.RestoreEmployee(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(restored, mediaType))
+	render(w, http.StatusOK, mediaType, toEmployeeResponse(restored))
+}
+
 type ListEmployeesResponse struct {
-	Employees []EmployeeResponse `json:"employees"`
-	Total     int                `json:"total"`
+	XMLName   xml.Name           `json:"-" xml:"employees" yaml:"-"`
+	Employees []EmployeeResponse `json:"employees" xml:"employee" yaml:"employees"`
+	// Total is left out when the request set total=false.
+	Total      *int   `json:"total,omitempty" xml:"total,omitempty" yaml:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty" xml:"next_cursor,omitempty" yaml:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty" xml:"prev_cursor,omitempty" yaml:"prev_cursor,omitempty"`
 }
 
 // ListEmployeesHandler
 // @Summary List employees
-// @Description List employees
+// @Description List employees, optionally filtered and sorted. Text filters ignore case. A salary range is read in
+// @Description currency, USD if omitted, and only matches salaries paid in it.
+// @Description
+// @Description Pages are read by keyset: pass next_cursor or prev_cursor back as cursor to get the neighbouring page
+// @Description with the same filters and sort. The Link header (RFC 8288) carries the same links. Passing page instead
+// @Description selects the older offset paging.
+// @Description
+// @Description Deleted employees are left out unless an admin sets include_deleted. With as_of the employees are listed as
+// @Description they were at that time.
+// @Description
+// @Description The page is returned as JSON, XML, YAML or CSV, whichever Accept prefers. CSV has a row per employee with
+// @Description every field and no total; the neighbouring pages are in the Link header.
 // @Tags employees
 // @Accept json
-// @Produce json
-// @Param page query int false "Page number"
-// @Param per_page query int false "Number of items per page"
+// @Produce json,xml,application/yaml,text/csv,application/problem+json
+// @Param cursor query string false "Cursor from a previous response; cannot be combined with page, sort or filters"
+// @Param page query int false "Page number, for offset paging"
+// @Param per_page query int false "Number of items per page, at most 100" default(10)
+// @Param total query bool false "Set to false to skip counting the matching employees" default(true)
+// @Param include_deleted query bool false "Also list deleted employees; admins only" default(false)
+// @Param as_of query string false "Time to list the employees at, in RFC 3339" format(date-time)
+// @Param name query string false "Name prefix"
+// @Param position query string false "Exact position"
+// @Param q query string false "Text to search for in name and position"
+// @Param currency query string false "ISO 4217 currency of the salary"
+// @Param salary_min query number false "Lowest salary, inclusive"
+// @Param salary_max query number false "Highest salary, inclusive"
+// @Param department_id query int false "ID of the department of the employees"
+// @Param sort query string false "Comma-separated fields among id, name, position and salary; prefix a field with - to sort it descending" example(-salary,name)
 // @Success 200 {object} ListEmployeesResponse
-// @Failure 500 {string} string "Internal server error"
+// @Header 200 {string} Link "Links to the first, next and previous pages"
+// @Failure 400 {object} Problem "Invalid filter, sort or cursor"
+// @Failure 403 {object} Problem "Deleted employees were requested by someone other than an admin"
+// @Failure 406 {object} Problem "None of the accepted types can be produced"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
 // @Router /employees [get]
 func (h *handler) ListEmployeesHandler(w http.ResponseWriter, r *http.Request) {
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil || page <= 0 {
-		page = 1
+	req, errs := h.parseListRequest(r)
+	if errs != nil {
+		writeValidationError(w, r, errs)
+		return
 	}
-	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
-	if err != nil || perPage <= 0 {
-		perPage = 10
+	mediaType := negotiate(r)
+	if mediaType == "" {
+		writeNotAcceptable(w, r)
+		return
 	}
-	employees, total, err := h.emp.ListEmployees(page, perPage)
+	if req.IncludeDeleted && !IsAdmin(r.Context(), h.admins) {
+		writeForbidden(w, r, "Only admins may list deleted employees")
+		return
+	}
+	page, err := h.emp.ListEmployees(r.Context(), req.query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeDBError(w, r, err)
 		return
 	}
 	response := ListEmployeesResponse{
-		Employees: make([]EmployeeResponse, len(employees)),
-		Total:     total,
+		Employees: make([]EmployeeResponse, len(page.Employees)),
+	}
+	if !req.skipTotal {
+		response.Total = &page.Total
 	}
-	for i, emp := range employees {
+	for i, emp := range page.Employees {
 		response.Employees[i] = toEmployeeResponse(emp)
 	}
+	var link string
+	response.NextCursor, response.PrevCursor, link = h.links(r, req, page)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Link", link)
+	render(w, http.StatusOK, mediaType, response)
 }
 
 func toEmployeeResponse(emp database.Employee) EmployeeResponse {
 	return EmployeeResponse{
-		ID:       emp.ID,
-		Name:     emp.Name,
-		Position: emp.Position,
-		Salary:   int(emp.Salary),
+		ID:        emp.ID,
+		Name:      emp.Name,
+		Position:  emp.Position,
+		Salary:    json.Number(emp.Salary.String()),
+		Currency:  emp.Salary.Currency,
+		DeletedAt: emp.DeletedAt,
+		DeletedBy: emp.DeletedBy,
+
+		DepartmentID: emp.DepartmentID,
+		ManagerID:    emp.ManagerID,
+		PositionID:   emp.PositionID,
+	}
+}
+
+// writeDBError writes the problem response for an error from the database
+// layer. Requests the client walked away from get 499; queries that timed
+// out, were cancelled by a server shutdown or hit an unavailable database get
+// 503 so the client knows to retry. Unexpected errors are logged rather than
+// shown to the client.
+func writeDBError(w http.ResponseWriter, r *http.Request, err error) {
+	var p Problem
+	switch {
+	case errors.Is(err, context.Canceled) && !errors.Is(context.Cause(r.Context()), ErrServerShutdown):
+		p = Problem{Type: ProblemTypeClientClosed, Title: "Client Closed Request", Status: StatusClientClosedRequest}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, database.ErrUnavailable):
+		p = Problem{Type: ProblemTypeServiceUnavailable, Status: http.StatusServiceUnavailable, Detail: "The database is unavailable, try again later"}
+	case errors.Is(err, database.ErrNotFound):
+		p = Problem{Type: ProblemTypeNotFound, Status: http.StatusNotFound, Detail: "Employee not found"}
+	case errors.Is(err, database.ErrUnknownDepartment):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The department does not exist"}
+	case errors.Is(err, database.ErrUnknownManager):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The manager does not exist or is deleted"}
+	case errors.Is(err, database.ErrReportingCycle):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The manager reports to the employee, directly or not"}
+	case errors.Is(err, database.ErrUnknownPosition):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The position is not in the catalog"}
+	case errors.Is(err, database.ErrUnmatchedPosition):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The position matches no single title of the catalog; give a position_id"}
+	case errors.Is(err, database.ErrSalaryOutOfBand):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The salary is outside the band of the position; give a band_override_reason to set it anyway"}
+	case errors.Is(err, database.ErrNotDeleted):
+		p = Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "The employee is not deleted"}
+	case errors.Is(err, database.ErrHasReports):
+		p = Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "Other employees report to the employee; give them another manager first"}
+	case errors.Is(err, database.ErrVersionMismatch):
+		p = Problem{Type: ProblemTypePreconditionFailed, Status: http.StatusPreconditionFailed, Detail: "The employee has changed since it was read, fetch it again and retry"}
+	case errors.Is(err, database.ErrConflict):
+		p = Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "The change conflicts with existing data"}
+	case errors.Is(err, database.ErrConstraint):
+		p = Problem{Type: ProblemTypeConstraint, Status: http.StatusUnprocessableEntity, Detail: "The change violates a data constraint"}
+	default:
+		log.Printf("request %s: %v", middleware.GetReqID(r.Context()), err)
+		p = Problem{Type: ProblemTypeInternal, Status: http.StatusInternalServerError}
 	}
+	writeProblem(w, r, p)
 }
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,10 +12,13 @@ import (
 	"net/http/httptest"
 	"net/http/httputil"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bradleyjkemp/cupaloy/v2"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/lib/pq"
 	"github.com/theluckiestsoul/employeemanager/database"
 )
 
@@ -25,18 +31,73 @@ func dumpResponse(t *testing.T, r *http.Response) string {
 	return string(body)
 }
 
+func salaryMinor(emp *EmployeeParams) int64 {
+	salary, _ := emp.salary()
+	return salary.Amount
+}
+
+// employeeColumns are the columns of the employee rows returned by sqlmock.
+var employeeColumns = []string{"id", "name", "position", "salary_minor", "currency", "version", "deleted_at", "deleted_by", "department_id", "manager_id", "position_id", "external_id", "user_name"}
+
+// expectWrite expects the transaction of a write to employee id: the row is
+// locked and read first, then written, then audited.
+func expectWrite(mock sqlmock.Sqlmock, id int, row ...driver.Value) {
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows(employeeColumns)
+	if row != nil {
+		rows.AddRow(row...)
+	}
+	mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM employees WHERE id=\$1 FOR NO KEY UPDATE`).WithArgs(id).WillReturnRows(rows)
+}
+
+// currentEmployees matches the table of the current employee rows, with the
+// salaries in effect today.
+const currentEmployees = `\(SELECT .* salary_history .*\) employees`
+
+// expectSalaryHistory expects the salary history read before an update, and
+// returns none, so that the salary stored is the one in effect.
+func expectSalaryHistory(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM salary_history`).WillReturnRows(sqlmock.NewRows([]string{"id", "employee_id", "salary_minor", "currency", "effective_from", "reason", "band_override", "created_by", "created_at"}))
+}
+
+// expectNoCatalog expects the position title of a write to be looked up in
+// an empty catalog, which leaves it free text.
+func expectNoCatalog(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT id FROM positions`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+}
+
+func expectNoReports(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+}
+
+func expectVersion(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`UPDATE employee_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO employee_versions`).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectAudit(mock sqlmock.Sqlmock, action string) {
+	mock.ExpectExec(`LOCK TABLE audit_log`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	mock.ExpectExec(`INSERT INTO audit_log`).WithArgs(sqlmock.AnyArg(), action, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
 func TestEmployeeCreateParamsValidate(t *testing.T) {
 	tests := []struct {
 		name      string
 		params    EmployeeParams
 		wantError bool
+		wantErrs  []error
 	}{
 		{
 			name: "valid params",
 			params: EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError: false,
 		},
@@ -45,27 +106,71 @@ func TestEmployeeCreateParamsValidate(t *testing.T) {
 			params: EmployeeParams{
 				Name:     "",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError: true,
+			wantErrs:  []error{ErrInvalidName},
 		},
 		{
 			name: "invalid position",
 			params: EmployeeParams{
 				Name:     "John Doe",
 				Position: "",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError: true,
+			wantErrs:  []error{ErrInvalidPosition},
 		},
 		{
 			name: "invalid salary",
 			params: EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   0.0,
+				Salary:   json.Number("0"),
+			},
+			wantError: true,
+			wantErrs:  []error{ErrInvalidSalary},
+		},
+		{
+			name: "every invalid field",
+			params: EmployeeParams{
+				Name:     "",
+				Position: "",
+				Salary:   json.Number("-1"),
+			},
+			wantError: true,
+			wantErrs:  []error{ErrInvalidName, ErrInvalidPosition, ErrInvalidSalary},
+		},
+		{
+			name: "exact cents",
+			params: EmployeeParams{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   json.Number("50000.75"),
+			},
+			wantError: false,
+		},
+		{
+			name: "salary too precise for currency",
+			params: EmployeeParams{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   json.Number("50000.5"),
+				Currency: "JPY",
+			},
+			wantError: true,
+			wantErrs:  []error{ErrInvalidSalary},
+		},
+		{
+			name: "unknown currency",
+			params: EmployeeParams{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   json.Number("50000"),
+				Currency: "XYZ",
 			},
 			wantError: true,
+			wantErrs:  []error{ErrInvalidCurrency},
 		},
 	}
 
@@ -75,6 +180,11 @@ func TestEmployeeCreateParamsValidate(t *testing.T) {
 			if (err != nil) != tt.wantError {
 				t.Errorf("validate() error = %v, wantError %v", err, tt.wantError)
 			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("validate() error = %v, want %v", err, want)
+				}
+			}
 		})
 	}
 }
@@ -98,12 +208,41 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError:      false,
 			expectedStatus: http.StatusCreated,
 			before: func(t *testing.T, emp *EmployeeParams) {
-				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectBegin()
+				expectNoCatalog(mock)
+				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, salaryMinor(emp), "USD", nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 1))
+				expectVersion(mock)
+				mock.ExpectQuery(`INSERT INTO salary_history`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAudit(mock, database.AuditCreate)
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name: "create employee with cents",
+			params: &EmployeeParams{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   json.Number("50000.75"),
+			},
+			wantError:      false,
+			expectedStatus: http.StatusCreated,
+			before: func(t *testing.T, emp *EmployeeParams) {
+				mock.ExpectBegin()
+				expectNoCatalog(mock)
+				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, 5000075, "USD", nil, nil, nil, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(2, 1))
+				expectVersion(mock)
+				mock.ExpectQuery(`INSERT INTO salary_history`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectAudit(mock, database.AuditCreate)
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -117,7 +256,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError:      true,
 			expectedStatus: http.StatusBadRequest,
@@ -131,7 +270,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError:      true,
 			expectedStatus: http.StatusBadRequest,
@@ -144,7 +283,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   0.0,
+				Salary:   json.Number("0"),
 			},
 			wantError:      true,
 			expectedStatus: http.StatusBadRequest,
@@ -152,17 +291,54 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			},
 			after: func(t *testing.T) {},
 		},
+		{
+			name: "multiple invalid fields",
+			params: &EmployeeParams{
+				Name:     "",
+				Position: "",
+				Salary:   json.Number("5000.001"),
+			},
+			wantError:      true,
+			expectedStatus: http.StatusBadRequest,
+			before:         func(t *testing.T, emp *EmployeeParams) {},
+			after:          func(t *testing.T) {},
+		},
+		{
+			name: "constraint violation",
+			params: &EmployeeParams{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   json.Number("5000"),
+			},
+			wantError:      true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			before: func(t *testing.T, emp *EmployeeParams) {
+				mock.ExpectBegin()
+				expectNoCatalog(mock)
+				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, salaryMinor(emp), "USD", nil, nil, nil, nil, nil).WillReturnError(&pq.Error{Code: "23514"})
+				mock.ExpectRollback()
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
 		{
 			name: "failed insert",
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			wantError:      true,
 			expectedStatus: http.StatusInternalServerError,
 			before: func(t *testing.T, emp *EmployeeParams) {
-				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, emp.Salary).WillReturnError(errors.New("failed to   insert"))
+				mock.ExpectBegin()
+				expectNoCatalog(mock)
+				mock.ExpectQuery(`INSERT INTO employees`).WithArgs(emp.Name, emp.Position, salaryMinor(emp), "USD", nil, nil, nil, nil, nil).WillReturnError(errors.New("failed to   insert"))
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -181,6 +357,7 @@ func TestCreateEmployeeHandler(t *testing.T) {
 			h := NewHandler(edb)
 			body, _ := json.Marshal(tt.params)
 			req, _ := http.NewRequest("POST", "/employees", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "test-request-id"))
 			rr := httptest.NewRecorder()
 
 			h.CreateEmployeeHandler(rr, req)
@@ -217,13 +394,19 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			id:             1,
 			wantError:      false,
 			expectedStatus: http.StatusOK,
 			before: func(t *testing.T, emp *EmployeeParams, id int) {
-				mock.ExpectExec(`UPDATE employees SET name=\$1, position=\$2, salary=\$3 WHERE id=\$4`).WithArgs(emp.Name, emp.Position, emp.Salary, id).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectWrite(mock, id, id, "John Doe", "Intern", salaryMinor(emp), "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectSalaryHistory(mock)
+				expectNoCatalog(mock)
+				rows := sqlmock.NewRows(employeeColumns).AddRow(id, emp.Name, emp.Position, salaryMinor(emp), "USD", 2, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET name=\$1, position=\$2, salary_minor=\$3, currency=\$4, department_id=\$5, manager_id=\$6, position_id=\$7, version=version\+1 WHERE id=\$8 RETURNING`).WithArgs(emp.Name, emp.Position, salaryMinor(emp), "USD", nil, nil, nil, id).WillReturnRows(rows)
+				expectVersion(mock)
+				expectAudit(mock, database.AuditUpdate)
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -237,7 +420,7 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "",
 				Position: "Engineer",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			id:             1,
 			wantError:      true,
@@ -252,7 +435,7 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "",
-				Salary:   5000.0,
+				Salary:   json.Number("5000"),
 			},
 			id:             1,
 			wantError:      true,
@@ -266,7 +449,7 @@ func TestUpdateEmployeeHandler(t *testing.T) {
 			params: &EmployeeParams{
 				Name:     "John Doe",
 				Position: "Engineer",
-				Salary:   0.0,
+				Salary:   json.Number("0"),
 			},
 			id:             1,
 			wantError:      true,
@@ -315,6 +498,7 @@ func TestGetEmployeeHandler(t *testing.T) {
 		name           string
 		id             int
 		wantError      bool
+		cancel         error
 		before         func(id int, t *testing.T)
 		after          func(t *testing.T)
 		expectedStatus int
@@ -325,8 +509,8 @@ func TestGetEmployeeHandler(t *testing.T) {
 			wantError:      false,
 			expectedStatus: http.StatusOK,
 			before: func(id int, t *testing.T) {
-				rows := sqlmock.NewRows([]string{"id", "name", "position", "salary"}).AddRow(1, "John Doe", "Engineer", 50000.0)
-				mock.ExpectQuery(`SELECT id, name, position, salary FROM employees WHERE id=\$1`).WithArgs(id).WillReturnRows(rows)
+				rows := sqlmock.NewRows(employeeColumns).AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, sqlmock.AnyArg()).WillReturnRows(rows)
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -336,12 +520,60 @@ func TestGetEmployeeHandler(t *testing.T) {
 			},
 		},
 		{
-			name:           "failed get",
+			name:           "client closed request",
 			id:             1,
 			wantError:      true,
+			expectedStatus: StatusClientClosedRequest,
+			cancel:         context.Canceled,
+			before:         func(id int, t *testing.T) {},
+			after:          func(t *testing.T) {},
+		},
+		{
+			name:           "server shutdown",
+			id:             1,
+			wantError:      true,
+			expectedStatus: http.StatusServiceUnavailable,
+			cancel:         ErrServerShutdown,
+			before:         func(id int, t *testing.T) {},
+			after:          func(t *testing.T) {},
+		},
+		{
+			name:           "employee not found",
+			id:             2,
+			wantError:      true,
 			expectedStatus: http.StatusNotFound,
 			before: func(id int, t *testing.T) {
-				mock.ExpectQuery(`SELECT id, name, position, salary FROM employees WHERE id=\$1`).WithArgs(id).WillReturnError(errors.New("failed to get"))
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:           "database unavailable",
+			id:             1,
+			wantError:      true,
+			expectedStatus: http.StatusServiceUnavailable,
+			before: func(id int, t *testing.T) {
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, sqlmock.AnyArg()).WillReturnError(&pq.Error{Code: "57P01"})
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:           "failed get",
+			id:             1,
+			wantError:      true,
+			expectedStatus: http.StatusInternalServerError,
+			before: func(id int, t *testing.T) {
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE id=\$1 AND deleted_at IS NULL`).WithArgs(id, sqlmock.AnyArg()).WillReturnError(errors.New("failed to get"))
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -363,7 +595,12 @@ func TestGetEmployeeHandler(t *testing.T) {
 			r.Get("/employees/{id}", h.GetEmployeeHandler)
 
 			url := fmt.Sprintf("/employees/%d", tt.id)
-			req, _ := http.NewRequest("GET", url, nil)
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			if tt.cancel != nil {
+				cancel(tt.cancel)
+			}
+			req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
@@ -399,7 +636,28 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 			wantError:      false,
 			expectedStatus: http.StatusNoContent,
 			before: func(id int, t *testing.T) {
-				mock.ExpectExec(`DELETE FROM employees WHERE id=\$1`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectWrite(mock, id, id, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectNoReports(mock)
+				rows := sqlmock.NewRows(employeeColumns).AddRow(id, "John Doe", "Engineer", 5000000, "USD", 2, time.Now(), "jane", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`UPDATE employees SET deleted_at=\$1, deleted_by=\$2, version=version\+1 WHERE id=\$3 RETURNING`).WithArgs(sqlmock.AnyArg(), "jane", id).WillReturnRows(rows)
+				expectVersion(mock)
+				expectAudit(mock, database.AuditDelete)
+			},
+			after: func(t *testing.T) {
+				err := mock.ExpectationsWereMet()
+				if err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			},
+		},
+		{
+			name:           "employee not found",
+			id:             2,
+			wantError:      true,
+			expectedStatus: http.StatusNotFound,
+			before: func(id int, t *testing.T) {
+				expectWrite(mock, id)
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -412,9 +670,12 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 			name:           "failed delete",
 			id:             1,
 			wantError:      true,
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusInternalServerError,
 			before: func(id int, t *testing.T) {
-				mock.ExpectExec(`DELETE FROM employees WHERE id=\$1`).WithArgs(id).WillReturnError(errors.New("failed to delete"))
+				expectWrite(mock, id, id, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				expectNoReports(mock)
+				mock.ExpectQuery(`UPDATE employees SET deleted_at=\$1, deleted_by=\$2`).WithArgs(sqlmock.AnyArg(), "jane", id).WillReturnError(errors.New("failed to delete"))
+				mock.ExpectRollback()
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -433,10 +694,12 @@ func TestDeleteEmployeeHandler(t *testing.T) {
 			h := NewHandler(edb)
 
 			r := chi.NewRouter()
+			r.Use(IdentifyActor)
 			r.Delete("/employees/{id}", h.DeleteEmployeeHandler)
 
 			url := fmt.Sprintf("/employees/%d", tt.id)
 			req, _ := http.NewRequest("DELETE", url, nil)
+			req.Header.Set(ActorHeader, "jane")
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
@@ -474,11 +737,12 @@ func TestListEmployeesHandler(t *testing.T) {
 			page:           1,
 			perPage:        10,
 			before: func(page, perPage int, t *testing.T) {
-				rows := sqlmock.NewRows([]string{"id", "name", "position", "salary", "total"}).
-					AddRow(1, "John Doe", "Engineer", 50000.0, 1)
-				mock.ExpectQuery(`SELECT id, name, position, salary, COUNT\(\*\) OVER\(\) AS total FROM employees ORDER BY id LIMIT \$1 OFFSET \$2`).
-					WithArgs(perPage, (page-1)*perPage).
+				rows := sqlmock.NewRows(employeeColumns).
+					AddRow(1, "John Doe", "Engineer", 5000000, "USD", 1, nil, "", nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
+					WithArgs(sqlmock.AnyArg(), perPage+1, (page-1)*perPage).
 					WillReturnRows(rows)
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM ` + currentEmployees + ` WHERE deleted_at IS NULL`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 			},
 			after: func(t *testing.T) {
 				err := mock.ExpectationsWereMet()
@@ -494,8 +758,8 @@ func TestListEmployeesHandler(t *testing.T) {
 			perPage:        10,
 			expectedStatus: http.StatusInternalServerError,
 			before: func(page, perPage int, t *testing.T) {
-				mock.ExpectQuery(`SELECT id, name, position, salary, COUNT\(\*\) OVER\(\) AS total FROM employees ORDER BY id LIMIT \$1 OFFSET \$2`).
-					WithArgs(perPage, (page-1)*perPage).
+				mock.ExpectQuery(`SELECT id, name, position, salary_minor, currency, version, deleted_at, deleted_by, department_id, manager_id, position_id, external_id, user_name FROM `+currentEmployees+` WHERE deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
+					WithArgs(sqlmock.AnyArg(), perPage+1, (page-1)*perPage).
 					WillReturnError(errors.New("failed to list"))
 			},
 			after: func(t *testing.T) {
@@ -532,3 +796,99 @@ func TestListEmployeesHandler(t *testing.T) {
 		})
 	}
 }
+
+// restoreFailingDB fails to restore employees with err.
+type restoreFailingDB struct {
+	database.EmployeeDB
+	err error
+}
+
+func (db restoreFailingDB) RestoreEmployee(context.Context, int) (database.Employee, error) {
+	return database.Employee{}, db.err
+}
+
+func TestRestoreEmployeeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		accept         string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "restore deleted employee",
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "restore as xml",
+			id:             "1",
+			accept:         "application/xml",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not acceptable",
+			id:             "1",
+			accept:         "image/png",
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "employee not deleted",
+			id:             "2",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "serialization failure",
+			id:             "1",
+			err:            fmt.Errorf("%w: could not serialize access", database.ErrConflict),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "employee not found",
+			id:             "3",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var edb database.EmployeeDB = database.NewMemoryEmployee()
+			for _, name := range []string{"John Doe", "Jane Doe"} {
+				if _, err := edb.CreateEmployee(context.Background(), database.Employee{Name: name, Position: "Engineer", Salary: database.Money{Amount: 5000000, Currency: "USD"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := edb.DeleteEmployee(context.Background(), 1, 0); err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != nil {
+				edb = restoreFailingDB{edb, tt.err}
+			}
+			h := NewHandler(edb)
+
+			r := chi.NewRouter()
+			r.Post("/employees/{id}/restore", h.RestoreEmployeeHandler)
+
+			req, _ := http.NewRequest("POST", "/employees/"+tt.id+"/restore", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+type ReportsResponse struct {
+	Employees []EmployeeResponse `json:"employees"`
+}
+
+type ReportingChainResponse struct {
+	// Managers starts with the manager of the employee and ends with the
+	// head of the hierarchy.
+	Managers []EmployeeResponse `json:"managers"`
+}
+
+// ReportResponse is an employee under a manager, Depth levels down.
+type ReportResponse struct {
+	EmployeeResponse
+	Depth int `json:"depth" example:"1"`
+}
+
+type SubtreeResponse struct {
+	Reports []ReportResponse `json:"reports"`
+}
+
+// ListDirectReportsHandler godoc
+// @Summary List the direct reports of an employee
+// @Tags hierarchy
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} ReportsResponse
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/reports [get]
+func (h *handler) ListDirectReportsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	reports, err := h.emp.ListDirectReports(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReportsResponse{Employees: toEmployeeResponses(reports)})
+}
+
+// ReportingChainHandler godoc
+// @Summary List the managers above an employee
+// @Description List the manager of an employee, the manager of that manager and so on up to the head of the hierarchy.
+// @Tags hierarchy
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} ReportingChainResponse
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/chain [get]
+func (h *handler) ReportingChainHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	managers, err := h.emp.ListReportingChain(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReportingChainResponse{Managers: toEmployeeResponses(managers)})
+}
+
+// SubtreeHandler godoc
+// @Summary List every employee under a manager
+// @Description List the direct reports of an employee, their reports and so on, by depth and then ID. Depth is 1 for a
+// @Description direct report.
+// @Tags hierarchy
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} SubtreeResponse
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/subtree [get]
+func (h *handler) SubtreeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	reports, err := h.emp.ListReports(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	response := SubtreeResponse{Reports: make([]ReportResponse, len(reports))}
+	for i, report := range reports {
+		response.Reports[i] = ReportResponse{EmployeeResponse: toEmployeeResponse(report.Employee), Depth: report.Depth}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func toEmployeeResponses(employees []database.Employee) []EmployeeResponse {
+	responses := make([]EmployeeResponse, len(employees))
+	for i, emp := range employees {
+		responses[i] = toEmployeeResponse(emp)
+	}
+	return responses
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestHierarchyHandlers(t *testing.T) {
+	h := NewHandler(database.NewMemoryEmployee())
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Post("/employees", h.CreateEmployeeHandler)
+	r.Patch("/employees/{id}", h.PatchEmployeeHandler)
+	r.Delete("/employees/{id}", h.DeleteEmployeeHandler)
+	r.Get("/employees/{id}/reports", h.ListDirectReportsHandler)
+	r.Get("/employees/{id}/chain", h.ReportingChainHandler)
+	r.Get("/employees/{id}/subtree", h.SubtreeHandler)
+
+	// Ada heads the hierarchy; Grace and Mary report to her and Linus to
+	// Grace.
+	for _, body := range []string{
+		`{"name":"Ada","position":"CEO","salary":90000}`,
+		`{"name":"Grace","position":"CTO","salary":80000,"manager_id":1}`,
+		`{"name":"Mary","position":"CFO","salary":80000,"manager_id":1}`,
+		`{"name":"Linus","position":"Engineer","salary":60000,"manager_id":2}`,
+	} {
+		req, _ := http.NewRequest("POST", "/employees", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("creating %s returned %d: %s", body, rr.Code, rr.Body)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"direct reports", "GET", "/employees/1/reports", "", http.StatusOK},
+		{"reporting chain", "GET", "/employees/4/chain", "", http.StatusOK},
+		{"subtree", "GET", "/employees/1/subtree", "", http.StatusOK},
+		{"missing employee", "GET", "/employees/9/subtree", "", http.StatusNotFound},
+		{"invalid employee id", "GET", "/employees/abc/chain", "", http.StatusBadRequest},
+		{"reporting cycle", "PATCH", "/employees/1", `{"manager_id":4}`, http.StatusUnprocessableEntity},
+		{"missing manager", "PATCH", "/employees/4", `{"manager_id":9}`, http.StatusUnprocessableEntity},
+		{"negative manager", "PATCH", "/employees/4", `{"manager_id":-1}`, http.StatusBadRequest},
+		{"delete manager", "DELETE", "/employees/2", "", http.StatusConflict},
+		{"clear manager", "PATCH", "/employees/4", `{"manager_id":null}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set(middleware.RequestIDHeader, "hierarchy")
+			if tt.method == "PATCH" {
+				req.Header.Set("Content-Type", MergePatchContentType)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/spreadsheet"
+)
+
+// Limits of an import: the size of the file, and the number of employees
+// it may hold.
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10000
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// errUnsupportedImport is returned for an upload in neither CSV nor XLSX.
+var errUnsupportedImport = errors.New("unsupported import format")
+
+// importFields are the fields of EmployeeParams the columns of an import
+// may hold, in the order required columns are reported.
+var importFields = []string{"name", "position", "salary", "currency", "department_id", "manager_id", "position_id", "band_override_reason"}
+
+// headerAliases are the other names a header may give a field, once
+// normalised by normaliseHeader.
+var headerAliases = map[string]string{
+	"full_name":       "name",
+	"employee_name":   "name",
+	"title":           "position",
+	"job_title":       "position",
+	"annual_salary":   "salary",
+	"pay":             "salary",
+	"department":      "department_id",
+	"manager":         "manager_id",
+	"override_reason": "band_override_reason",
+}
+
+// ImportRowError describes what is wrong with one row of an import. Rows
+// are numbered as a spreadsheet shows them, the header being row 1.
+type ImportRowError struct {
+	Row     int    `json:"row" example:"3"`
+	Column  string `json:"column,omitempty" example:"Annual Salary"`
+	Field   string `json:"field,omitempty" example:"salary"`
+	Message string `json:"message" example:"must be greater than zero"`
+}
+
+// ImportResponse is the result of an import, or of its dry run.
+type ImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Rows counts the employees the file holds, blank rows aside, and Valid
+	// those that passed every check.
+	Rows     int `json:"rows" example:"250"`
+	Valid    int `json:"valid" example:"248"`
+	Imported int `json:"imported" example:"0"`
+	// IgnoredColumns are the headers that map to no field.
+	IgnoredColumns []string           `json:"ignored_columns,omitempty"`
+	Errors         []ImportRowError   `json:"errors,omitempty"`
+	Employees      []EmployeeResponse `json:"employees,omitempty"`
+}
+
+// importRow is a row of an import and the employee read from it.
+type importRow struct {
+	num    int
+	values []string
+	params EmployeeParams
+}
+
+// ImportEmployeesHandler godoc
+// @Summary Import employees from a spreadsheet
+// @Description Create employees from the rows of a CSV file or of the first worksheet of an XLSX workbook, sent as the
+// @Description body or as the file part of a multipart form. The first row is the header. Headers name the fields of
+// @Description EmployeeParams, ignoring case, spaces and hyphens, or common aliases such as "Full Name" or "Job Title";
+// @Description map_<field>=<header> maps any other header to a field. Columns that map to no field are ignored and
+// @Description listed in the response.
+// @Description
+// @Description Every row is validated like a created employee, and either every employee is imported or, if any row
+// @Description is invalid, none is. With dry_run=true the rows are only checked. With Accept: text/csv the response is
+// @Description a CSV report of the invalid rows, with their errors in a last column, to fix and upload again.
+// @Tags employees
+// @Accept text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,mpfd
+// @Produce json,text/csv,application/problem+json
+// @Param file formData file false "CSV or XLSX file, when sent as a multipart form"
+// @Param dry_run query bool false "Only check the rows"
+// @Param map_name query string false "Header of the column holding the name; likewise map_salary and the other fields"
+// @Success 200 {object} ImportResponse "Dry run"
+// @Success 201 {object} ImportResponse "Employees imported"
+// @Failure 400 {object} Problem "Invalid file, header or parameters"
+// @Failure 413 {object} Problem "File too large"
+// @Failure 415 {object} Problem "Neither CSV nor XLSX"
+// @Failure 422 {object} Problem "Invalid rows; nothing was imported"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/import [post]
+func (h *handler) ImportEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var errs ValidationError
+	var dryRun bool
+	if v := params.Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			errs = append(errs, FieldError{Field: "dry_run", Message: "must be true or false", err: ErrInvalidImport})
+		}
+	}
+	mapping, mapErrs := importMapping(params)
+	if errs = append(errs, mapErrs...); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	records, err := readImport(w, r)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeInvalidPayload,
+			Status: http.StatusRequestEntityTooLarge,
+			Detail: fmt.Sprintf("The file must be at most %d bytes", maxImportBytes),
+		})
+		return
+	case errors.Is(err, errUnsupportedImport):
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeUnsupportedMediaType,
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Send a CSV or XLSX file, as the body or as the file part of a multipart form",
+		})
+		return
+	case err != nil:
+		writeProblem(w, r, Problem{Type: ProblemTypeInvalidPayload, Status: http.StatusBadRequest, Detail: "The file is not a valid CSV or XLSX spreadsheet"})
+		return
+	}
+
+	header, rows := splitImport(records)
+	columns, ignored, errs := mapHeader(header, mapping)
+	switch {
+	case errs != nil:
+	case len(rows) == 0:
+		errs = ValidationError{{Field: "file", Message: "must have a header row and at least one employee", err: ErrInvalidImport}}
+	case len(rows) > maxImportRows:
+		errs = ValidationError{{Field: "file", Message: fmt.Sprintf("must have at most %d employees", maxImportRows), err: ErrInvalidImport}}
+	}
+	if errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	response := ImportResponse{DryRun: dryRun, Rows: len(rows), IgnoredColumns: ignored}
+	var valid []importRow
+	for _, row := range rows {
+		rowErrs := row.read(columns)
+		for _, e := range rowErrs {
+			response.Errors = append(response.Errors, importRowError(row, header, columns, e.Field, e.Message))
+		}
+		if rowErrs == nil {
+			valid = append(valid, row)
+		}
+	}
+	// Even when some rows are invalid, the others are checked against the
+	// database so that one report lists every error.
+	employees, err := h.emp.ImportEmployees(r.Context(), importRows(valid), dryRun || response.Errors != nil)
+	var importErr *database.ImportError
+	if errors.As(err, &importErr) {
+		for _, refused := range importErr.Rows {
+			row := valid[refused.Index]
+			field, message := importRowDBError(refused.Err)
+			response.Errors = append(response.Errors, importRowError(row, header, columns, field, message))
+		}
+		sort.SliceStable(response.Errors, func(i, j int) bool { return response.Errors[i].Row < response.Errors[j].Row })
+	} else if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	response.Valid = response.Rows - rejectedRows(response.Errors)
+	switch {
+	case response.Errors != nil && !dryRun:
+		status = http.StatusUnprocessableEntity
+	case !dryRun:
+		status = http.StatusCreated
+		response.Imported = len(employees)
+		response.Employees = make([]EmployeeResponse, len(employees))
+		for i, emp := range employees {
+			response.Employees[i] = toEmployeeResponse(emp)
+		}
+	}
+	if acceptsCSV(r) {
+		writeImportReport(w, status, header, rows, response.Errors)
+		return
+	}
+	if status == http.StatusUnprocessableEntity {
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeImportRejected,
+			Title:  "Import rejected",
+			Status: status,
+			Detail: fmt.Sprintf("%d of %d rows are invalid; nothing was imported", response.Rows-response.Valid, response.Rows),
+			Rows:   response.Errors,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// importMapping reads the map_<field>=<header> parameters, as normalised
+// headers to fields.
+func importMapping(params map[string][]string) (map[string]string, ValidationError) {
+	mapping := make(map[string]string)
+	var errs ValidationError
+	for param, values := range params {
+		field, ok := strings.CutPrefix(param, "map_")
+		if !ok {
+			continue
+		}
+		header := normaliseHeader(values[0])
+		switch {
+		case !slices.Contains(importFields, field):
+			errs = append(errs, FieldError{Field: param, Message: "must map one of " + strings.Join(importFields, ", "), err: ErrInvalidImport})
+		case header == "":
+			errs = append(errs, FieldError{Field: param, Message: "must name a column", err: ErrInvalidImport})
+		case mapping[header] != "":
+			errs = append(errs, FieldError{Field: param, Message: "must not name the column another field is mapped to", err: ErrInvalidImport})
+		default:
+			mapping[header] = field
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return mapping, errs
+}
+
+// normaliseHeader folds the case, spacing and hyphens of a header, so that
+// "Department ID" and "department-id" both name department_id.
+func normaliseHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.Join(strings.FieldsFunc(header, func(r rune) bool { return r == ' ' || r == '-' || r == '_' }), "_")
+}
+
+// readImport reads the spreadsheet of an import, as the body or as the
+// file part of a multipart form.
+func readImport(w http.ResponseWriter, r *http.Request) ([][]string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return readSpreadsheet(r.Body, mediaType)
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errUnsupportedImport
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		// Browsers send CSV files as whatever the system registers them as,
+		// so the extension decides when the type is not one of ours.
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if mediaType != spreadsheet.CSVContentType && mediaType != spreadsheet.XLSXContentType {
+			switch strings.ToLower(path.Ext(part.FileName())) {
+			case ".csv":
+				mediaType = spreadsheet.CSVContentType
+			case ".xlsx":
+				mediaType = spreadsheet.XLSXContentType
+			}
+		}
+		return readSpreadsheet(part, mediaType)
+	}
+}
+
+// readSpreadsheet reads the rows of r, a file of mediaType.
+func readSpreadsheet(r io.Reader, mediaType string) ([][]string, error) {
+	switch mediaType {
+	case spreadsheet.CSVContentType:
+		return spreadsheet.ReadCSV(r)
+	case spreadsheet.XLSXContentType:
+		// Workbooks are zip files, read from their central directory at
+		// the end.
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return spreadsheet.ReadXLSX(bytes.NewReader(b), int64(len(b)))
+	}
+	return nil, errUnsupportedImport
+}
+
+// splitImport returns the header of records, the first row that is not
+// blank, and the rows under it that are not blank either.
+func splitImport(records [][]string) ([]string, []importRow) {
+	var header []string
+	var rows []importRow
+	for i, values := range records {
+		if blank(values) {
+			continue
+		}
+		if header == nil {
+			header = values
+			continue
+		}
+		rows = append(rows, importRow{num: i + 1, values: values})
+	}
+	return header, rows
+}
+
+func blank(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// mapHeader returns the field each column of header maps to, "" for the
+// columns it ignores, and the headers of those columns. Explicit mappings
+// take precedence over header names and aliases.
+func mapHeader(header []string, mapping map[string]string) ([]string, []string, ValidationError) {
+	columns := make([]string, len(header))
+	var ignored []string
+	var errs ValidationError
+	explicit := make(map[string]bool)
+	for _, field := range mapping {
+		explicit[field] = true
+	}
+	mapped := make(map[string]int)
+	for i, h := range header {
+		name := normaliseHeader(h)
+		field, ok := mapping[name]
+		if !ok {
+			field = cmp.Or(headerAliases[name], name)
+			if !slices.Contains(importFields, field) || explicit[field] {
+				ignored = append(ignored, h)
+				continue
+			}
+		}
+		if j, ok := mapped[field]; ok {
+			msg := fmt.Sprintf("is named by both columns %q and %q; choose one with map_%s", header[j], h, field)
+			errs = append(errs, FieldError{Field: field, Message: msg, err: ErrInvalidImport})
+			continue
+		}
+		mapped[field] = i
+		columns[i] = field
+	}
+	for name, field := range mapping {
+		if !slices.ContainsFunc(header, func(h string) bool { return normaliseHeader(h) == name }) {
+			errs = append(errs, FieldError{Field: "map_" + field, Message: "must name a column of the header", err: ErrInvalidImport})
+		}
+	}
+	for _, required := range []string{"name", "salary"} {
+		if _, ok := mapped[required]; !ok {
+			errs = append(errs, FieldError{Field: required, Message: "must have a column", err: ErrInvalidImport})
+		}
+	}
+	_, position := mapped["position"]
+	if _, positionID := mapped["position_id"]; !position && !positionID {
+		errs = append(errs, FieldError{Field: "position", Message: "must have a column, unless position_id has one", err: ErrInvalidImport})
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return columns, ignored, errs
+}
+
+// read sets the params of row from the values of its columns, and returns
+// what is wrong with them.
+func (row *importRow) read(columns []string) ValidationError {
+	var errs ValidationError
+	if len(row.values) > len(columns) && !blank(row.values[len(columns):]) {
+		errs = append(errs, FieldError{Message: "must not have more cells than the header", err: ErrInvalidImport})
+	}
+	p := &row.params
+	for i, field := range columns {
+		if i >= len(row.values) || field == "" {
+			continue
+		}
+		value := strings.TrimSpace(row.values[i])
+		var id *int
+		switch field {
+		case "name":
+			p.Name = value
+		case "position":
+			p.Position = value
+		case "salary":
+			p.Salary = json.Number(value)
+		case "currency":
+			p.Currency = value
+		case "department_id":
+			id = &p.DepartmentID
+		case "manager_id":
+			id = &p.ManagerID
+		case "position_id":
+			id = &p.PositionID
+		case "band_override_reason":
+			p.BandOverrideReason = value
+		}
+		if id == nil || value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			errs = append(errs, FieldError{Field: field, Message: "must be a positive integer", err: ErrInvalidRelation})
+			continue
+		}
+		*id = n
+	}
+	return append(errs, p.validate()...)
+}
+
+// importRowError is the error about field of row, in the column of header
+// that field is read from, if any.
+func importRowError(row importRow, header, columns []string, field, message string) ImportRowError {
+	e := ImportRowError{Row: row.num, Field: field, Message: message}
+	if i := slices.Index(columns, field); field != "" && i >= 0 {
+		e.Column = header[i]
+	}
+	return e
+}
+
+// importRows converts the params of rows for ImportEmployees.
+func importRows(rows []importRow) []database.ImportRow {
+	imports := make([]database.ImportRow, len(rows))
+	for i, row := range rows {
+		imports[i] = database.ImportRow{Employee: row.params.ToEmployee(), BandOverride: strings.TrimSpace(row.params.BandOverrideReason)}
+	}
+	return imports
+}
+
+// importRowDBError returns the field the database refused a row for, and
+// why, in the words of a validation error.
+func importRowDBError(err error) (string, string) {
+	switch {
+	case errors.Is(err, database.ErrUnknownDepartment):
+		return "department_id", "must be an existing department"
+	case errors.Is(err, database.ErrUnknownManager):
+		return "manager_id", "must be an employee who is not deleted"
+	case errors.Is(err, database.ErrUnknownPosition):
+		return "position_id", "must be a position in the catalog"
+	case errors.Is(err, database.ErrUnmatchedPosition):
+		return "position", "must match a single title of the catalog, unless position_id is given"
+	case errors.Is(err, database.ErrSalaryOutOfBand):
+		return "salary", "must be within the band of the position, unless band_override_reason is given"
+	}
+	return "", "violates a data constraint"
+}
+
+// rejectedRows counts the rows errs are about.
+func rejectedRows(errs []ImportRowError) int {
+	rows := make(map[int]bool)
+	for _, e := range errs {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// acceptsCSV reports whether the client asked for the CSV report.
+func acceptsCSV(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, _ := mime.ParseMediaType(accept); mediaType == spreadsheet.CSVContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeImportReport writes the rows errs are about as a CSV file, under the
+// header of the import and with their errors in a last column, so that
+// they can be fixed and uploaded again.
+func writeImportReport(w http.ResponseWriter, status int, header []string, rows []importRow, errs []ImportRowError) {
+	messages := make(map[int][]string)
+	for _, e := range errs {
+		msg := e.Message
+		if e.Column != "" {
+			msg = e.Column + ": " + msg
+		}
+		messages[e.Row] = append(messages[e.Row], msg)
+	}
+	w.Header().Set("Content-Type", spreadsheet.CSVContentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+	w.WriteHeader(status)
+	cw := csv.NewWriter(w)
+	cw.Write(append(slices.Clip(header), "errors"))
+	for _, row := range rows {
+		msgs, ok := messages[row.num]
+		if !ok {
+			continue
+		}
+		values := make([]string, len(header))
+		copy(values, row.values)
+		cw.Write(append(values, strings.Join(msgs, "; ")))
+	}
+	cw.Flush()
+}
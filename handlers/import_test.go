@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/spreadsheet"
+)
+
+func TestImportEmployeesHandler(t *testing.T) {
+	newRouter := func(t *testing.T) http.Handler {
+		t.Helper()
+		db := database.NewMemoryEmployee()
+		if _, err := db.CreateDepartment(context.Background(), database.Department{Name: "Engineering"}); err != nil {
+			t.Fatal(err)
+		}
+		senior := database.Position{Code: "ENG-3", Title: "Senior Engineer", Level: 3, Bands: []database.SalaryBand{{Currency: "USD", Min: 9000000, Mid: 11000000, Max: 13000000}}}
+		if _, err := db.CreatePosition(context.Background(), senior); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.CreatePosition(context.Background(), database.Position{Code: "EXEC-1", Title: "cto", Level: 9}); err != nil {
+			t.Fatal(err)
+		}
+		h := NewHandler(db)
+		r := chi.NewRouter()
+		r.Use(middleware.RequestID)
+		r.Post("/employees/import", h.ImportEmployeesHandler)
+		return r
+	}
+
+	const valid = "Full Name,Job Title,Annual Salary,Currency,Department,Position ID,Notes\n" +
+		"Ada Lovelace,CTO,150000,usd,1,,founder\n" +
+		"\n" +
+		"Grace Hopper,,120000.50,,,1,\n"
+	const invalid = "name,position,salary,department_id,position_id,band_override_reason\n" +
+		"Ada Lovelace,CTO,150000,1,,\n" +
+		",,-5,x,,\n" +
+		"Grace Hopper,,150000,,1,\n" +
+		"Linus Torvalds,,150000,,1,Competing offer\n" +
+		"Mary Jackson,Engineer,90000,7,,\n"
+
+	multipartBody := func(filename, content string) (string, string) {
+		var b bytes.Buffer
+		mw := multipart.NewWriter(&b)
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write([]byte(content))
+		mw.Close()
+		return b.String(), mw.FormDataContentType()
+	}
+	csvUpload, csvUploadType := multipartBody("staff.csv", valid)
+	textUpload, textUploadType := multipartBody("staff.txt", valid)
+
+	type step struct {
+		name           string
+		query          string
+		contentType    string
+		accept         string
+		body           string
+		expectedStatus int
+	}
+	request := func(tt step) *http.Request {
+		req, _ := http.NewRequest("POST", "/employees/import"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set(middleware.RequestIDHeader, "import")
+		req.Header.Set("Content-Type", tt.contentType)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		return req
+	}
+
+	// The steps build on each other: only the last import commits. Each
+	// runs on a store of its own after the steps before it.
+	tests := []step{
+		{"dry run", "?dry_run=true", spreadsheet.CSVContentType, "", valid, http.StatusOK},
+		{"dry run of invalid rows", "?dry_run=1", spreadsheet.CSVContentType, "", invalid, http.StatusOK},
+		{"error report", "?dry_run=true", spreadsheet.CSVContentType, "text/csv", invalid, http.StatusOK},
+		{"rejected", "", spreadsheet.CSVContentType, "", invalid, http.StatusUnprocessableEntity},
+		{"rejected error report", "", spreadsheet.CSVContentType, "text/csv, application/json", invalid, http.StatusUnprocessableEntity},
+		{"explicit mapping", "?dry_run=true&map_name=Employee&map_salary=Comp", spreadsheet.CSVContentType, "", "Employee,Comp,Position\nAda,100,CTO\n", http.StatusOK},
+		{"invalid mapping", "?map_age=Age&map_name=", spreadsheet.CSVContentType, "", valid, http.StatusBadRequest},
+		{"missing columns", "", spreadsheet.CSVContentType, "", "Employee,Pay,Pay\nAda,1,2\n", http.StatusBadRequest},
+		{"header only", "", spreadsheet.CSVContentType, "", "name,position,salary\n", http.StatusBadRequest},
+		{"invalid dry run", "?dry_run=maybe", spreadsheet.CSVContentType, "", valid, http.StatusBadRequest},
+		{"malformed csv", "", spreadsheet.CSVContentType, "", "name,\"position\nAda", http.StatusBadRequest},
+		{"malformed xlsx", "", spreadsheet.XLSXContentType, "", "not a zip", http.StatusBadRequest},
+		{"unsupported format", "", "application/json", "", `{"name":"Ada"}`, http.StatusUnsupportedMediaType},
+		{"unsupported upload", "", textUploadType, "", textUpload, http.StatusUnsupportedMediaType},
+		{"import", "", csvUploadType, "", csvUpload, http.StatusCreated},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t)
+			for _, before := range tests[:i] {
+				r.ServeHTTP(httptest.NewRecorder(), request(before))
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request(tt))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
+
+func TestImportEmployeesHandlerTooLarge(t *testing.T) {
+	h := NewHandler(database.NewMemoryEmployee())
+	body := "name,position,salary\n" + strings.Repeat("Ada,CTO,1\n", maxImportBytes/10+1)
+	req, _ := http.NewRequest("POST", "/employees/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", spreadsheet.CSVContentType)
+	rr := httptest.NewRecorder()
+	h.ImportEmployeesHandler(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d: %s", rr.Code, http.StatusRequestEntityTooLarge, rr.Body)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/orgchart"
+)
+
+// OrgChartHandler godoc
+// @Summary Draw the org chart
+// @Description Draw the reporting lines of the employees who are not deleted, as Graphviz DOT, a Mermaid flowchart or a
+// @Description standalone SVG. Without root the chart starts from every employee who reports to no one. With depth
+// @Description only that many levels of reports are drawn, and each cut-off manager shows how many employees it hides.
+// @Tags hierarchy
+// @Produce image/svg+xml,text/vnd.graphviz,plain,application/problem+json
+// @Param root query int false "ID of the employee at the top of the chart"
+// @Param depth query int false "Levels of reports to draw under the top, all if omitted"
+// @Param group_by query string false "Group the employees by position" Enums(position)
+// @Param format query string false "Output format" Enums(svg, dot, mermaid) default(svg)
+// @Success 200 {string} string "The chart"
+// @Failure 400 {object} Problem "Invalid parameters"
+// @Failure 404 {object} Problem "Root employee not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /orgchart [get]
+func (h *handler) OrgChartHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var errs ValidationError
+	var opts orgchart.Options
+	if root := params.Get("root"); root != "" {
+		var err error
+		if opts.Root, err = strconv.Atoi(root); err != nil || opts.Root <= 0 {
+			errs = append(errs, FieldError{Field: "root", Message: "must be a positive integer", err: ErrInvalidFilter})
+		}
+	}
+	if depth := params.Get("depth"); depth != "" {
+		var err error
+		if opts.Depth, err = strconv.Atoi(depth); err != nil || opts.Depth <= 0 {
+			errs = append(errs, FieldError{Field: "depth", Message: "must be a positive integer", err: ErrInvalidFilter})
+		}
+	}
+	if opts.GroupBy = params.Get("group_by"); opts.GroupBy != "" && opts.GroupBy != orgchart.GroupByPosition {
+		errs = append(errs, FieldError{Field: "group_by", Message: "must be " + orgchart.GroupByPosition, err: ErrInvalidFilter})
+	}
+	format := strings.ToLower(params.Get("format"))
+	if format == "" {
+		format = orgchart.FormatSVG
+	}
+	if !slices.Contains(orgchart.Formats, format) {
+		errs = append(errs, FieldError{Field: "format", Message: "must be one of " + strings.Join(orgchart.Formats, ", "), err: ErrInvalidFilter})
+	}
+	if errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	chart, err := orgchart.Build(r.Context(), h.emp, opts)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	var b bytes.Buffer
+	if err := orgchart.Render(&b, chart, format); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", orgchart.ContentType(format))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(b.Bytes())
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestOrgChartHandler(t *testing.T) {
+	h := NewHandler(database.NewMemoryEmployee())
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Post("/employees", h.CreateEmployeeHandler)
+	r.Get("/orgchart", h.OrgChartHandler)
+
+	for _, body := range []string{
+		`{"name":"Ada","position":"CEO","salary":90000}`,
+		`{"name":"Grace","position":"CTO","salary":80000,"manager_id":1}`,
+		`{"name":"Linus","position":"Engineer","salary":60000,"manager_id":2}`,
+	} {
+		req, _ := http.NewRequest("POST", "/employees", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("creating %s returned %d: %s", body, rr.Code, rr.Body)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"mermaid", "/orgchart?format=mermaid", http.StatusOK},
+		{"dot rooted with depth", "/orgchart?format=DOT&root=1&depth=1&group_by=position", http.StatusOK},
+		{"svg by default", "/orgchart?root=2", http.StatusOK},
+		{"missing root", "/orgchart?root=9", http.StatusNotFound},
+		{"invalid parameters", "/orgchart?root=x&depth=-1&group_by=salary&format=png", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Header.Set(middleware.RequestIDHeader, "orgchart")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
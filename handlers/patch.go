@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// Media types accepted by PatchEmployeeHandler.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// maxPatchBytes bounds the size of a patch document.
+const maxPatchBytes = 1 << 20
+
+// PatchEmployeeHandler partially updates an employee.
+// @Summary Partially update an employee
+// @Description Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to an employee. The patch applies to the
+// @Description fields of EmployeeParams, and the result is validated like a full update. Only changed fields are written.
+// @Description With If-Match the patch only applies if the employee still has that ETag.
+// @Tags employees
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Param If-Match header string false "ETag the employee must still have"
+// @Param body body object true "JSON Merge Patch document or array of JSON Patch operations"
+// @Success 200 {object} EmployeeResponse
+// @Header 200 {string} ETag "Version of the employee, for If-Match"
+// @Failure 400 {object} Problem "Invalid patch or resulting employee"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 409 {object} Problem "A JSON Patch test operation failed"
+// @Failure 412 {object} Problem "The employee no longer matches If-Match, or changed while the patch was applied"
+// @Failure 415 {object} Problem "Unsupported patch format"
+// @Failure 422 {object} Problem "The patch cannot be applied to the employee"
+// @Failure 500 {object} Problem
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id} [patch]
+func (h *handler) PatchEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != MergePatchContentType && mediaType != JSONPatchContentType {
+		w.Header().Set("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeUnsupportedMediaType,
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Send the patch as " + MergePatchContentType + " or " + JSONPatchContentType,
+		})
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+
+	current, err := h.emp.GetEmployeeByID(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !ifMatches(ifMatch, current) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	original := toEmployeeParams(current)
+	patched, err := applyPatch(mediaType, original, patch)
+	if err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+	if errs := patched.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+
+	employee, err := h.emp.PatchEmployee(patched.Context(r.Context()), id, changes(current, patched.ToEmployee()))
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(employee, JSONContentType))
+	json.NewEncoder(w).Encode(toEmployeeResponse(employee))
+}
+
+var (
+	errMalformedPatch = errors.New("malformed patch")
+	errPatchTest      = errors.New("patch test failed")
+)
+
+// applyPatch applies patch to the JSON form of params and decodes the
+// result, rejecting fields EmployeeParams does not have.
+func applyPatch(mediaType string, params EmployeeParams, patch []byte) (EmployeeParams, error) {
+	doc, err := json.Marshal(params)
+	if err != nil {
+		return EmployeeParams{}, err
+	}
+	if mediaType == MergePatchContentType {
+		if !json.Valid(patch) {
+			return EmployeeParams{}, errMalformedPatch
+		}
+		doc, err = jsonpatch.MergePatch(doc, patch)
+	} else {
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(patch); err != nil {
+			return EmployeeParams{}, errMalformedPatch
+		}
+		doc, err = ops.Apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return EmployeeParams{}, errPatchTest
+		}
+	}
+	if err != nil {
+		return EmployeeParams{}, err
+	}
+
+	var patched EmployeeParams
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&patched); err != nil {
+		return EmployeeParams{}, errMalformedPatch
+	}
+	return patched, nil
+}
+
+func writePatchError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errMalformedPatch):
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeInvalidPayload,
+			Status: http.StatusBadRequest,
+			Detail: "The patch is malformed or sets fields an employee does not have",
+		})
+	case errors.Is(err, errPatchTest):
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeConflict,
+			Status: http.StatusConflict,
+			Detail: "A test operation in the patch did not match the employee",
+		})
+	default:
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeUnprocessablePatch,
+			Status: http.StatusUnprocessableEntity,
+			Detail: err.Error(),
+		})
+	}
+}
+
+func toEmployeeParams(emp database.Employee) EmployeeParams {
+	return EmployeeParams{
+		Name:     emp.Name,
+		Position: emp.Position,
+		Salary:   json.Number(emp.Salary.String()),
+		Currency: emp.Salary.Currency,
+
+		DepartmentID: emp.DepartmentID,
+		ManagerID:    emp.ManagerID,
+		PositionID:   emp.PositionID,
+	}
+}
+
+// changes lists the fields of updated that differ from current. The patch was
+// computed from current, so the changes are conditional on its version.
+func changes(current, updated database.Employee) database.EmployeeChanges {
+	c := database.EmployeeChanges{Version: current.Version}
+	if updated.Name != current.Name {
+		c.Name = &updated.Name
+	}
+	if updated.Position != current.Position {
+		c.Position = &updated.Position
+	}
+	if updated.Salary != current.Salary {
+		c.Salary = &updated.Salary
+	}
+	if updated.DepartmentID != current.DepartmentID {
+		c.DepartmentID = &updated.DepartmentID
+	}
+	if updated.ManagerID != current.ManagerID {
+		c.ManagerID = &updated.ManagerID
+	}
+	if updated.PositionID != current.PositionID {
+		c.PositionID = &updated.PositionID
+	}
+	return c
+}
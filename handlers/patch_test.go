@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestPatchEmployeeHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             int
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "merge patch position",
+			id:             1,
+			contentType:    MergePatchContentType,
+			body:           `{"position": "Manager"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "merge patch salary and currency",
+			id:             1,
+			contentType:    MergePatchContentType,
+			body:           `{"salary": 6000000, "currency": "JPY"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "json patch replace salary",
+			id:             1,
+			contentType:    JSONPatchContentType,
+			body:           `[{"op": "replace", "path": "/salary", "value": 50000.75}]`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "json patch test failed",
+			id:             1,
+			contentType:    JSONPatchContentType,
+			body:           `[{"op": "test", "path": "/name", "value": "Jane Doe"}, {"op": "replace", "path": "/name", "value": "John Smith"}]`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "json patch missing path",
+			id:             1,
+			contentType:    JSONPatchContentType,
+			body:           `[{"op": "remove", "path": "/department"}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown field",
+			id:             1,
+			contentType:    MergePatchContentType,
+			body:           `{"department": "Sales"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid result",
+			id:             1,
+			contentType:    MergePatchContentType,
+			body:           `{"name": null, "salary": 0}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed patch",
+			id:             1,
+			contentType:    JSONPatchContentType,
+			body:           `{"op": "replace"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported media type",
+			id:             1,
+			contentType:    "application/json",
+			body:           `{"position": "Manager"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "employee not found",
+			id:             2,
+			contentType:    MergePatchContentType,
+			body:           `{"position": "Manager"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edb := database.NewMemoryEmployee()
+			_, err := edb.CreateEmployee(context.Background(), database.Employee{
+				Name:     "John Doe",
+				Position: "Engineer",
+				Salary:   database.Money{Amount: 5000000, Currency: "USD"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := NewHandler(edb)
+
+			r := chi.NewRouter()
+			r.Patch("/employees/{id}", h.PatchEmployeeHandler)
+
+			req, _ := http.NewRequest("PATCH", "/employees/"+strconv.Itoa(tt.id), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+var (
+	ErrInvalidPositionCode = errors.New("invalid position code")
+	ErrInvalidBand         = errors.New("invalid salary band")
+)
+
+// PositionParams defines the body of CreatePositionHandler and
+// UpdatePositionHandler. An update replaces the bands of the position.
+type PositionParams struct {
+	Code  string       `json:"code" example:"ENG-3"`
+	Title string       `json:"title" example:"Senior Engineer"`
+	Level int          `json:"level" example:"3"`
+	Bands []BandParams `json:"bands,omitempty"`
+}
+
+// BandParams is the salary band of a position in one currency, with no
+// more decimals than the currency allows.
+type BandParams struct {
+	Currency string      `json:"currency" example:"USD"`
+	Min      json.Number `json:"min" swaggertype:"number" example:"90000"`
+	Mid      json.Number `json:"mid" swaggertype:"number" example:"110000"`
+	Max      json.Number `json:"max" swaggertype:"number" example:"130000"`
+}
+
+// validate reports every invalid field rather than stopping at the first.
+func (p PositionParams) validate() ValidationError {
+	var errs ValidationError
+	if strings.TrimSpace(p.Code) == "" {
+		errs = append(errs, FieldError{Field: "code", Message: "must not be empty", err: ErrInvalidPositionCode})
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		errs = append(errs, FieldError{Field: "title", Message: "must not be empty", err: ErrInvalidPosition})
+	}
+	if p.Level < 0 {
+		errs = append(errs, FieldError{Field: "level", Message: "must not be negative", err: ErrInvalidPosition})
+	}
+	seen := make(map[string]bool)
+	for i, b := range p.Bands {
+		field := fmt.Sprintf("bands[%d]", i)
+		currency := strings.ToUpper(b.Currency)
+		if _, ok := database.CurrencyExponent(currency); !ok {
+			errs = append(errs, FieldError{Field: field + ".currency", Message: "must be a supported ISO 4217 currency code", err: ErrInvalidCurrency})
+			continue
+		}
+		if seen[currency] {
+			errs = append(errs, FieldError{Field: field + ".currency", Message: "must not repeat the currency of another band", err: ErrInvalidBand})
+			continue
+		}
+		seen[currency] = true
+		band, bandErrs := b.band(field)
+		if errs = append(errs, bandErrs...); bandErrs == nil && (band.Mid < band.Min || band.Max < band.Mid) {
+			errs = append(errs, FieldError{Field: field, Message: "must have min <= mid <= max", err: ErrInvalidBand})
+		}
+	}
+	return errs
+}
+
+// band parses b, reporting its invalid amounts as fields of field.
+func (b BandParams) band(field string) (database.SalaryBand, ValidationError) {
+	band := database.SalaryBand{Currency: strings.ToUpper(b.Currency)}
+	var errs ValidationError
+	for _, a := range []struct {
+		name   string
+		amount json.Number
+		minor  *int64
+	}{{"min", b.Min, &band.Min}, {"mid", b.Mid, &band.Mid}, {"max", b.Max, &band.Max}} {
+		money, err := database.ParseMoney(a.amount.String(), band.Currency)
+		if err != nil || money.Amount <= 0 {
+			exp, _ := database.CurrencyExponent(band.Currency)
+			msg := fmt.Sprintf("must be a number greater than zero with at most %d decimal places for %s", exp, band.Currency)
+			errs = append(errs, FieldError{Field: field + "." + a.name, Message: msg, err: ErrInvalidBand})
+			continue
+		}
+		*a.minor = money.Amount
+	}
+	return band, errs
+}
+
+// toPosition converts validated params.
+func (p PositionParams) toPosition() database.Position {
+	position := database.Position{Code: strings.TrimSpace(p.Code), Title: strings.TrimSpace(p.Title), Level: p.Level}
+	for _, b := range p.Bands {
+		band, _ := b.band("")
+		position.Bands = append(position.Bands, band)
+	}
+	return position
+}
+
+type PositionResponse struct {
+	ID    int            `json:"id" example:"4"`
+	Code  string         `json:"code" example:"ENG-3"`
+	Title string         `json:"title" example:"Senior Engineer"`
+	Level int            `json:"level" example:"3"`
+	Bands []BandResponse `json:"bands"`
+}
+
+type BandResponse struct {
+	Currency string      `json:"currency" example:"USD"`
+	Min      json.Number `json:"min" swaggertype:"number" example:"90000.00"`
+	Mid      json.Number `json:"mid" swaggertype:"number" example:"110000.00"`
+	Max      json.Number `json:"max" swaggertype:"number" example:"130000.00"`
+}
+
+type PositionsResponse struct {
+	Positions []PositionResponse `json:"positions"`
+}
+
+func toPositionResponse(p database.Position) PositionResponse {
+	response := PositionResponse{ID: p.ID, Code: p.Code, Title: p.Title, Level: p.Level, Bands: make([]BandResponse, len(p.Bands))}
+	for i, b := range p.Bands {
+		amount := func(minor int64) json.Number {
+			return json.Number(database.Money{Amount: minor, Currency: b.Currency}.String())
+		}
+		response.Bands[i] = BandResponse{Currency: b.Currency, Min: amount(b.Min), Mid: amount(b.Mid), Max: amount(b.Max)}
+	}
+	return response
+}
+
+// CreatePositionHandler godoc
+// @Summary Create a position
+// @Description Add a position to the catalog, with a salary band per currency. Employees given the position take its
+// @Description title, and their salary must be within its band for their currency unless the write gives a
+// @Description band_override_reason.
+// @Tags positions
+// @Accept json
+// @Produce json,application/problem+json
+// @Param body body PositionParams true "Position"
+// @Success 201 {object} PositionResponse
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 409 {object} Problem "A position with that code already exists"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /positions [post]
+func (h *handler) CreatePositionHandler(w http.ResponseWriter, r *http.Request) {
+	var params PositionParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+	if errs := params.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	position, err := h.emp.CreatePosition(r.Context(), params.toPosition())
+	if err != nil {
+		writePositionError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", r.URL.Path+"/"+strconv.Itoa(position.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPositionResponse(position))
+}
+
+// ListPositionsHandler godoc
+// @Summary List positions
+// @Description List the position catalog, most senior level first and then by code.
+// @Tags positions
+// @Produce json,application/problem+json
+// @Success 200 {object} PositionsResponse
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /positions [get]
+func (h *handler) ListPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	positions, err := h.emp.ListPositions(r.Context())
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	response := PositionsResponse{Positions: make([]PositionResponse, len(positions))}
+	for i, p := range positions {
+		response.Positions[i] = toPositionResponse(p)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPositionHandler godoc
+// @Summary Get a position by ID
+// @Tags positions
+// @Produce json,application/problem+json
+// @Param id path int true "Position ID"
+// @Success 200 {object} PositionResponse
+// @Failure 400 {object} Problem "Invalid position ID"
+// @Failure 404 {object} Problem "Position not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /positions/{id} [get]
+func (h *handler) GetPositionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	position, err := h.emp.GetPosition(r.Context(), id)
+	if err != nil {
+		writePositionError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPositionResponse(position))
+}
+
+// UpdatePositionHandler godoc
+// @Summary Update a position
+// @Description Replace a position and its bands. A new title is given to every employee holding the position. New bands
+// @Description apply to later writes only: salaries already outside them are left alone.
+// @Tags positions
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path int true "Position ID"
+// @Param body body PositionParams true "Position"
+// @Success 200 {object} PositionResponse
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 404 {object} Problem "Position not found"
+// @Failure 409 {object} Problem "A position with that code already exists"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /positions/{id} [put]
+func (h *handler) UpdatePositionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	var params PositionParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+	if errs := params.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	position := params.toPosition()
+	position.ID = id
+	position, err = h.emp.UpdatePosition(r.Context(), position)
+	if err != nil {
+		writePositionError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPositionResponse(position))
+}
+
+// DeletePositionHandler godoc
+// @Summary Delete a position
+// @Description Delete a position no employee holds, counting deleted employees until they are purged.
+// @Tags positions
+// @Produce json,application/problem+json
+// @Param id path int true "Position ID"
+// @Success 204 {string} string "Position deleted"
+// @Failure 400 {object} Problem "Invalid position ID"
+// @Failure 404 {object} Problem "Position not found"
+// @Failure 409 {object} Problem "Employees hold the position"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /positions/{id} [delete]
+func (h *handler) DeletePositionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	if err := h.emp.DeletePosition(r.Context(), id); err != nil {
+		writePositionError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePositionError is writeDBError for the position endpoints, whose not
+// found and conflicts are about positions.
+func writePositionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		writeProblem(w, r, Problem{Type: ProblemTypeNotFound, Status: http.StatusNotFound, Detail: "Position not found"})
+	case errors.Is(err, database.ErrPositionInUse):
+		writeProblem(w, r, Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "Employees hold the position; give them another first"})
+	case errors.Is(err, database.ErrConflict):
+		writeProblem(w, r, Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "A position with that code already exists"})
+	default:
+		writeDBError(w, r, err)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestPositionHandlers(t *testing.T) {
+	newRouter := func() http.Handler {
+		h := NewHandler(database.NewMemoryEmployee())
+		r := chi.NewRouter()
+		r.Use(middleware.RequestID)
+		r.Post("/employees", h.CreateEmployeeHandler)
+		r.Put("/employees/{id}", h.UpdateEmployeeHandler)
+		r.Patch("/employees/{id}", h.PatchEmployeeHandler)
+		r.Post("/positions", h.CreatePositionHandler)
+		r.Get("/positions", h.ListPositionsHandler)
+		r.Get("/positions/{id}", h.GetPositionHandler)
+		r.Put("/positions/{id}", h.UpdatePositionHandler)
+		r.Delete("/positions/{id}", h.DeletePositionHandler)
+		return r
+	}
+	type step struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}
+	request := func(tt step) *http.Request {
+		req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set(middleware.RequestIDHeader, "position")
+		if tt.method == "PATCH" {
+			req.Header.Set("Content-Type", MergePatchContentType)
+		}
+		return req
+	}
+
+	const senior = `{"code":"ENG-3","title":"Senior Engineer","level":3,"bands":[{"currency":"usd","min":90000,"mid":110000,"max":130000}]}`
+	// The steps build on each other, so each runs on a store of its own
+	// after the steps before it.
+	tests := []step{
+		{"create", "POST", "/positions", senior, http.StatusCreated},
+		{"create without bands", "POST", "/positions", `{"code":"ENG-1","title":"Engineer","level":1}`, http.StatusCreated},
+		{"duplicate code", "POST", "/positions", senior, http.StatusConflict},
+		{"invalid", "POST", "/positions", `{"code":"","title":" ","level":-1,"bands":[{"currency":"XYZ","min":1,"mid":2,"max":3},{"currency":"USD","min":0,"mid":2.001,"max":3},{"currency":"EUR","min":3,"mid":2,"max":1},{"currency":"eur","min":1,"mid":2,"max":3}]}`, http.StatusBadRequest},
+		{"get", "GET", "/positions/1", "", http.StatusOK},
+		{"list", "GET", "/positions", "", http.StatusOK},
+		{"hire in band", "POST", "/employees", `{"name":"John Doe","position_id":1,"salary":100000}`, http.StatusCreated},
+		{"hire above band", "POST", "/employees", `{"name":"Jane Doe","position_id":1,"salary":150000}`, http.StatusUnprocessableEntity},
+		{"hire above band with override", "POST", "/employees", `{"name":"Jane Doe","position_id":1,"salary":150000,"band_override_reason":"Competing offer"}`, http.StatusCreated},
+		{"hire into missing position", "POST", "/employees", `{"name":"Jim Doe","position_id":9,"salary":100000}`, http.StatusUnprocessableEntity},
+		{"hire without any position", "POST", "/employees", `{"name":"Jim Doe","salary":100000}`, http.StatusBadRequest},
+		{"raise above band", "PATCH", "/employees/1", `{"salary":140000}`, http.StatusUnprocessableEntity},
+		{"raise above band with override", "PATCH", "/employees/1", `{"salary":140000,"band_override_reason":"Retention"}`, http.StatusOK},
+		{"update to position without bands", "PUT", "/employees/2", `{"name":"Jane Doe","position_id":2,"salary":150000}`, http.StatusOK},
+		{"retitle", "PUT", "/positions/2", `{"code":"ENG-1","title":"Associate Engineer","level":1}`, http.StatusOK},
+		{"update missing", "PUT", "/positions/9", `{"code":"X","title":"X"}`, http.StatusNotFound},
+		{"delete position in use", "DELETE", "/positions/2", "", http.StatusConflict},
+		{"delete missing", "DELETE", "/positions/9", "", http.StatusNotFound},
+		{"invalid id", "GET", "/positions/abc", "", http.StatusBadRequest},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter()
+			for _, before := range tests[:i] {
+				r.ServeHTTP(httptest.NewRecorder(), request(before))
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request(tt))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
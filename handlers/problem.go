@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem types, identifying the kind of error independently of the
+// human-readable title and detail.
+const (
+	ProblemTypeInvalidPayload       = "urn:employeemanager:problem:invalid-payload"
+	ProblemTypeValidation           = "urn:employeemanager:problem:validation"
+	ProblemTypeUnsupportedMediaType = "urn:employeemanager:problem:unsupported-media-type"
+	ProblemTypeNotAcceptable        = "urn:employeemanager:problem:not-acceptable"
+	ProblemTypeUnprocessablePatch   = "urn:employeemanager:problem:unprocessable-patch"
+	ProblemTypeForbidden            = "urn:employeemanager:problem:forbidden"
+	ProblemTypeNotFound             = "urn:employeemanager:problem:not-found"
+	ProblemTypeConflict             = "urn:employeemanager:problem:conflict"
+	ProblemTypePreconditionFailed   = "urn:employeemanager:problem:precondition-failed"
+	ProblemTypeConstraint           = "urn:employeemanager:problem:constraint-violation"
+	ProblemTypeImportRejected       = "urn:employeemanager:problem:import-rejected"
+	ProblemTypeClientClosed         = "urn:employeemanager:problem:client-closed-request"
+	ProblemTypeServiceUnavailable   = "urn:employeemanager:problem:service-unavailable"
+	ProblemTypeInternal             = "urn:employeemanager:problem:internal"
+)
+
+// Problem is an RFC 7807 problem details object, returned as the body of
+// every error response.
+type Problem struct {
+	Type      string       `json:"type" example:"urn:employeemanager:problem:validation"`
+	Title     string       `json:"title" example:"Validation failed"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"The request has 2 invalid fields"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/employees"`
+	RequestID string       `json:"request_id,omitempty" example:"host/abc123-000001"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Rows lists the invalid rows of a rejected import.
+	Rows []ImportRowError `json:"rows,omitempty"`
+}
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field" example:"salary"`
+	Message string `json:"message" example:"must be greater than zero"`
+	err     error
+}
+
+// ValidationError lists every invalid field of a request.
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	msgs := make([]string, len(v))
+	for i, f := range v {
+		msgs[i] = f.err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the sentinel error of each field, so that
+// errors.Is(err, ErrInvalidSalary) reports whether the salary was invalid.
+func (v ValidationError) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, f := range v {
+		errs[i] = f.err
+	}
+	return errs
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+func writeInvalidPayload(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, Problem{
+		Type:   ProblemTypeInvalidPayload,
+		Status: http.StatusBadRequest,
+		Detail: "Invalid request payload",
+	})
+}
+
+func writeInvalidID(w http.ResponseWriter, r *http.Request) {
+	writeValidationError(w, r, ValidationError{
+		{Field: "id", Message: "must be a positive integer", err: ErrInvalidID},
+	})
+}
+
+func writeValidationError(w http.ResponseWriter, r *http.Request, err ValidationError) {
+	detail := "The request has 1 invalid field"
+	if len(err) != 1 {
+		detail = "The request has " + strconv.Itoa(len(err)) + " invalid fields"
+	}
+	writeProblem(w, r, Problem{
+		Type:   ProblemTypeValidation,
+		Title:  "Validation failed",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Errors: err,
+	})
+}
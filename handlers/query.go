@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// Page sizes of ListEmployeesHandler.
+const (
+	DefaultPerPage = 10
+	MaxPerPage     = 100
+)
+
+// listParams are the query parameters a cursor replaces.
+var listParams = []string{"page", "name", "position", "q", "currency", "salary_min", "salary_max", "sort", "department_id", "include_deleted", "as_of"}
+
+// listRequest is a parsed request to ListEmployeesHandler.
+type listRequest struct {
+	listing
+	// page selects offset paging when non-zero; otherwise pages are read
+	// by keyset, starting from the cursor if there is one.
+	page      int
+	perPage   int
+	skipTotal bool
+}
+
+func (l listRequest) query() database.ListQuery {
+	q := l.listing.query(l.perPage)
+	if l.page != 0 {
+		q.Page = l.page
+	}
+	q.SkipTotal = l.skipTotal
+	return q
+}
+
+// parseListRequest reads the query parameters of ListEmployeesHandler. Bad page
+// numbers fall back to the defaults and page sizes are capped at
+// MaxPerPage; bad filters, sorts and cursors are reported.
+func (h *handler) parseListRequest(r *http.Request) (listRequest, ValidationError) {
+	params := r.URL.Query()
+	req := listRequest{perPage: DefaultPerPage}
+	if perPage, err := strconv.Atoi(params.Get("per_page")); err == nil && perPage > 0 {
+		req.perPage = min(perPage, MaxPerPage)
+	}
+
+	var errs ValidationError
+	if total := params.Get("total"); total != "" {
+		withTotal, err := strconv.ParseBool(total)
+		if err != nil {
+			errs = append(errs, FieldError{Field: "total", Message: "must be true or false", err: ErrInvalidFilter})
+		}
+		req.skipTotal = !withTotal
+	}
+
+	if token := params.Get("cursor"); token != "" {
+		for _, param := range listParams {
+			if params.Has(param) {
+				return req, append(errs, FieldError{Field: "cursor", Message: "must not be combined with page, sort or filters, which the cursor carries", err: ErrInvalidCursor})
+			}
+		}
+		l, err := h.decodeCursor(token)
+		if err != nil {
+			return req, append(errs, FieldError{Field: "cursor", Message: "must be a cursor returned by this API", err: ErrInvalidCursor})
+		}
+		req.listing = l
+		return req, errs
+	}
+
+	if params.Has("page") {
+		req.page = 1
+		if page, err := strconv.Atoi(params.Get("page")); err == nil && page > 0 {
+			req.page = page
+		}
+	}
+	l, listErrs := parseListing(params)
+	req.listing = l
+	return req, append(errs, listErrs...)
+}
+
+// parseListing reads the filters and sort of the employee listings.
+func parseListing(params url.Values) (listing, ValidationError) {
+	var errs ValidationError
+	l := listing{
+		Name:     params.Get("name"),
+		Position: params.Get("position"),
+		Search:   params.Get("q"),
+		Currency: strings.ToUpper(params.Get("currency")),
+		Sort:     params.Get("sort"),
+	}
+	if department := params.Get("department_id"); department != "" {
+		var err error
+		if l.DepartmentID, err = strconv.Atoi(department); err != nil || l.DepartmentID <= 0 {
+			errs = append(errs, FieldError{Field: "department_id", Message: "must be a positive integer", err: ErrInvalidFilter})
+		}
+	}
+	if include := params.Get("include_deleted"); include != "" {
+		var err error
+		if l.IncludeDeleted, err = strconv.ParseBool(include); err != nil {
+			errs = append(errs, FieldError{Field: "include_deleted", Message: "must be true or false", err: ErrInvalidFilter})
+		}
+	}
+	if asOf, asOfErrs := parseAsOf(params); asOfErrs != nil {
+		errs = append(errs, asOfErrs...)
+	} else if !asOf.IsZero() {
+		l.AsOf = &asOf
+	}
+	if _, err := database.ParseSort(l.Sort); err != nil {
+		errs = append(errs, FieldError{Field: "sort", Message: "must list fields among id, name, position and salary, each optionally prefixed with -", err: ErrInvalidSort})
+	}
+
+	if l.Currency != "" {
+		if _, ok := database.CurrencyExponent(l.Currency); !ok {
+			errs = append(errs, FieldError{Field: "currency", Message: "must be a supported ISO 4217 currency code", err: ErrInvalidCurrency})
+			return l, errs
+		}
+	}
+	for _, bound := range []struct {
+		param string
+		dest  **int64
+	}{{"salary_min", &l.MinSalary}, {"salary_max", &l.MaxSalary}} {
+		value := params.Get(bound.param)
+		if value == "" {
+			continue
+		}
+		l.Currency = cmp.Or(l.Currency, database.DefaultCurrency)
+		salary, err := database.ParseMoney(value, l.Currency)
+		if err != nil {
+			exp, _ := database.CurrencyExponent(l.Currency)
+			msg := fmt.Sprintf("must be a decimal number with at most %d decimal places for %s", exp, l.Currency)
+			errs = append(errs, FieldError{Field: bound.param, Message: msg, err: ErrInvalidFilter})
+			continue
+		}
+		*bound.dest = &salary.Amount
+	}
+	return l, errs
+}
+
+// ParseListQuery reads the filters and sort of the employee listings from
+// params, named as in the query of ListEmployeesHandler, into a query for
+// every matching employee. Its error is a ValidationError.
+func ParseListQuery(params url.Values) (database.ListQuery, error) {
+	l, errs := parseListing(params)
+	if errs != nil {
+		return database.ListQuery{}, errs
+	}
+	return l.query(0), nil
+}
+
+// parseAsOf reads the as_of parameter of the employee endpoints, which is
+// the zero time when absent.
+func parseAsOf(params url.Values) (time.Time, ValidationError) {
+	value := params.Get("as_of")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, ValidationError{{Field: "as_of", Message: "must be an RFC 3339 time", err: ErrInvalidFilter}}
+	}
+	return asOf, nil
+}
+
+// links returns the cursors of the pages around page, and the RFC 8288 Link
+// header pointing at them. Offset pages link to the neighbouring page
+// numbers instead of cursors.
+func (h *handler) links(r *http.Request, req listRequest, page database.EmployeePage) (next, prev, link string) {
+	var links []string
+	add := func(rel string, params url.Values) {
+		target := r.URL.Path
+		if len(params) > 0 {
+			target += "?" + params.Encode()
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, target, rel))
+	}
+	keep := func(params url.Values) url.Values {
+		for _, param := range []string{"per_page", "total"} {
+			if value := r.URL.Query().Get(param); value != "" {
+				params.Set(param, value)
+			}
+		}
+		return params
+	}
+
+	if req.page != 0 {
+		withPage := func(n int) url.Values {
+			params := r.URL.Query()
+			params.Set("page", strconv.Itoa(n))
+			return params
+		}
+		add("first", withPage(1))
+		if page.More {
+			add("next", withPage(req.page+1))
+		}
+		if req.page > 1 {
+			add("prev", withPage(req.page-1))
+		}
+		return "", "", strings.Join(links, ", ")
+	}
+
+	first := r.URL.Query()
+	if first.Has("cursor") {
+		// The first page is the listing with its filters and no key.
+		first = url.Values{}
+		for param, value := range map[string]string{
+			"name": req.Name, "position": req.Position, "q": req.Search, "currency": req.Currency, "sort": req.Sort,
+		} {
+			if value != "" {
+				first.Set(param, value)
+			}
+		}
+		for param, bound := range map[string]*int64{"salary_min": req.MinSalary, "salary_max": req.MaxSalary} {
+			if bound != nil {
+				first.Set(param, database.Money{Amount: *bound, Currency: req.Currency}.String())
+			}
+		}
+		if req.DepartmentID != 0 {
+			first.Set("department_id", strconv.Itoa(req.DepartmentID))
+		}
+		if req.IncludeDeleted {
+			first.Set("include_deleted", "true")
+		}
+		if req.AsOf != nil {
+			first.Set("as_of", req.AsOf.Format(time.RFC3339Nano))
+		}
+		keep(first)
+	}
+	add("first", first)
+
+	// A Before page has more employees before it, and any other page after it.
+	if n := len(page.Employees); n > 0 {
+		if (req.Before && req.Key != nil) || (!req.Before && page.More) {
+			next = h.encodeCursor(req.continueFrom(page.Employees[n-1], false))
+			add("next", keep(url.Values{"cursor": {next}}))
+		}
+		if req.Key != nil && (!req.Before || page.More) {
+			prev = h.encodeCursor(req.continueFrom(page.Employees[0], true))
+			add("prev", keep(url.Values{"cursor": {prev}}))
+		}
+	}
+	return next, prev, strings.Join(links, ", ")
+}
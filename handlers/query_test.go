@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestListEmployeesHandlerQuery(t *testing.T) {
+	edb := database.NewMemoryEmployee()
+	for _, emp := range []database.Employee{
+		{Name: "Alice Smith", Position: "Engineer", Salary: database.Money{Amount: 9000000, Currency: "USD"}},
+		{Name: "Bob Jones", Position: "Manager", Salary: database.Money{Amount: 12000000, Currency: "USD"}},
+		{Name: "Alan Turing", Position: "Engineer", Salary: database.Money{Amount: 15000000, Currency: "USD"}},
+		{Name: "Dai Tanaka", Position: "Engineer", Salary: database.Money{Amount: 9000000, Currency: "JPY"}},
+	} {
+		if _, err := edb.CreateEmployee(context.Background(), emp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{
+			name:           "engineers over a salary by salary descending",
+			query:          "position=engineer&salary_min=100000&sort=-salary",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "name prefix sorted by name",
+			query:          "name=al&sort=name",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "search in yen",
+			query:          "q=engineer&currency=jpy",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown sort field",
+			query:          "sort=-salary,password",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid salary bounds",
+			query:          "salary_min=lots&salary_max=100.001",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown currency",
+			query:          "currency=XYZ&salary_min=100",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(edb)
+
+			r := chi.NewRouter()
+			r.Get("/employees", h.ListEmployeesHandler)
+
+			req, _ := http.NewRequest("GET", "/employees?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
+
+func TestAsOfQuery(t *testing.T) {
+	ctx := context.Background()
+	edb := database.NewMemoryEmployee()
+	for _, name := range []string{"John Doe", "Jane Doe"} {
+		if _, err := edb.CreateEmployee(ctx, database.Employee{Name: name, Position: "Engineer", Salary: database.Money{Amount: 5000000, Currency: "USD"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	hired := time.Now()
+	time.Sleep(time.Millisecond)
+	position := "Manager"
+	for id := 1; id <= 2; id++ {
+		if _, err := edb.PatchEmployee(ctx, id, database.EmployeeChanges{Position: &position}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	asOf := url.QueryEscape(hired.Format(time.RFC3339Nano))
+
+	h := NewHandler(edb)
+	r := chi.NewRouter()
+	r.Get("/employees", h.ListEmployeesHandler)
+	r.Get("/employees/{id}", h.GetEmployeeHandler)
+	get := func(t *testing.T, path string, expectedStatus int, response any) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != expectedStatus {
+			t.Fatalf("GET %s returned %d, want %d: %s", path, rr.Code, expectedStatus, rr.Body)
+		}
+		if response != nil {
+			if err := json.Unmarshal(rr.Body.Bytes(), response); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("get", func(t *testing.T) {
+		var employee EmployeeResponse
+		get(t, "/employees/1?as_of="+asOf, http.StatusOK, &employee)
+		if employee.Position != "Engineer" {
+			t.Errorf("employee as of the hire = %+v, want an engineer", employee)
+		}
+		get(t, "/employees/1?as_of=2000-01-01T00:00:00Z", http.StatusNotFound, nil)
+	})
+
+	t.Run("list follows cursors at the same time", func(t *testing.T) {
+		var first, next ListEmployeesResponse
+		get(t, "/employees?per_page=1&position=engineer&as_of="+asOf, http.StatusOK, &first)
+		get(t, "/employees?per_page=1&cursor="+first.NextCursor, http.StatusOK, &next)
+		if len(first.Employees) != 1 || len(next.Employees) != 1 || next.Employees[0].Name != "Jane Doe" || *next.Total != 2 {
+			t.Errorf("pages as of the hire = %+v, %+v, want both engineers", first, next)
+		}
+	})
+
+	for name, path := range map[string]string{
+		"invalid time in list": "/employees?as_of=yesterday",
+		"invalid time in get":  "/employees/1?as_of=yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/spreadsheet"
+	"gopkg.in/yaml.v3"
+)
+
+// Media types employees are read and written in, besides CSV.
+const (
+	JSONContentType = "application/json"
+	XMLContentType  = "application/xml"
+	YAMLContentType = "application/yaml"
+)
+
+// representations are the media types employees are rendered in, by
+// preference, and the format of each. Aliases come after the type they
+// stand for, so that a client accepting anything gets the canonical one.
+var representations = []struct {
+	mediaType, format string
+}{
+	{JSONContentType, "json"},
+	{XMLContentType, "xml"},
+	{"text/xml", "xml"},
+	{spreadsheet.CSVContentType, "csv"},
+	{YAMLContentType, "yaml"},
+	{"application/x-yaml", "yaml"},
+	{"text/yaml", "yaml"},
+}
+
+// tabular is implemented by the responses that can be rendered as CSV.
+type tabular interface {
+	rows() [][]any
+}
+
+// negotiate returns the representation the Accept header of r prefers, as
+// RFC 9110 has it: each type takes the quality of the most specific range
+// matching it, and ties go to the order of representations. Without an
+// Accept header it is JSON; it is "" when nothing is acceptable.
+func negotiate(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return JSONContentType
+	}
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, rep := range representations {
+		q, specificity := 0.0, -1
+		for _, rg := range ranges {
+			s := -1
+			switch {
+			case rg.mediaType == rep.mediaType:
+				s = 2
+			case strings.HasSuffix(rg.mediaType, "/*") && strings.HasPrefix(rep.mediaType, strings.TrimSuffix(rg.mediaType, "*")):
+				s = 1
+			case rg.mediaType == "*/*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = rg.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = rep.mediaType, q
+		}
+	}
+	return best
+}
+
+// formatOf returns the format of a media type of representations.
+func formatOf(mediaType string) string {
+	for _, rep := range representations {
+		if rep.mediaType == mediaType {
+			return rep.format
+		}
+	}
+	return ""
+}
+
+func writeNotAcceptable(w http.ResponseWriter, r *http.Request) {
+	types := make([]string, len(representations))
+	for i, rep := range representations {
+		types[i] = rep.mediaType
+	}
+	writeProblem(w, r, Problem{
+		Type:   ProblemTypeNotAcceptable,
+		Status: http.StatusNotAcceptable,
+		Detail: "Accept one of " + strings.Join(types, ", "),
+	})
+}
+
+// render writes v with status in mediaType, one of representations. Only
+// tabular values can be rendered as CSV.
+func render(w http.ResponseWriter, status int, mediaType string, v any) {
+	w.Header().Add("Vary", "Accept")
+	contentType := mediaType
+	if strings.HasPrefix(mediaType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	switch formatOf(mediaType) {
+	case "xml":
+		io.WriteString(w, xml.Header)
+		enc := xml.NewEncoder(w)
+		enc.Encode(v)
+		io.WriteString(w, "\n")
+	case "csv":
+		out := spreadsheet.NewCSVWriter(w)
+		for _, row := range v.(tabular).rows() {
+			out.WriteRow(row)
+		}
+		out.Close()
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		enc.Encode(v)
+		enc.Close()
+	default:
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+var (
+	// errUnsupportedBody is returned for a body in a media type that
+	// decodeEmployeeParams does not read.
+	errUnsupportedBody = errors.New("unsupported body media type")
+	// errMalformedBody is returned for a body that is not valid in its
+	// media type.
+	errMalformedBody = errors.New("malformed body")
+)
+
+// decodeEmployeeParams reads the body of r as the media type of its
+// Content-Type: JSON, XML, YAML or CSV, the latter being a header and a
+// single row named like the columns of an import. A body without a type is
+// JSON; any other type, forms included, is unsupported.
+func decodeEmployeeParams(r *http.Request) (EmployeeParams, error) {
+	var params EmployeeParams
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var err error
+	switch mediaType {
+	case "", JSONContentType:
+		err = json.NewDecoder(r.Body).Decode(&params)
+	case XMLContentType, "text/xml":
+		err = xml.NewDecoder(r.Body).Decode(&params)
+	case YAMLContentType, "application/x-yaml", "text/yaml":
+		err = yaml.NewDecoder(r.Body).Decode(&params)
+	case spreadsheet.CSVContentType:
+		return decodeCSVParams(r.Body)
+	default:
+		return params, errUnsupportedBody
+	}
+	if err != nil {
+		return params, errMalformedBody
+	}
+	return params, nil
+}
+
+// decodeCSVParams reads the params of one employee from CSV with a header
+// row, whose cells are read and validated as those of an import.
+func decodeCSVParams(body io.Reader) (EmployeeParams, error) {
+	records, err := spreadsheet.ReadCSV(body)
+	if err != nil {
+		return EmployeeParams{}, errMalformedBody
+	}
+	header, rows := splitImport(records)
+	if len(rows) != 1 {
+		return EmployeeParams{}, errMalformedBody
+	}
+	columns, _, errs := mapHeader(header, nil)
+	if errs != nil {
+		return EmployeeParams{}, errs
+	}
+	row := rows[0]
+	if errs := row.read(columns); errs != nil {
+		return EmployeeParams{}, errs
+	}
+	return row.params, nil
+}
+
+// writeBodyError writes the problem response for an error of
+// decodeEmployeeParams.
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var errs ValidationError
+	switch {
+	case errors.As(err, &errs):
+		writeValidationError(w, r, errs)
+	case errors.Is(err, errUnsupportedBody):
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeUnsupportedMediaType,
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Send the employee as " + strings.Join([]string{JSONContentType, XMLContentType, YAMLContentType, spreadsheet.CSVContentType}, ", "),
+		})
+	default:
+		writeInvalidPayload(w, r)
+	}
+}
+
+// rows returns the employee as a CSV header and a row with every field.
+func (e EmployeeResponse) rows() [][]any {
+	return [][]any{csvHeader(), e.values(exportFields)}
+}
+
+// rows returns the employees of the page as a CSV header and a row each,
+// with every field. The neighbouring pages are left to the Link header.
+func (l ListEmployeesResponse) rows() [][]any {
+	rows := [][]any{csvHeader()}
+	for _, e := range l.Employees {
+		rows = append(rows, e.values(exportFields))
+	}
+	return rows
+}
+
+func csvHeader() []any {
+	header := make([]any, len(exportFields))
+	for i, field := range exportFields {
+		header[i] = field
+	}
+	return header
+}
+
+// MarshalYAML writes the salary as a number, which as a json.Number it
+// would not be, with the decimals of its currency.
+func (e EmployeeResponse) MarshalYAML() (any, error) {
+	type plain EmployeeResponse
+	var node yaml.Node
+	if err := node.Encode(plain(e)); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "salary" {
+			salary := node.Content[i+1]
+			salary.Tag, salary.Style = "!!int", 0
+			if strings.Contains(salary.Value, ".") {
+				salary.Tag = "!!float"
+			}
+		}
+	}
+	return &node, nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", JSONContentType},
+		{"*/*", JSONContentType},
+		{"application/xml", XMLContentType},
+		{"text/xml", "text/xml"},
+		{"text/csv", "text/csv"},
+		{"application/yaml;q=0.9, text/csv;q=0.5", YAMLContentType},
+		{"text/*", "text/xml"},
+		{"text/*, text/xml;q=0", "text/csv"},
+		{"*/*;q=0.1, application/json;q=0", XMLContentType},
+		{"application/xml, application/json", JSONContentType},
+		{"text/html", ""},
+		{"application/json;q=0", ""},
+		{"application/json;q=2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			if got := negotiate(req); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	edb := database.NewMemoryEmployee()
+	ctx := context.Background()
+	for _, employee := range []database.Employee{
+		{Name: "Ada Lovelace", Position: "CTO", Salary: database.Money{Amount: 15000075, Currency: "USD"}},
+		{Name: "Grace <Hopper> & co", Position: "Engineer", Salary: database.Money{Amount: 900000000, Currency: "JPY"}, ManagerID: 1},
+	} {
+		if _, err := edb.CreateEmployee(ctx, employee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewHandler(edb, WithCursorSecret([]byte("secret")))
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Get("/employees", h.ListEmployeesHandler)
+	r.Post("/employees", h.CreateEmployeeHandler)
+	r.Get("/employees/{id}", h.GetEmployeeHandler)
+	r.Put("/employees/{id}", h.UpdateEmployeeHandler)
+
+	tests := []struct {
+		name           string
+		method, path   string
+		accept         string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{"get as xml", "GET", "/employees/2", "application/xml", "", "", http.StatusOK},
+		{"get as csv", "GET", "/employees/2", "text/csv", "", "", http.StatusOK},
+		{"get as yaml", "GET", "/employees/1", "application/yaml", "", "", http.StatusOK},
+		{"get not acceptable", "GET", "/employees/1", "text/html", "", "", http.StatusNotAcceptable},
+		{"list as xml", "GET", "/employees?per_page=1", "application/xml", "", "", http.StatusOK},
+		{"list as csv", "GET", "/employees", "text/csv", "", "", http.StatusOK},
+		{"list as yaml", "GET", "/employees?total=false", "application/x-yaml", "", "", http.StatusOK},
+		{"list not acceptable", "GET", "/employees", "application/pdf", "", "", http.StatusNotAcceptable},
+		{"create from xml", "POST", "/employees", "application/xml", "application/xml",
+			`<employee><name>Alan Turing</name><position>Engineer</position><salary>70000.50</salary><manager_id>1</manager_id></employee>`, http.StatusCreated},
+		{"create from yaml", "POST", "/employees", "", "application/yaml",
+			"name: Edsger Dijkstra\nposition: Engineer\nsalary: 65000.25\ncurrency: EUR\n", http.StatusCreated},
+		{"create from csv", "POST", "/employees", "text/csv", "text/csv; charset=utf-8",
+			"Full Name,Title,Salary,Manager\nBarbara Liskov,Engineer,72000,1\n", http.StatusCreated},
+		{"create from invalid csv", "POST", "/employees", "", "text/csv",
+			"name,position,salary,manager_id\n,Engineer,12.345,x\n", http.StatusBadRequest},
+		{"create from csv with two rows", "POST", "/employees", "", "text/csv",
+			"name,position,salary\nA,B,1\nC,D,2\n", http.StatusBadRequest},
+		{"create from malformed xml", "POST", "/employees", "", "application/xml", `<employee><name>`, http.StatusBadRequest},
+		{"create from unsupported type", "POST", "/employees", "", "application/pdf", `%PDF`, http.StatusUnsupportedMediaType},
+		{"create from form", "POST", "/employees", "", "application/x-www-form-urlencoded",
+			`{"name":"Nobody","position":"Engineer","salary":1}`, http.StatusUnsupportedMediaType},
+		{"create not acceptable", "POST", "/employees", "text/html", "application/json",
+			`{"name":"Nobody","position":"Engineer","salary":1}`, http.StatusNotAcceptable},
+		{"update from yaml as xml", "PUT", "/employees/1", "application/xml", "text/yaml",
+			"name: Ada King\nposition: CTO\nsalary: 160000\n", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(middleware.RequestIDHeader, "render")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+
+	t.Run("not acceptable creates nothing", func(t *testing.T) {
+		page, err := edb.ListEmployees(ctx, database.ListQuery{PerPage: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, employee := range page.Employees {
+			if employee.Name == "Nobody" {
+				t.Errorf("employee created despite 406: %+v", employee)
+			}
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+var (
+	ErrInvalidDate   = errors.New("invalid date")
+	ErrInvalidReason = errors.New("invalid reason")
+)
+
+// Statuses of a salary change relative to today.
+const (
+	SalaryStatusPast      = "past"
+	SalaryStatusCurrent   = "current"
+	SalaryStatusScheduled = "scheduled"
+)
+
+// SalaryChangeParams defines the body of AddSalaryChangeHandler.
+type SalaryChangeParams struct {
+	Salary   json.Number `json:"salary" swaggertype:"number" example:"55000"`
+	Currency string      `json:"currency,omitempty" example:"USD"`
+	// EffectiveFrom is the date the salary takes effect, today if omitted.
+	EffectiveFrom string `json:"effective_from,omitempty" example:"2024-07-01"`
+	Reason        string `json:"reason" example:"promotion" enums:"hire,promotion,merit,adjustment,correction,demotion,restructure"`
+	// BandOverrideReason lets the salary be outside the band of the
+	// position. It is kept with the change, and the band is checked again
+	// with it when a scheduled change takes effect.
+	BandOverrideReason string `json:"band_override_reason,omitempty" example:"Retention offer"`
+}
+
+func (p SalaryChangeParams) validate() ValidationError {
+	errs := validateSalary(p.Salary, p.Currency)
+	if p.EffectiveFrom != "" {
+		if _, err := time.Parse(time.DateOnly, p.EffectiveFrom); err != nil {
+			errs = append(errs, FieldError{Field: "effective_from", Message: "must be a date such as 2024-07-01", err: ErrInvalidDate})
+		}
+	}
+	if !slices.Contains(database.SalaryReasons, p.Reason) {
+		errs = append(errs, FieldError{Field: "reason", Message: "must be one of " + strings.Join(database.SalaryReasons, ", "), err: ErrInvalidReason})
+	}
+	if len(p.BandOverrideReason) > maxOverrideReason {
+		msg := fmt.Sprintf("must be at most %d bytes", maxOverrideReason)
+		errs = append(errs, FieldError{Field: "band_override_reason", Message: msg, err: ErrInvalidOverride})
+	}
+	return errs
+}
+
+// context returns ctx with the band override reason of p, if it has one.
+func (p SalaryChangeParams) context(ctx context.Context) context.Context {
+	return EmployeeParams{BandOverrideReason: p.BandOverrideReason}.Context(ctx)
+}
+
+func (p SalaryChangeParams) toSalaryChange(employeeID int) database.SalaryChange {
+	salary, _ := parseSalary(p.Salary, p.Currency)
+	effective := time.Now()
+	if p.EffectiveFrom != "" {
+		effective, _ = time.Parse(time.DateOnly, p.EffectiveFrom)
+	}
+	return database.SalaryChange{
+		EmployeeID:    employeeID,
+		Salary:        salary,
+		EffectiveFrom: database.Date(effective),
+		Reason:        p.Reason,
+	}
+}
+
+// SalaryChangeResponse is one entry of the salary history of an employee.
+type SalaryChangeResponse struct {
+	ID            int64       `json:"id"`
+	Salary        json.Number `json:"salary" swaggertype:"number" example:"55000.00"`
+	Currency      string      `json:"currency" example:"USD"`
+	EffectiveFrom string      `json:"effective_from" example:"2024-07-01"`
+	Reason        string      `json:"reason" example:"promotion"`
+	// BandOverrideReason is the reason the salary may be outside the band
+	// of the position, if it needed one.
+	BandOverrideReason string `json:"band_override_reason,omitempty" example:"Retention offer"`
+	// Status tells whether the salary was paid in the past, is paid today
+	// or is scheduled to take effect.
+	Status    string    `json:"status" enums:"past,current,scheduled"`
+	CreatedBy string    `json:"created_by,omitempty" example:"jane"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SalaryHistoryResponse struct {
+	Changes []SalaryChangeResponse `json:"changes"`
+}
+
+// ListSalaryChangesHandler godoc
+// @Summary List the salary history of an employee
+// @Description List every salary of an employee by the date it takes effect, including scheduled changes. The current
+// @Description salary is the latest one effective today.
+// @Tags salary
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Success 200 {object} SalaryHistoryResponse
+// @Failure 400 {object} Problem "Invalid employee ID"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/salary [get]
+func (h *handler) ListSalaryChangesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	changes, err := h.emp.ListSalaryChanges(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SalaryHistoryResponse{Changes: toSalaryHistory(changes)})
+}
+
+// AddSalaryChangeHandler godoc
+// @Summary Change the salary of an employee
+// @Description Add a salary to the history of an employee. A salary effective today or earlier becomes the salary of the
+// @Description employee unless a later one is already in effect; a later one is scheduled and takes effect on its date.
+// @Description The salary must be within the band of the position of the employee unless a band_override_reason is
+// @Description given, both now and when the change takes effect.
+// @Tags salary
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path int true "Employee ID"
+// @Param body body SalaryChangeParams true "Salary change"
+// @Success 201 {object} SalaryChangeResponse
+// @Failure 400 {object} Problem "Invalid request payload"
+// @Failure 404 {object} Problem "Employee not found"
+// @Failure 409 {object} Problem "The employee already has a salary change effective that day"
+// @Failure 422 {object} Problem "The salary is outside the band of the position"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 503 {object} Problem "Service unavailable"
+// @Router /employees/{id}/salary [post]
+func (h *handler) AddSalaryChangeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		writeInvalidID(w, r)
+		return
+	}
+	var params SalaryChangeParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeInvalidPayload(w, r)
+		return
+	}
+	if errs := params.validate(); errs != nil {
+		writeValidationError(w, r, errs)
+		return
+	}
+	change, err := h.emp.AddSalaryChange(params.context(r.Context()), params.toSalaryChange(id))
+	if errors.Is(err, database.ErrConflict) {
+		writeProblem(w, r, Problem{Type: ProblemTypeConflict, Status: http.StatusConflict, Detail: "The employee already has a salary change effective that day"})
+		return
+	}
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	// The status of the change depends on the rest of the history.
+	changes, err := h.emp.ListSalaryChanges(r.Context(), id)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	history := toSalaryHistory(changes)
+	i := slices.IndexFunc(history, func(c SalaryChangeResponse) bool { return c.ID == change.ID })
+	if i < 0 {
+		// Changed again in the meantime.
+		writeDBError(w, r, database.ErrConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(history[i])
+}
+
+// toSalaryHistory converts a salary history sorted by effective date, telling
+// which of its salaries is current.
+func toSalaryHistory(changes []database.SalaryChange) []SalaryChangeResponse {
+	today := database.Date(time.Now())
+	history := make([]SalaryChangeResponse, len(changes))
+	for i, change := range changes {
+		history[i] = SalaryChangeResponse{
+			ID:                 change.ID,
+			Salary:             json.Number(change.Salary.String()),
+			Currency:           change.Salary.Currency,
+			EffectiveFrom:      change.EffectiveFrom.Format(time.DateOnly),
+			Reason:             change.Reason,
+			BandOverrideReason: change.BandOverride,
+			Status:             SalaryStatusPast,
+			CreatedBy:          change.CreatedBy,
+			CreatedAt:          change.CreatedAt,
+		}
+		switch {
+		case change.EffectiveFrom.After(today):
+			history[i].Status = SalaryStatusScheduled
+		case i == len(changes)-1 || changes[i+1].EffectiveFrom.After(today):
+			history[i].Status = SalaryStatusCurrent
+		}
+	}
+	return history
+}
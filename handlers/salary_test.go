@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestSalaryHandlers(t *testing.T) {
+	newRouter := func(t *testing.T) http.Handler {
+		t.Helper()
+		edb := database.NewMemoryEmployee()
+		h := NewHandler(edb)
+		r := chi.NewRouter()
+		r.Use(middleware.RequestID)
+		r.Use(IdentifyActor)
+		r.Post("/employees", h.CreateEmployeeHandler)
+		r.Get("/employees/{id}", h.GetEmployeeHandler)
+		r.Get("/employees/{id}/salary", h.ListSalaryChangesHandler)
+		r.Post("/employees/{id}/salary", h.AddSalaryChangeHandler)
+
+		req, _ := http.NewRequest("POST", "/employees", bytes.NewBufferString(`{"name":"John Doe","position":"Engineer","salary":50000}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("creating an employee returned %d: %s", rr.Code, rr.Body)
+		}
+		ctx := context.Background()
+		position, err := edb.CreatePosition(ctx, database.Position{Code: "ENG-1", Title: "Engineer", Bands: []database.SalaryBand{{Currency: "USD", Min: 4000000, Mid: 5000000, Max: 6000000}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := edb.CreateEmployee(ctx, database.Employee{Name: "Jane Doe", Salary: database.Money{Amount: 5000000, Currency: "USD"}, PositionID: position.ID}); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	type step struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		// The statuses of the returned changes; dates and times vary, so
+		// successful responses are checked field by field.
+		expectedChanges []string
+	}
+	request := func(tt step) *http.Request {
+		req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set(middleware.RequestIDHeader, "salary")
+		return req
+	}
+
+	// The steps build on each other: the hire is followed by a backdated
+	// change and a scheduled one. Each runs on a store of its own after
+	// the steps before it.
+	tests := []step{
+		{
+			name:            "add scheduled change",
+			method:          "POST",
+			path:            "/employees/1/salary",
+			body:            `{"salary":60000,"effective_from":"2999-01-01","reason":"promotion"}`,
+			expectedStatus:  http.StatusCreated,
+			expectedChanges: []string{SalaryStatusScheduled},
+		},
+		{
+			name:            "add backdated change",
+			method:          "POST",
+			path:            "/employees/1/salary",
+			body:            `{"salary":45000,"currency":"USD","effective_from":"2020-01-01","reason":"hire"}`,
+			expectedStatus:  http.StatusCreated,
+			expectedChanges: []string{SalaryStatusPast},
+		},
+		{
+			name:            "list",
+			method:          "GET",
+			path:            "/employees/1/salary",
+			expectedStatus:  http.StatusOK,
+			expectedChanges: []string{SalaryStatusPast, SalaryStatusCurrent, SalaryStatusScheduled},
+		},
+		{
+			name:           "add conflicting change",
+			method:         "POST",
+			path:           "/employees/1/salary",
+			body:           `{"salary":70000,"effective_from":"2999-01-01","reason":"merit"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid change",
+			method:         "POST",
+			path:           "/employees/1/salary",
+			body:           `{"salary":-1,"currency":"usd","effective_from":"01/01/2999","reason":"bonus"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "add change outside the band",
+			method:         "POST",
+			path:           "/employees/2/salary",
+			body:           `{"salary":90000,"effective_from":"2999-01-01","reason":"merit"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:            "add change outside the band with an override",
+			method:          "POST",
+			path:            "/employees/2/salary",
+			body:            `{"salary":90000,"effective_from":"2999-01-01","reason":"merit","band_override_reason":"Retention offer"}`,
+			expectedStatus:  http.StatusCreated,
+			expectedChanges: []string{SalaryStatusScheduled},
+		},
+		{
+			name:           "missing employee",
+			method:         "POST",
+			path:           "/employees/99/salary",
+			body:           `{"salary":60000,"reason":"merit"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid employee id",
+			method:         "GET",
+			path:           "/employees/abc/salary",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t)
+			for _, before := range tests[:i] {
+				r.ServeHTTP(httptest.NewRecorder(), request(before))
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request(tt))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.expectedStatus)
+			}
+			if rr.Code >= 300 {
+				res := rr.Result()
+				defer res.Body.Close()
+				cupaloy.SnapshotT(t, dumpResponse(t, res))
+				return
+			}
+
+			var changes []SalaryChangeResponse
+			if tt.method == "GET" {
+				var response SalaryHistoryResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				changes = response.Changes
+			} else {
+				var response SalaryChangeResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				changes = []SalaryChangeResponse{response}
+			}
+			var statuses []string
+			for _, change := range changes {
+				statuses = append(statuses, change.Status)
+				if tt.path == "/employees/2/salary" && change.BandOverrideReason != "Retention offer" {
+					t.Errorf("change %+v lacks the band override reason", change)
+				}
+				if change.ID == 0 || change.Currency != "USD" || change.CreatedAt.IsZero() {
+					t.Errorf("change %+v lacks an ID, currency or creation time", change)
+				}
+			}
+			if !slices.Equal(statuses, tt.expectedChanges) {
+				t.Errorf("returned changes %v, want %v", statuses, tt.expectedChanges)
+			}
+		})
+	}
+
+	// The scheduled change has not taken effect, so the hire is current.
+	r := newRouter(t)
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), request(tt))
+	}
+	req, _ := http.NewRequest("GET", "/employees/1", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	var employee EmployeeResponse
+	json.Unmarshal(rr.Body.Bytes(), &employee)
+	if employee.Salary != "50000.00" {
+		t.Errorf("salary = %s, want 50000.00", employee.Salary)
+	}
+}
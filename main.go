@@ -3,16 +3,22 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/graph"
 	"github.com/theluckiestsoul/employeemanager/handlers"
+	"github.com/theluckiestsoul/employeemanager/rpc"
+	"github.com/theluckiestsoul/employeemanager/scim"
 
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	_ "github.com/theluckiestsoul/employeemanager/docs"
@@ -33,43 +39,125 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	db, err := database.NewDatabase(cfg.DbURL)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg.DbURL, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "orgchart" {
+		if err := runOrgChart(context.Background(), cfg, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		if err := runVerifyAudit(context.Background(), cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	auditKey, err := signingKey(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
 
-	if err := database.Initialize(db); err != nil {
+	empDB, closeDB, err := openEmployeeDB(context.Background(), cfg)
+	if err != nil {
 		log.Fatal(err)
 	}
+	defer closeDB()
 
-	empDB := database.NewEmployee(db)
-
-	h := handlers.NewHandler(empDB)
+	var opts []handlers.Option
+	if cfg.CursorSecret != "" {
+		opts = append(opts, handlers.WithCursorSecret([]byte(cfg.CursorSecret)))
+	}
+	opts = append(opts, handlers.WithAdmins(cfg.Admins...))
+	h := handlers.NewHandler(empDB, opts...)
+	g := graph.NewHandler(empDB, graph.WithAdmins(cfg.Admins...))
+	scimOpts := []scim.Option{scim.WithToken(cfg.SCIMToken)}
+	if cfg.SCIMDefaultSalary != "" {
+		salary, err := database.ParseMoney(cfg.SCIMDefaultSalary, strings.ToUpper(cfg.SCIMDefaultCurrency))
+		if err != nil || salary.Amount <= 0 {
+			log.Fatalf("SCIM_DEFAULT_SALARY: want a positive amount in %s", cfg.SCIMDefaultCurrency)
+		}
+		scimOpts = append(scimOpts, scim.WithDefaultSalary(salary))
+	}
+	s := scim.NewHandler(empDB, scimOpts...)
 
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(handlers.IdentifyActor)
 
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
+	r.Get("/graphql", g.GraphiQLHandler)
+	r.Post("/graphql", g.QueryHandler)
+	r.Mount("/scim/v2", s.Routes())
 
 	r.Route("/api/v1/employees", func(r chi.Router) {
 		r.Post("/", h.CreateEmployeeHandler)
 		r.Get("/", h.ListEmployeesHandler)
+		r.Post("/import", h.ImportEmployeesHandler)
+		r.Get("/export", h.ExportEmployeesHandler)
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.GetEmployeeHandler)
 			r.Put("/", h.UpdateEmployeeHandler)
+			r.Patch("/", h.PatchEmployeeHandler)
 			r.Delete("/", h.DeleteEmployeeHandler)
+			r.Post("/restore", h.RestoreEmployeeHandler)
+			r.Get("/history", h.EmployeeHistoryHandler)
+			r.Get("/salary", h.ListSalaryChangesHandler)
+			r.Post("/salary", h.AddSalaryChangeHandler)
+			r.Get("/reports", h.ListDirectReportsHandler)
+			r.Get("/chain", h.ReportingChainHandler)
+			r.Get("/subtree", h.SubtreeHandler)
 		})
 	})
+	r.Route("/api/v1/departments", func(r chi.Router) {
+		r.Post("/", h.CreateDepartmentHandler)
+		r.Get("/", h.ListDepartmentsHandler)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.GetDepartmentHandler)
+			r.Put("/", h.UpdateDepartmentHandler)
+			r.Delete("/", h.DeleteDepartmentHandler)
+		})
+	})
+	r.Route("/api/v1/positions", func(r chi.Router) {
+		r.Post("/", h.CreatePositionHandler)
+		r.Get("/", h.ListPositionsHandler)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.GetPositionHandler)
+			r.Put("/", h.UpdatePositionHandler)
+			r.Delete("/", h.DeletePositionHandler)
+		})
+	})
+	r.Get("/api/v1/audit", h.ListAuditHandler)
+	r.Get("/api/v1/orgchart", h.OrgChartHandler)
+
+	// Requests derive their context from baseCtx, so cancelling it aborts
+	// the queries of any request still running when shutdown gives up.
+	baseCtx, cancelBase := context.WithCancelCause(context.Background())
+	defer cancelBase(nil)
+
+	if cfg.PurgeAfter > 0 && cfg.PurgeInterval > 0 {
+		go purgeDeleted(baseCtx, empDB, cfg.PurgeAfter, cfg.PurgeInterval)
+	}
+	if auditKey != nil && cfg.CheckpointInterval > 0 {
+		go checkpointAudit(baseCtx, empDB, auditKey, cfg.CheckpointInterval)
+	}
+	if cfg.SalaryApplyInterval > 0 {
+		go applySalaryChanges(baseCtx, empDB, cfg.SalaryApplyInterval)
+	}
 
 	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: r,
+		Addr:        ":" + cfg.Port,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	go func() {
@@ -79,6 +167,21 @@ func main() {
 		}
 	}()
 
+	grpcServer := rpc.NewServer(empDB, rpc.WithAdmins(cfg.Admins...), rpc.WithToken(cfg.GRPCToken))
+	go func() {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			log.Fatalf("Failed to start gRPC server: %v", err)
+		}
+		log.Printf("gRPC server started on port %s\n", cfg.GRPCPort)
+		if cfg.GRPCToken == "" {
+			log.Println("GRPC_TOKEN is not set: gRPC requests are not authenticated and have no actor")
+		}
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to start gRPC server: %v", err)
+		}
+	}()
+
 	sigs := make(chan os.Signal, 1)
 
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -86,9 +189,31 @@ func main() {
 	sig := <-sigs
 	log.Printf("Received signal: %s \n", sig)
 	log.Println("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Failed to shutdown server: %v", err)
-	}
+	// The servers shut down side by side, each with a grace period of its
+	// own, so that neither keeps accepting requests while the other drains.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := grpcServer.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown grace period expired, cancelled in-flight gRPC requests: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Shutdown grace period expired, cancelling in-flight requests: %v", err)
+			cancelBase(handlers.ErrServerShutdown)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(ctx); err != nil {
+				log.Fatalf("Failed to shutdown server: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
 }
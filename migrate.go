@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+const migrateUsage = `usage: employeemanager migrate <command>
+
+commands:
+  status         list migrations and whether they are applied
+  up             apply all pending migrations
+  down [n]       revert the last n applied migrations (default 1)
+  to <version>   migrate up or down to version (0 reverts everything)`
+
+// runMigrate implements the migrate subcommand against the database in
+// dbURL.
+func runMigrate(ctx context.Context, dbURL string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	db, dialect, err := database.NewDatabase(dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, err := database.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch cmd, rest := args[0], args[1:]; {
+	case cmd == "status" && len(rest) == 0:
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	case cmd == "up" && len(rest) == 0:
+		return m.Up(ctx)
+	case cmd == "down" && len(rest) <= 1:
+		steps := 1
+		if len(rest) == 1 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", rest[0])
+			}
+		}
+		return m.Down(ctx, steps)
+	case cmd == "to" && len(rest) == 1:
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		return m.To(ctx, version)
+	}
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/orgchart"
+)
+
+// runOrgChart implements the orgchart subcommand: it draws the org chart
+// like GET /api/v1/orgchart and writes it to a file, or standard output.
+func runOrgChart(ctx context.Context, cfg config, args []string) error {
+	fs := flag.NewFlagSet("orgchart", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: employeemanager orgchart [flags]")
+		fs.PrintDefaults()
+	}
+	var opts orgchart.Options
+	fs.IntVar(&opts.Root, "root", 0, "ID of the employee at the top of the chart, every head of the hierarchy if 0")
+	fs.IntVar(&opts.Depth, "depth", 0, "levels of reports to draw under the top, all if 0")
+	fs.StringVar(&opts.GroupBy, "group-by", "", "group the employees by "+orgchart.GroupByPosition)
+	format := fs.String("format", "", "output format: "+strings.Join(orgchart.Formats, ", ")+"; guessed from the extension of -o, svg otherwise")
+	out := fs.String("o", "-", "file to write the chart to, - for standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *format == "" {
+		*format = formatOf(*out)
+	}
+	if !slices.Contains(orgchart.Formats, *format) {
+		return fmt.Errorf("%w %q", orgchart.ErrUnknownFormat, *format)
+	}
+
+	// Drawing must not change the database it reads.
+	cfg.MigrateOnStart = false
+	empDB, closeDB, err := openEmployeeDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	chart, err := orgchart.Build(ctx, empDB, opts)
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		return orgchart.Render(os.Stdout, chart, *format)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := orgchart.Render(f, chart, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// formatOf guesses the format of a chart from the name of its file.
+func formatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".dot", ".gv":
+		return orgchart.FormatDOT
+	case ".mmd", ".mermaid":
+		return orgchart.FormatMermaid
+	}
+	return orgchart.FormatSVG
+}
@@ -0,0 +1,206 @@
+// Package orgchart draws the reporting lines of employees as Graphviz DOT,
+// Mermaid flowcharts or self-contained SVG.
+package orgchart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// Output formats.
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatSVG     = "svg"
+)
+
+// Formats are the formats Render accepts.
+var Formats = []string{FormatDOT, FormatMermaid, FormatSVG}
+
+// GroupByPosition groups employees with the same position together.
+const GroupByPosition = "position"
+
+var ErrUnknownFormat = errors.New("unknown org chart format")
+
+// Options select the part of the organisation a chart shows.
+type Options struct {
+	// Root is the employee at the top of the chart. When zero the chart
+	// shows every head of the hierarchy and everyone under them.
+	Root int
+	// Depth limits the levels of reports shown under the top of the chart,
+	// 1 showing direct reports only. Zero shows every level.
+	Depth int
+	// GroupBy groups employees in the chart: empty, or GroupByPosition.
+	GroupBy string
+}
+
+// Chart is a forest of employees linked by reporting line.
+type Chart struct {
+	Roots   []*Node
+	GroupBy string
+}
+
+// Node is an employee in a chart with the reports shown under it.
+type Node struct {
+	database.Employee
+	Reports []*Node
+	// Hidden counts the employees under the node that the depth limit left
+	// out.
+	Hidden int
+}
+
+// group is the group of n in a chart grouped by groupBy, or empty.
+func (n *Node) group(groupBy string) string {
+	if groupBy == GroupByPosition {
+		return n.Position
+	}
+	return ""
+}
+
+// pageSize is the number of employees Build reads at a time.
+const pageSize = 500
+
+// Build reads the chart selected by opts from edb.
+func Build(ctx context.Context, edb database.EmployeeDB, opts Options) (Chart, error) {
+	var employees []database.Employee
+	if opts.Root != 0 {
+		root, err := edb.GetEmployeeByID(ctx, opts.Root)
+		if err != nil {
+			return Chart{}, err
+		}
+		reports, err := edb.ListReports(ctx, opts.Root)
+		if err != nil {
+			return Chart{}, err
+		}
+		employees = append(employees, root)
+		for _, r := range reports {
+			employees = append(employees, r.Employee)
+		}
+	} else {
+		q := database.ListQuery{Page: 1, PerPage: pageSize, SkipTotal: true}
+		for {
+			page, err := edb.ListEmployees(ctx, q)
+			if err != nil {
+				return Chart{}, err
+			}
+			employees = append(employees, page.Employees...)
+			if !page.More {
+				break
+			}
+			q.After = &page.Employees[len(page.Employees)-1]
+		}
+	}
+
+	chart := Chart{GroupBy: opts.GroupBy}
+	slices.SortFunc(employees, func(a, b database.Employee) int { return a.ID - b.ID })
+	nodes := make(map[int]*Node, len(employees))
+	for _, e := range employees {
+		nodes[e.ID] = &Node{Employee: e}
+	}
+	for _, e := range employees {
+		// The manager of the root is outside the chart.
+		if manager, ok := nodes[e.ManagerID]; ok && e.ID != opts.Root {
+			manager.Reports = append(manager.Reports, nodes[e.ID])
+		} else {
+			chart.Roots = append(chart.Roots, nodes[e.ID])
+		}
+	}
+	if opts.Depth > 0 {
+		for _, root := range chart.Roots {
+			prune(root, opts.Depth)
+		}
+	}
+	return chart, nil
+}
+
+// prune cuts the reports of n more than depth levels down.
+func prune(n *Node, depth int) {
+	if depth == 0 {
+		n.Hidden = count(n.Reports)
+		n.Reports = nil
+		return
+	}
+	for _, r := range n.Reports {
+		prune(r, depth-1)
+	}
+}
+
+// count counts nodes and everyone under them.
+func count(nodes []*Node) int {
+	n := len(nodes)
+	for _, node := range nodes {
+		n += count(node.Reports)
+	}
+	return n
+}
+
+// walk calls fn on every node of c, managers before their reports.
+func (c Chart) walk(fn func(n *Node)) {
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		fn(n)
+		for _, r := range n.Reports {
+			visit(r)
+		}
+	}
+	for _, root := range c.Roots {
+		visit(root)
+	}
+}
+
+// groups returns the groups of c in order, with their nodes by ID. Nodes
+// outside any group are left out.
+func (c Chart) groups() ([]string, map[string][]*Node) {
+	members := make(map[string][]*Node)
+	c.walk(func(n *Node) {
+		if g := n.group(c.GroupBy); g != "" {
+			members[g] = append(members[g], n)
+		}
+	})
+	names := make([]string, 0, len(members))
+	for name, nodes := range members {
+		names = append(names, name)
+		slices.SortFunc(nodes, func(a, b *Node) int { return a.ID - b.ID })
+	}
+	slices.Sort(names)
+	return names, members
+}
+
+// label is the text shown for n: its name, position and the size of any
+// part of its team left out.
+func (n *Node) label() []string {
+	lines := []string{n.Name, n.Position}
+	if n.Hidden > 0 {
+		lines = append(lines, fmt.Sprintf("+%d more", n.Hidden))
+	}
+	return lines
+}
+
+// ContentType is the media type of charts in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatDOT:
+		return "text/vnd.graphviz; charset=utf-8"
+	case FormatSVG:
+		return "image/svg+xml"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Render writes c to w in format.
+func Render(w io.Writer, c Chart, format string) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, c)
+	case FormatMermaid:
+		return WriteMermaid(w, c)
+	case FormatSVG:
+		return WriteSVG(w, c)
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
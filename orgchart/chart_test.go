@@ -0,0 +1,134 @@
+package orgchart
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// newOrg creates Ada, heading Grace and Mary, with Linus and Ken under
+// Grace, and Alan, who reports to no one.
+func newOrg(t *testing.T) database.EmployeeDB {
+	t.Helper()
+	ctx := context.Background()
+	edb := database.NewMemoryEmployee()
+	salary, _ := database.ParseMoney("50000", "USD")
+	for _, e := range []database.Employee{
+		{Name: `Ada "Countess" Lovelace`, Position: "CEO"},
+		{Name: "Grace", Position: "CTO", ManagerID: 1},
+		{Name: "Mary", Position: "CFO", ManagerID: 1},
+		{Name: "Linus", Position: "Engineer", ManagerID: 2},
+		{Name: "Ken", Position: "Engineer", ManagerID: 2},
+		{Name: "Alan", Position: "Engineer & <Researcher>"},
+	} {
+		e.Salary = salary
+		if _, err := edb.CreateEmployee(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return edb
+}
+
+func TestBuild(t *testing.T) {
+	edb := newOrg(t)
+	tests := []struct {
+		name  string
+		opts  Options
+		want  string
+		error error
+	}{
+		{name: "whole organisation", opts: Options{}, want: "1(2(4 5) 3) 6"},
+		{name: "rooted", opts: Options{Root: 2}, want: "2(4 5)"},
+		{name: "depth", opts: Options{Depth: 1}, want: "1(2+2 3) 6"},
+		{name: "missing root", opts: Options{Root: 9}, error: database.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chart, err := Build(context.Background(), edb, tt.opts)
+			if !errors.Is(err, tt.error) {
+				t.Fatalf("Build() error = %v, want %v", err, tt.error)
+			}
+			if got := shape(chart.Roots); got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// shape writes nodes as their IDs, with their reports in parentheses and
+// the count of hidden employees after a +.
+func shape(nodes []*Node) string {
+	var b bytes.Buffer
+	for i, n := range nodes {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.Itoa(n.ID))
+		if len(n.Reports) > 0 {
+			b.WriteString("(" + shape(n.Reports) + ")")
+		}
+		if n.Hidden > 0 {
+			b.WriteString("+" + strconv.Itoa(n.Hidden))
+		}
+	}
+	return b.String()
+}
+
+func TestRender(t *testing.T) {
+	edb := newOrg(t)
+	for _, format := range Formats {
+		for name, opts := range map[string]Options{
+			"plain":   {},
+			"grouped": {GroupBy: GroupByPosition, Depth: 1},
+		} {
+			t.Run(format+" "+name, func(t *testing.T) {
+				chart, err := Build(context.Background(), edb, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var b bytes.Buffer
+				if err := Render(&b, chart, format); err != nil {
+					t.Fatal(err)
+				}
+				if format == FormatSVG {
+					checkXML(t, b.Bytes())
+				}
+				cupaloy.SnapshotT(t, b.String())
+			})
+		}
+	}
+
+	if err := Render(io.Discard, Chart{}, "png"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Render() png error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestWriteSVGEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteSVG(&b, Chart{}); err != nil {
+		t.Fatal(err)
+	}
+	checkXML(t, b.Bytes())
+}
+
+// checkXML fails unless doc is well-formed XML.
+func checkXML(t *testing.T, doc []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package orgchart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes c as a Graphviz digraph, with a cluster per group.
+func WriteDOT(w io.Writer, c Chart) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph orgchart {")
+	fmt.Fprintln(b, "\trankdir=TB;")
+	fmt.Fprintln(b, `	node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica"];`)
+	fmt.Fprintln(b, `	edge [arrowhead=none];`)
+
+	node := func(indent string, n *Node) {
+		fmt.Fprintf(b, "%se%d [label=%s];\n", indent, n.ID, dotString(strings.Join(n.label(), "\n")))
+	}
+	c.walk(func(n *Node) {
+		if n.group(c.GroupBy) == "" {
+			node("\t", n)
+		}
+	})
+	names, members := c.groups()
+	for i, name := range names {
+		fmt.Fprintf(b, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(b, "\t\tlabel=%s;\n", dotString(name))
+		fmt.Fprintf(b, "\t\tstyle=filled;\n\t\tfillcolor=%s;\n", dotString(palette[i%len(palette)]))
+		for _, n := range members[name] {
+			node("\t\t", n)
+		}
+		fmt.Fprintln(b, "\t}")
+	}
+	c.walk(func(n *Node) {
+		for _, r := range n.Reports {
+			fmt.Fprintf(b, "\te%d -> e%d;\n", n.ID, r.ID)
+		}
+	})
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// dotString quotes s as a DOT string, in which \n breaks the line.
+func dotString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
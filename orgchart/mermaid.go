@@ -0,0 +1,49 @@
+package orgchart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMermaid writes c as a Mermaid flowchart, with a subgraph per group.
+func WriteMermaid(w io.Writer, c Chart) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "flowchart TD")
+
+	node := func(indent string, n *Node) {
+		lines := n.label()
+		for i, line := range lines {
+			lines[i] = mermaidText(line)
+		}
+		fmt.Fprintf(b, "%se%d[\"%s\"]\n", indent, n.ID, strings.Join(lines, "<br/>"))
+	}
+	c.walk(func(n *Node) {
+		if n.group(c.GroupBy) == "" {
+			node("    ", n)
+		}
+	})
+	names, members := c.groups()
+	for i, name := range names {
+		fmt.Fprintf(b, "    subgraph g%d [\"%s\"]\n", i, mermaidText(name))
+		for _, n := range members[name] {
+			node("        ", n)
+		}
+		fmt.Fprintln(b, "    end")
+		fmt.Fprintf(b, "    style g%d fill:%s\n", i, palette[i%len(palette)])
+	}
+	c.walk(func(n *Node) {
+		for _, r := range n.Reports {
+			fmt.Fprintf(b, "    e%d --- e%d\n", n.ID, r.ID)
+		}
+	})
+	return b.Flush()
+}
+
+// mermaidText escapes s for a quoted Mermaid label, where characters are
+// written as #code; and markup is otherwise interpreted.
+func mermaidText(s string) string {
+	r := strings.NewReplacer(`#`, "#35;", `"`, "#34;", `&`, "#38;", `<`, "#60;", `>`, "#62;", "\n", " ", "\r", "")
+	return r.Replace(s)
+}
@@ -0,0 +1,139 @@
+package orgchart
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dimensions of the SVG layout, in pixels.
+const (
+	nodeWidth  = 180
+	nodeHeight = 60
+	hGap       = 24
+	vGap       = 48
+	margin     = 20
+	legendRow  = 22
+)
+
+// palette fills the groups of a chart, in turn.
+var palette = []string{"#dbeafe", "#dcfce7", "#fef3c7", "#fce7f3", "#ede9fe", "#cffafe", "#ffedd5", "#e5e7eb"}
+
+// point is where a node is drawn: the centre of its top edge.
+type point struct{ x, y int }
+
+// layout places the nodes of c as a tidy tree: leaves take consecutive
+// columns from left to right, and managers are centred above their first
+// and last report, so that teams never overlap. It returns the positions
+// and the number of columns and levels used.
+func layout(c Chart) (positions map[*Node]point, columns, levels int) {
+	positions = make(map[*Node]point)
+	var place func(n *Node, level int) int
+	place = func(n *Node, level int) int {
+		levels = max(levels, level+1)
+		var x int
+		if len(n.Reports) == 0 {
+			x = margin + nodeWidth/2 + columns*(nodeWidth+hGap)
+			columns++
+		} else {
+			first := place(n.Reports[0], level+1)
+			last := first
+			for _, r := range n.Reports[1:] {
+				last = place(r, level+1)
+			}
+			x = (first + last) / 2
+		}
+		positions[n] = point{x: x, y: margin + level*(nodeHeight+vGap)}
+		return x
+	}
+	for _, root := range c.Roots {
+		place(root, 0)
+	}
+	return positions, columns, levels
+}
+
+// WriteSVG writes c as a standalone SVG document that needs no fonts,
+// scripts or stylesheets beyond its own. Groups are told apart by colour
+// and listed in a legend below the chart.
+func WriteSVG(w io.Writer, c Chart) error {
+	b := bufio.NewWriter(w)
+	positions, columns, levels := layout(c)
+	names, members := c.groups()
+
+	width := max(2*margin+columns*(nodeWidth+hGap)-hGap, 2*margin+nodeWidth)
+	height := 2*margin + levels*(nodeHeight+vGap) - vGap
+	if levels == 0 {
+		height = 2*margin + nodeHeight
+	}
+	legendTop := height
+	height += len(names) * legendRow
+
+	fmt.Fprintln(b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(b, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+	if len(c.Roots) == 0 {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="14" fill="#6b7280">No employees</text>`+"\n", width/2, margin+nodeHeight/2)
+	}
+
+	fill := make(map[*Node]string)
+	for i, name := range names {
+		for _, n := range members[name] {
+			fill[n] = palette[i%len(palette)]
+		}
+	}
+
+	fmt.Fprintln(b, `<g fill="none" stroke="#9ca3af" stroke-width="1.5">`)
+	c.walk(func(n *Node) {
+		from := positions[n]
+		for _, r := range n.Reports {
+			to := positions[r]
+			mid := from.y + nodeHeight + vGap/2
+			fmt.Fprintf(b, `<path d="M%d %d V%d H%d V%d"/>`+"\n", from.x, from.y+nodeHeight, mid, to.x, to.y)
+		}
+	})
+	fmt.Fprintln(b, `</g>`)
+
+	c.walk(func(n *Node) {
+		p := positions[n]
+		lines := n.label()
+		fmt.Fprintf(b, `<g><title>%s</title>`+"\n", xmlText(strings.Join(lines, "\n")))
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="8" fill="%s" stroke="#4b5563"/>`+"\n",
+			p.x-nodeWidth/2, p.y, nodeWidth, nodeHeight, cmp.Or(fill[n], "#ffffff"))
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="14" font-weight="bold" fill="#111827">%s</text>`+"\n",
+			p.x, p.y+21, xmlText(truncate(lines[0], 20)))
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="12" fill="#374151">%s</text>`+"\n",
+			p.x, p.y+38, xmlText(truncate(lines[1], 25)))
+		if len(lines) > 2 {
+			fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="11" fill="#6b7280">%s</text>`+"\n",
+				p.x, p.y+53, xmlText(lines[2]))
+		}
+		fmt.Fprintln(b, `</g>`)
+	})
+
+	for i, name := range names {
+		y := legendTop + i*legendRow
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="14" height="14" rx="3" fill="%s" stroke="#4b5563"/>`+"\n", margin, y, palette[i%len(palette)])
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="12" fill="#111827">%s</text>`+"\n", margin+22, y+11, xmlText(name))
+	}
+	fmt.Fprintln(b, `</svg>`)
+	return b.Flush()
+}
+
+// truncate shortens s to at most n characters, marking the cut with an
+// ellipsis. The full text stays in the title of the node.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func xmlText(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// purgeDeleted permanently removes the employees deleted more than retention
+// ago, once right away and then every interval until ctx is done.
+func purgeDeleted(ctx context.Context, empDB database.EmployeeDB, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := empDB.PurgeEmployees(ctx, time.Now().Add(-retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Failed to purge deleted employees: %v", err)
+		case purged > 0:
+			log.Printf("Purged %d employees deleted more than %s ago", purged, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from requests, as the headers of the REST API are.
+var (
+	actorKey         = strings.ToLower(handlers.ActorHeader)
+	requestIDKey     = strings.ToLower(middleware.RequestIDHeader)
+	authorizationKey = "authorization"
+)
+
+// healthPrefix starts the methods of the health service, which load
+// balancers call without a token.
+const healthPrefix = "/grpc.health.v1.Health/"
+
+// requestIDPrefix starts the request IDs made up by identify, which count
+// along with those of middleware.RequestID.
+var requestIDPrefix = func() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+	return hostname + "/grpc"
+}()
+
+// authenticate checks the bearer token of the authorization metadata of
+// ctx, if the server has a token.
+func (s *server) authenticate(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	token, ok := strings.CutPrefix(first(md, authorizationKey), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return status.Error(codes.Unauthenticated, "The request needs a valid bearer token")
+	}
+	return nil
+}
+
+// identify attributes the writes made with ctx to the actor and request ID
+// of its metadata, making up a request ID the way middleware.RequestID does
+// if there is none. Only callers holding the token of the server may name
+// the actor: without a token the port has no way to tell a gateway that
+// authenticated the actor from anyone else, so requests have no actor.
+func (s *server) identify(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if actor := first(md, actorKey); actor != "" && s.token != "" {
+		ctx = database.WithActor(ctx, actor)
+	}
+	id := first(md, requestIDKey)
+	if id == "" {
+		id = fmt.Sprintf("%s-%06d", requestIDPrefix, middleware.NextRequestID())
+	}
+	return database.WithRequestID(ctx, id)
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// serve authenticates a request and calls handler with its context, logs it,
+// and turns a panic into errInternal.
+func (s *server) serve(ctx context.Context, method string, handler func(context.Context) error) (err error) {
+	ctx = s.identify(ctx)
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("request %s: panic: %v\n%s", database.RequestIDFrom(ctx), p, debug.Stack())
+			err = errInternal
+		}
+		log.Printf("request %s: %s %s in %v", database.RequestIDFrom(ctx), method, status.Code(err), time.Since(start))
+	}()
+	if !strings.HasPrefix(method, healthPrefix) {
+		if err := s.authenticate(ctx); err != nil {
+			return err
+		}
+	}
+	return handler(ctx)
+}
+
+func (s *server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var resp any
+	err := s.serve(ctx, info.FullMethod, func(ctx context.Context) error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (s *server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.serve(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	})
+}
+
+// serverStream is a grpc.ServerStream with the context of serve.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,259 @@
+// Package rpc serves employees over gRPC, as the EmployeeService of
+// proto/employeemanager/v1/employee.proto, on top of the database and the
+// validation of the REST API in package handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+	"github.com/theluckiestsoul/employeemanager/rpc/employeepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type server struct {
+	employeepb.UnimplementedEmployeeServiceServer
+	emp    database.EmployeeDB
+	admins []string
+	token  string
+}
+
+type Option func(*server)
+
+// WithAdmins names the actors allowed to list deleted employees.
+func WithAdmins(actors ...string) Option {
+	return func(s *server) {
+		s.admins = append(s.admins, actors...)
+	}
+}
+
+// WithToken requires requests to carry token as a bearer token in their
+// authorization metadata, but for health checks. The holders of the token,
+// such as a gateway that authenticates users, are trusted to name the
+// actor of a request; without one requests have no actor.
+func WithToken(token string) Option {
+	return func(s *server) {
+		s.token = token
+	}
+}
+
+// Server is a gRPC server of EmployeeService, along with the health and
+// reflection services.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// NewServer returns a Server of the employees of db. Like the REST API, it
+// attributes each request to its actor and request ID, logs it and recovers
+// from its panics. It also checks the token of WithToken.
+func NewServer(db database.EmployeeDB, opts ...Option) *Server {
+	srv := &server{emp: db}
+	for _, opt := range opts {
+		opt(srv)
+	}
+	s := &Server{
+		Server: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(srv.unaryInterceptor),
+			grpc.ChainStreamInterceptor(srv.streamInterceptor),
+		),
+		health: health.NewServer(),
+	}
+	employeepb.RegisterEmployeeServiceServer(s.Server, srv)
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	s.health.SetServingStatus(employeepb.EmployeeService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	reflection.Register(s.Server)
+	return s
+}
+
+// Shutdown reports the server as not serving, stops it from accepting
+// requests and waits for the running ones to end, cancelling them once ctx
+// is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *server) CreateEmployee(ctx context.Context, req *employeepb.CreateEmployeeRequest) (*employeepb.Employee, error) {
+	params := toParams(req.GetEmployee())
+	if err := params.Validate(); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	employee, err := s.emp.CreateEmployee(params.Context(ctx), params.ToEmployee())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEmployee(employee), nil
+}
+
+func (s *server) GetEmployee(ctx context.Context, req *employeepb.GetEmployeeRequest) (*employeepb.Employee, error) {
+	id, err := employeeID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	var employee database.Employee
+	if req.AsOf == nil {
+		employee, err = s.emp.GetEmployeeByID(ctx, id)
+	} else {
+		if err := req.AsOf.CheckValid(); err != nil {
+			return nil, invalidArgument("as_of", "must be a valid time")
+		}
+		employee, err = s.emp.GetEmployeeAsOf(ctx, id, req.AsOf.AsTime())
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEmployee(employee), nil
+}
+
+func (s *server) UpdateEmployee(ctx context.Context, req *employeepb.UpdateEmployeeRequest) (*employeepb.Employee, error) {
+	id, err := employeeID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := employeeVersion(req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	params := toParams(req.GetEmployee())
+	if err := params.Validate(); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	employee := params.ToEmployee()
+	employee.ID, employee.Version = id, version
+	updated, err := s.emp.UpdateEmployee(params.Context(ctx), employee)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEmployee(updated), nil
+}
+
+func (s *server) DeleteEmployee(ctx context.Context, req *employeepb.DeleteEmployeeRequest) (*employeepb.DeleteEmployeeResponse, error) {
+	id, err := employeeID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := employeeVersion(req.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.emp.DeleteEmployee(ctx, id, version); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &employeepb.DeleteEmployeeResponse{}, nil
+}
+
+// ListEmployees streams the employees as ExportEmployees reads them, from
+// one snapshot. An error after the first employee ends the stream with its
+// status, so a client cannot take a partial listing for a complete one.
+func (s *server) ListEmployees(req *employeepb.ListEmployeesRequest, stream grpc.ServerStreamingServer[employeepb.Employee]) error {
+	ctx := stream.Context()
+	params := url.Values{}
+	for name, value := range map[string]string{
+		"name":       req.GetName(),
+		"position":   req.GetPosition(),
+		"q":          req.GetQ(),
+		"currency":   req.GetCurrency(),
+		"salary_min": req.GetSalaryMin(),
+		"salary_max": req.GetSalaryMax(),
+		"sort":       req.GetSort(),
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	if req.GetDepartmentId() != 0 {
+		params.Set("department_id", strconv.FormatInt(req.GetDepartmentId(), 10))
+	}
+	if req.GetIncludeDeleted() {
+		params.Set("include_deleted", "true")
+	}
+	if req.AsOf != nil {
+		if err := req.AsOf.CheckValid(); err != nil {
+			return invalidArgument("as_of", "must be a valid time")
+		}
+		params.Set("as_of", req.AsOf.AsTime().Format(time.RFC3339Nano))
+	}
+	q, err := handlers.ParseListQuery(params)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	if q.IncludeDeleted && !handlers.IsAdmin(ctx, s.admins) {
+		return status.Error(codes.PermissionDenied, "Only admins may list deleted employees")
+	}
+	err = s.emp.ExportEmployees(ctx, q, func(employee database.Employee) error {
+		return stream.Send(toEmployee(employee))
+	})
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func employeeID(id int64) (int, error) {
+	if id <= 0 {
+		return 0, invalidArgument("id", "must be a positive integer")
+	}
+	return int(id), nil
+}
+
+func employeeVersion(version int64) (int, error) {
+	if version < 0 {
+		return 0, invalidArgument("version", "must not be negative")
+	}
+	return int(version), nil
+}
+
+func toParams(p *employeepb.EmployeeParams) handlers.EmployeeParams {
+	return handlers.EmployeeParams{
+		Name:               p.GetName(),
+		Position:           p.GetPosition(),
+		Salary:             json.Number(p.GetSalary()),
+		Currency:           p.GetCurrency(),
+		DepartmentID:       int(p.GetDepartmentId()),
+		ManagerID:          int(p.GetManagerId()),
+		PositionID:         int(p.GetPositionId()),
+		BandOverrideReason: p.GetBandOverrideReason(),
+	}
+}
+
+func toEmployee(e database.Employee) *employeepb.Employee {
+	employee := &employeepb.Employee{
+		Id:           int64(e.ID),
+		Name:         e.Name,
+		Position:     e.Position,
+		Salary:       e.Salary.String(),
+		Currency:     e.Salary.Currency,
+		DepartmentId: int64(e.DepartmentID),
+		ManagerId:    int64(e.ManagerID),
+		PositionId:   int64(e.PositionID),
+		Version:      int64(e.Version),
+	}
+	if e.DeletedAt != nil {
+		employee.DeletedAt = timestamppb.New(*e.DeletedAt)
+		employee.DeletedBy = e.DeletedBy
+	}
+	return employee
+}
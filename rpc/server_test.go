@@ -0,0 +1,263 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/rpc/employeepb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves db on an in-memory listener and returns a connection to it.
+func dial(t *testing.T, db database.EmployeeDB, opts ...Option) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(db, opts...)
+	go s.Serve(lis)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// token is the token of the servers that authenticate requests.
+const token = "secret"
+
+// as returns ctx sent by actor through a gateway holding token.
+func as(ctx context.Context, actor string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token, "x-forwarded-user", actor)
+}
+
+func list(t *testing.T, ctx context.Context, client employeepb.EmployeeServiceClient, req *employeepb.ListEmployeesRequest) ([]string, error) {
+	t.Helper()
+	stream, err := client.ListEmployees(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		employee, err := stream.Recv()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return names, err
+		}
+		names = append(names, employee.Name)
+	}
+}
+
+func TestEmployeeService(t *testing.T) {
+	db := database.NewMemoryEmployee()
+	client := employeepb.NewEmployeeServiceClient(dial(t, db, WithAdmins("root"), WithToken(token)))
+	ctx := as(context.Background(), "jane")
+
+	ada, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.EmployeeParams{
+		Name: "Ada Lovelace", Position: "CTO", Salary: "150000.75",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ada.Id != 1 || ada.Salary != "150000.75" || ada.Currency != "USD" || ada.Version != 1 {
+		t.Errorf("created %v", ada)
+	}
+	for _, params := range []*employeepb.EmployeeParams{
+		{Name: "Grace Hopper", Position: "Engineer", Salary: "9000000", Currency: "JPY", ManagerId: ada.Id},
+		{Name: "Alan Turing", Position: "Engineer", Salary: "70000", ManagerId: ada.Id},
+	} {
+		if _, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: params}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("get", func(t *testing.T) {
+		got, err := client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{Id: ada.Id})
+		if err != nil || got.Name != "Ada Lovelace" {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("update with the current version", func(t *testing.T) {
+		got, err := client.UpdateEmployee(ctx, &employeepb.UpdateEmployeeRequest{Id: ada.Id, Version: ada.Version, Employee: &employeepb.EmployeeParams{
+			Name: "Ada King", Position: "CTO", Salary: "160000",
+		}})
+		if err != nil || got.Name != "Ada King" || got.Salary != "160000.00" || got.Version != 2 {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		names, err := list(t, ctx, client, &employeepb.ListEmployeesRequest{Position: "engineer", Sort: "-name"})
+		if err != nil || fmt.Sprint(names) != "[Grace Hopper Alan Turing]" {
+			t.Errorf("listed %v, %v", names, err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if _, err := client.DeleteEmployee(ctx, &employeepb.DeleteEmployeeRequest{Id: 3}); err != nil {
+			t.Fatal(err)
+		}
+		names, err := list(t, ctx, client, &employeepb.ListEmployeesRequest{})
+		if err != nil || fmt.Sprint(names) != "[Ada King Grace Hopper]" {
+			t.Errorf("listed %v, %v", names, err)
+		}
+		names, err = list(t, as(context.Background(), "root"), client, &employeepb.ListEmployeesRequest{IncludeDeleted: true, Q: "turing"})
+		if err != nil || fmt.Sprint(names) != "[Alan Turing]" {
+			t.Errorf("listed %v, %v", names, err)
+		}
+		deleted, err := client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{Id: 3})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("got %v, %v", deleted, err)
+		}
+	})
+
+	errorTests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"invalid employee", func() error {
+			_, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.EmployeeParams{Position: "Engineer", Salary: "1.234"}})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid id", func() error {
+			_, err := client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"missing employee", func() error {
+			_, err := client.GetEmployee(ctx, &employeepb.GetEmployeeRequest{Id: 42})
+			return err
+		}, codes.NotFound},
+		{"missing manager", func() error {
+			_, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.EmployeeParams{Name: "Nobody", Position: "Engineer", Salary: "1", ManagerId: 42}})
+			return err
+		}, codes.FailedPrecondition},
+		{"stale version", func() error {
+			_, err := client.UpdateEmployee(ctx, &employeepb.UpdateEmployeeRequest{Id: ada.Id, Version: ada.Version, Employee: &employeepb.EmployeeParams{Name: "Ada", Position: "CTO", Salary: "1"}})
+			return err
+		}, codes.Aborted},
+		{"employee with reports", func() error {
+			_, err := client.DeleteEmployee(ctx, &employeepb.DeleteEmployeeRequest{Id: ada.Id})
+			return err
+		}, codes.FailedPrecondition},
+		{"invalid filter", func() error {
+			_, err := list(t, ctx, client, &employeepb.ListEmployeesRequest{Sort: "age"})
+			return err
+		}, codes.InvalidArgument},
+		{"deleted for someone other than an admin", func() error {
+			_, err := list(t, ctx, client, &employeepb.ListEmployeesRequest{IncludeDeleted: true})
+			return err
+		}, codes.PermissionDenied},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); status.Code(err) != tt.code {
+				t.Errorf("got %v, want %v", err, tt.code)
+			}
+		})
+	}
+
+	t.Run("field violations", func(t *testing.T) {
+		_, err := client.CreateEmployee(ctx, &employeepb.CreateEmployeeRequest{Employee: &employeepb.EmployeeParams{Position: "Engineer", Salary: "1.234"}})
+		var fields []string
+		for _, detail := range status.Convert(err).Details() {
+			if bad, ok := detail.(*errdetails.BadRequest); ok {
+				for _, v := range bad.FieldViolations {
+					fields = append(fields, v.Field)
+				}
+			}
+		}
+		if fmt.Sprint(fields) != "[name salary]" {
+			t.Errorf("got violations of %v: %v", fields, err)
+		}
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	db := database.NewMemoryEmployee()
+	if _, err := db.CreateEmployee(context.Background(), database.Employee{Name: "Ada Lovelace", Position: "CTO", Salary: database.Money{Amount: 100, Currency: "USD"}}); err != nil {
+		t.Fatal(err)
+	}
+	client := employeepb.NewEmployeeServiceClient(dial(t, db, WithAdmins("root"), WithToken(token)))
+	get := &employeepb.GetEmployeeRequest{Id: 1}
+
+	if _, err := client.GetEmployee(context.Background(), get); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("without a token got %v, want %v", err, codes.Unauthenticated)
+	}
+	wrong := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer guess", "x-forwarded-user", "root")
+	if _, err := client.GetEmployee(wrong, get); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("with the wrong token got %v, want %v", err, codes.Unauthenticated)
+	}
+	if _, err := client.GetEmployee(as(context.Background(), "jane"), get); err != nil {
+		t.Errorf("with the token got %v", err)
+	}
+
+	// Without a token anyone can reach the port, so no one may claim to be
+	// an actor, let alone an admin.
+	open := employeepb.NewEmployeeServiceClient(dial(t, db, WithAdmins("root")))
+	claim := metadata.AppendToOutgoingContext(context.Background(), "x-forwarded-user", "root")
+	if _, err := list(t, claim, open, &employeepb.ListEmployeesRequest{IncludeDeleted: true}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("deleted for a claimed admin got %v, want %v", err, codes.PermissionDenied)
+	}
+	if _, err := open.DeleteEmployee(claim, &employeepb.DeleteEmployeeRequest{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := db.ListAuditEntries(context.Background(), database.AuditQuery{EmployeeID: 1, Action: database.AuditDelete, Limit: 1})
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].Actor != "" {
+		t.Errorf("ListAuditEntries() = %+v, %v, want a delete without an actor", page.Entries, err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	conn := dial(t, database.NewMemoryEmployee(), WithToken(token))
+	res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: employeepb.EmployeeService_ServiceDesc.ServiceName,
+	})
+	if err != nil || res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("got %v, %v", res, err)
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.Unavailable},
+		{fmt.Errorf("query: %w", database.ErrUnavailable), codes.Unavailable},
+		{database.ErrNotFound, codes.NotFound},
+		{database.ErrReportingCycle, codes.FailedPrecondition},
+		{database.ErrSalaryOutOfBand, codes.FailedPrecondition},
+		{database.ErrVersionMismatch, codes.Aborted},
+		{database.ErrConflict, codes.AlreadyExists},
+		{database.ErrHasReports, codes.FailedPrecondition},
+		{status.Error(codes.Unavailable, "transport closing"), codes.Unavailable},
+		{errors.New("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := status.Code(toStatus(context.Background(), tt.err)); got != tt.code {
+				t.Errorf("toStatus(%v) has code %v, want %v", tt.err, got, tt.code)
+			}
+		})
+	}
+}
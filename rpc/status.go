@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errInternal is the status of unexpected errors, whose details are logged
+// rather than sent to the client.
+var errInternal = status.Error(codes.Internal, "Internal server error")
+
+// toStatus returns the gRPC status of an error from validation or the
+// database layer, with the codes and messages the REST API has for it.
+func toStatus(ctx context.Context, err error) error {
+	var errs handlers.ValidationError
+	var code codes.Code
+	var msg string
+	switch {
+	case errors.As(err, &errs):
+		return validationStatus(errs)
+	case errors.Is(err, context.Canceled):
+		code, msg = codes.Canceled, "The request was cancelled"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, database.ErrUnavailable):
+		code, msg = codes.Unavailable, "The database is unavailable, try again later"
+	case errors.Is(err, database.ErrNotFound):
+		code, msg = codes.NotFound, "Employee not found"
+	case errors.Is(err, database.ErrUnknownDepartment):
+		code, msg = codes.FailedPrecondition, "The department does not exist"
+	case errors.Is(err, database.ErrUnknownManager):
+		code, msg = codes.FailedPrecondition, "The manager does not exist or is deleted"
+	case errors.Is(err, database.ErrReportingCycle):
+		code, msg = codes.FailedPrecondition, "The manager reports to the employee, directly or not"
+	case errors.Is(err, database.ErrUnknownPosition):
+		code, msg = codes.FailedPrecondition, "The position is not in the catalog"
+	case errors.Is(err, database.ErrUnmatchedPosition):
+		code, msg = codes.FailedPrecondition, "The position matches no single title of the catalog; give a position_id"
+	case errors.Is(err, database.ErrSalaryOutOfBand):
+		code, msg = codes.FailedPrecondition, "The salary is outside the band of the position; give a band_override_reason to set it anyway"
+	case errors.Is(err, database.ErrHasReports):
+		code, msg = codes.FailedPrecondition, "Other employees report to the employee; give them another manager first"
+	case errors.Is(err, database.ErrVersionMismatch):
+		code, msg = codes.Aborted, "The employee has changed since it was read, fetch it again and retry"
+	case errors.Is(err, database.ErrConflict):
+		code, msg = codes.AlreadyExists, "The change conflicts with existing data"
+	case errors.Is(err, database.ErrConstraint):
+		code, msg = codes.FailedPrecondition, "The change violates a data constraint"
+	case status.Code(err) != codes.Unknown:
+		// Already a status, such as that of a failed send on a stream.
+		return err
+	default:
+		log.Printf("request %s: %v", database.RequestIDFrom(ctx), err)
+		return errInternal
+	}
+	return status.Error(code, msg)
+}
+
+// validationStatus is the InvalidArgument status of errs, which lists the
+// invalid fields in its message and as BadRequest details.
+func validationStatus(errs handlers.ValidationError) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: e.Field, Description: e.Message}
+		msgs[i] = e.Field + " " + e.Message
+	}
+	return badRequest(violations, "Invalid request: "+strings.Join(msgs, "; "))
+}
+
+// invalidArgument is the InvalidArgument status of a single invalid field.
+func invalidArgument(field, message string) error {
+	violation := &errdetails.BadRequest_FieldViolation{Field: field, Description: message}
+	return badRequest([]*errdetails.BadRequest_FieldViolation{violation}, "Invalid request: "+field+" "+message)
+}
+
+func badRequest(violations []*errdetails.BadRequest_FieldViolation, msg string) error {
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return st.Err()
+}
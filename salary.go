@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// applySalaryChanges makes the salary changes that have taken effect the
+// salary of their employee, once right away and then every interval until
+// ctx is done.
+func applySalaryChanges(ctx context.Context, empDB database.EmployeeDB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		applied, err := empDB.ApplySalaryChanges(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to apply salary changes: %v", err)
+		}
+		if applied > 0 {
+			log.Printf("Applied the scheduled salary changes of %d employees", applied)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+// URNs of the schemas of the resources and messages served.
+const (
+	SchemaUser           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaEnterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	// SchemaCompensation extends users with the salary every employee
+	// needs. Its attributes are only written, never returned.
+	SchemaCompensation = "urn:employeemanager:params:scim:schemas:extension:compensation:2.0:User"
+
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// maxResults is the most resources a list returns at once.
+const maxResults = handlers.MaxPerPage
+
+// Attribute describes an attribute of a schema, as in RFC 7643 section 7.
+type Attribute struct {
+	Name           string      `json:"name"`
+	Type           string      `json:"type"`
+	MultiValued    bool        `json:"multiValued"`
+	Description    string      `json:"description"`
+	Required       bool        `json:"required"`
+	CaseExact      bool        `json:"caseExact"`
+	Mutability     string      `json:"mutability"`
+	Returned       string      `json:"returned"`
+	Uniqueness     string      `json:"uniqueness"`
+	ReferenceTypes []string    `json:"referenceTypes,omitempty"`
+	SubAttributes  []Attribute `json:"subAttributes,omitempty"`
+}
+
+// Schema is a schema resource.
+type Schema struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []Attribute `json:"attributes"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// str is a single-valued string attribute, writable and returned by
+// default unless mutability says otherwise.
+func str(name, description string, caseExact bool, mutability string) Attribute {
+	returned := "default"
+	if mutability == "writeOnly" {
+		returned = "never"
+	}
+	return Attribute{Name: name, Type: "string", Description: description, CaseExact: caseExact, Mutability: mutability, Returned: returned, Uniqueness: "none"}
+}
+
+var schemas = []Schema{
+	{
+		ID:          SchemaUser,
+		Name:        "User",
+		Description: "An employee with a user name",
+		Attributes: []Attribute{
+			func() Attribute {
+				a := str("userName", "Unique identifier of the user at the identity provider, unique ignoring case.", false, "readWrite")
+				a.Required, a.Uniqueness = true, "server"
+				return a
+			}(),
+			{Name: "name", Type: "complex", Description: "The name of the user. Only the full name is kept.", Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+				SubAttributes: []Attribute{
+					str("formatted", "The full name, which is the name of the employee.", false, "readWrite"),
+					str("familyName", "The family name, only read along with givenName when no full name is given.", false, "writeOnly"),
+					str("givenName", "The given name, only read along with familyName when no full name is given.", false, "writeOnly"),
+				}},
+			str("displayName", "The name of the employee.", false, "readWrite"),
+			str("title", "The position of the employee.", false, "readWrite"),
+			{Name: "active", Type: "boolean", Description: "Whether the employee is not deleted.", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+		},
+	},
+	{
+		ID:          SchemaEnterpriseUser,
+		Name:        "EnterpriseUser",
+		Description: "Enterprise User",
+		Attributes: []Attribute{
+			str("employeeNumber", "The ID of the employee.", true, "readOnly"),
+			str("department", "The name of the department of the employee, which is created if it does not exist.", false, "readWrite"),
+			{Name: "manager", Type: "complex", Description: "The user the employee reports to.", Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+				SubAttributes: []Attribute{
+					str("value", "The id of the manager.", true, "readWrite"),
+					{Name: "$ref", Type: "reference", ReferenceTypes: []string{"User"}, Description: "The URI of the manager.", Mutability: "readOnly", Returned: "default", Uniqueness: "none"},
+					str("displayName", "The name of the manager.", false, "readOnly"),
+				}},
+		},
+	},
+	{
+		ID:          SchemaCompensation,
+		Name:        "Compensation",
+		Description: "The salary of an employee, which is never returned",
+		Attributes: []Attribute{
+			{Name: "salary", Type: "decimal", Description: "The salary, with no more decimals than the currency allows.", Mutability: "writeOnly", Returned: "never", Uniqueness: "none"},
+			str("currency", "The ISO 4217 code of the currency of the salary, USD if omitted.", false, "writeOnly"),
+		},
+	},
+}
+
+// ResourceType describes the endpoint of a type of resource.
+type ResourceType struct {
+	Schemas          []string          `json:"schemas"`
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Endpoint         string            `json:"endpoint"`
+	Description      string            `json:"description"`
+	Schema           string            `json:"schema"`
+	SchemaExtensions []SchemaExtension `json:"schemaExtensions"`
+	Meta             *Meta             `json:"meta,omitempty"`
+}
+
+type SchemaExtension struct {
+	Schema   string `json:"schema"`
+	Required bool   `json:"required"`
+}
+
+var userResourceType = ResourceType{
+	ID:          "User",
+	Name:        "User",
+	Endpoint:    "/Users",
+	Description: "Employees provisioned by the identity provider",
+	Schema:      SchemaUser,
+	SchemaExtensions: []SchemaExtension{
+		{Schema: SchemaEnterpriseUser},
+		{Schema: SchemaCompensation},
+	},
+}
+
+// ServiceProviderConfig describes the features of the service.
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 Supported              `json:"patch"`
+	Bulk                  Bulk                   `json:"bulk"`
+	Filter                Filter                 `json:"filter"`
+	ChangePassword        Supported              `json:"changePassword"`
+	Sort                  Supported              `json:"sort"`
+	ETag                  Supported              `json:"etag"`
+	AuthenticationSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+	Meta                  *Meta                  `json:"meta,omitempty"`
+}
+
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+type Bulk struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type Filter struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary,omitempty"`
+}
+
+// ListResponse is the body of a list of resources.
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+func listOf[T any](resources []T) ListResponse[T] {
+	return ListResponse[T]{Schemas: []string{SchemaListResponse}, TotalResults: len(resources), StartIndex: 1, ItemsPerPage: len(resources), Resources: resources}
+}
+
+// ServiceProviderConfigHandler describes the features of the service.
+func (h *handler) ServiceProviderConfigHandler(w http.ResponseWriter, r *http.Request) {
+	config := ServiceProviderConfig{
+		Schemas:               []string{SchemaServiceProviderConfig},
+		Patch:                 Supported{true},
+		Filter:                Filter{Supported: true, MaxResults: maxResults},
+		ETag:                  Supported{true},
+		AuthenticationSchemes: []AuthenticationScheme{},
+		Meta:                  &Meta{ResourceType: "ServiceProviderConfig", Location: baseURL(r) + "/ServiceProviderConfig"},
+	}
+	if h.token != "" {
+		config.AuthenticationSchemes = append(config.AuthenticationSchemes, AuthenticationScheme{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "The token configured for the identity provider, in an Authorization: Bearer header",
+			Primary:     true,
+		})
+	}
+	writeJSON(w, r, http.StatusOK, config)
+}
+
+func resourceType(r *http.Request) ResourceType {
+	rt := userResourceType
+	rt.Schemas = []string{SchemaResourceType}
+	rt.Meta = &Meta{ResourceType: "ResourceType", Location: baseURL(r) + "/ResourceTypes/" + rt.ID}
+	return rt
+}
+
+// ResourceTypesHandler lists the types of resource served, which is only
+// User.
+func (h *handler) ResourceTypesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, listOf([]ResourceType{resourceType(r)}))
+}
+
+func (h *handler) ResourceTypeHandler(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "id") != userResourceType.ID {
+		writeError(w, r, http.StatusNotFound, "", "Resource type not found")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, resourceType(r))
+}
+
+func schemaResource(r *http.Request, s Schema) Schema {
+	s.Schemas = []string{SchemaSchema}
+	s.Meta = &Meta{ResourceType: "Schema", Location: baseURL(r) + "/Schemas/" + s.ID}
+	return s
+}
+
+// SchemasHandler lists the schemas of users and their extensions, with
+// the attributes the service keeps.
+func (h *handler) SchemasHandler(w http.ResponseWriter, r *http.Request) {
+	resources := make([]Schema, len(schemas))
+	for i, s := range schemas {
+		resources[i] = schemaResource(r, s)
+	}
+	writeJSON(w, r, http.StatusOK, listOf(resources))
+}
+
+func (h *handler) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	for _, s := range schemas {
+		if strings.EqualFold(s.ID, id) {
+			writeJSON(w, r, http.StatusOK, schemaResource(r, s))
+			return
+		}
+	}
+	writeError(w, r, http.StatusNotFound, "", "Schema not found")
+}
@@ -0,0 +1,98 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// Error is the body of an error response, as defined in RFC 7644 section
+// 3.12.
+type Error struct {
+	Schemas []string `json:"schemas"`
+	// Status is the HTTP status code, as a string.
+	Status   string `json:"status"`
+	ScimType string `json:"scimType,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// The scimType of 400 and 409 errors.
+const (
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidSyntax = "invalidSyntax"
+	ScimTypeInvalidPath   = "invalidPath"
+	ScimTypeInvalidValue  = "invalidValue"
+	ScimTypeNoTarget      = "noTarget"
+	ScimTypeMutability    = "mutability"
+	ScimTypeUniqueness    = "uniqueness"
+)
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, scimType, detail string) {
+	writeJSON(w, r, status, Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// badRequest is an error of the request itself, reported with its scimType.
+type badRequest struct {
+	scimType string
+	detail   string
+}
+
+func (e *badRequest) Error() string {
+	return e.detail
+}
+
+func invalidValue(detail string) error {
+	return &badRequest{ScimTypeInvalidValue, detail}
+}
+
+// writeDBError writes the response for an error of a request: a
+// badRequest, or one of the database layer, with the messages the REST API
+// has for it.
+func writeDBError(w http.ResponseWriter, r *http.Request, err error) {
+	var bad *badRequest
+	switch {
+	case errors.As(err, &bad):
+		writeError(w, r, http.StatusBadRequest, bad.scimType, bad.detail)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, database.ErrUnavailable):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, http.StatusServiceUnavailable, "", "The database is unavailable, try again later")
+	case errors.Is(err, database.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "", "User not found")
+	case errors.Is(err, database.ErrUnknownDepartment):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The department does not exist")
+	case errors.Is(err, database.ErrUnknownManager):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The manager does not exist or is not active")
+	case errors.Is(err, database.ErrReportingCycle):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The manager reports to the user, directly or not")
+	case errors.Is(err, database.ErrUnknownPosition):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The position is not in the catalog")
+	case errors.Is(err, database.ErrUnmatchedPosition):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The title matches no single position title of the catalog")
+	case errors.Is(err, database.ErrSalaryOutOfBand):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The salary is outside the band of the position")
+	case errors.Is(err, database.ErrHasReports):
+		writeError(w, r, http.StatusConflict, "", "Other users report to the user; give them another manager first")
+	case errors.Is(err, database.ErrVersionMismatch):
+		writePreconditionFailed(w, r)
+	case errors.Is(err, database.ErrConflict):
+		writeError(w, r, http.StatusConflict, ScimTypeUniqueness, "The userName or externalId is already in use")
+	case errors.Is(err, database.ErrConstraint):
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, "The change violates a data constraint")
+	default:
+		log.Printf("request %s: %v", database.RequestIDFrom(r.Context()), err)
+		writeError(w, r, http.StatusInternalServerError, "", "Internal server error")
+	}
+}
+
+func writePreconditionFailed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusPreconditionFailed, "", "The user has changed since it was read, fetch it again and retry")
+}
@@ -0,0 +1,467 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// attribute is an attribute of users, such as name.givenName, with the
+// schema that defines it.
+type attribute struct {
+	schema string
+	// name is the path of the attribute within its schema. Paths are
+	// unique across the schemas of users.
+	name string
+	def  Attribute
+}
+
+// commonAttributes are the attributes every resource has, which are given
+// no schema prefix but are read as those of the core User schema.
+var commonAttributes = []Attribute{
+	{Name: "id", Type: "string", CaseExact: true, Mutability: "readOnly", Returned: "always", Uniqueness: "server"},
+	{Name: "externalId", Type: "string", CaseExact: true, Mutability: "readWrite", Returned: "default", Uniqueness: "server"},
+}
+
+// lookupAttribute returns the attribute at path, such as userName,
+// name.givenName, or urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value.
+// Names are not case sensitive. Attributes of extensions need no schema
+// prefix either.
+func lookupAttribute(path string) (attribute, bool) {
+	schema := ""
+	for _, s := range schemas {
+		if len(path) > len(s.ID) && strings.EqualFold(path[:len(s.ID)+1], s.ID+":") {
+			schema, path = s.ID, path[len(s.ID)+1:]
+			break
+		}
+	}
+	top, sub, _ := strings.Cut(path, ".")
+	for _, s := range schemas {
+		if schema != "" && schema != s.ID {
+			continue
+		}
+		attrs := s.Attributes
+		if s.ID == SchemaUser {
+			attrs = slices.Concat(commonAttributes, attrs)
+		}
+		for _, a := range attrs {
+			if !strings.EqualFold(a.Name, top) {
+				continue
+			}
+			if sub == "" {
+				return attribute{s.ID, a.Name, a}, true
+			}
+			for _, subAttr := range a.SubAttributes {
+				if strings.EqualFold(subAttr.Name, sub) {
+					return attribute{s.ID, a.Name + "." + subAttr.Name, subAttr}, true
+				}
+			}
+			return attribute{}, false
+		}
+	}
+	return attribute{}, false
+}
+
+// value returns the value of attribute a of u: a string, a bool, or nil
+// if u has none.
+func (u *User) value(a attribute) any {
+	var s string
+	switch a.name {
+	case "id":
+		s = u.ID
+	case "externalId":
+		s = u.ExternalID
+	case "userName":
+		s = u.UserName
+	case "displayName":
+		s = u.DisplayName
+	case "title":
+		s = u.Title
+	case "active":
+		if u.Active == nil {
+			return nil
+		}
+		return *u.Active
+	}
+	if name := u.Name; name != nil {
+		switch a.name {
+		case "name.formatted":
+			s = name.Formatted
+		case "name.givenName":
+			s = name.GivenName
+		case "name.familyName":
+			s = name.FamilyName
+		}
+	}
+	if e := u.Enterprise; e != nil {
+		switch a.name {
+		case "employeeNumber":
+			s = e.EmployeeNumber
+		case "department":
+			s = e.Department
+		}
+		if m := e.Manager; m != nil {
+			switch a.name {
+			case "manager", "manager.value":
+				s = m.Value
+			case "manager.displayName":
+				s = m.DisplayName
+			case "manager.$ref":
+				s = m.Ref
+			}
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// filter is a parsed filter, as defined in RFC 7644 section 3.4.2.2.
+type filter interface {
+	match(u *User) bool
+}
+
+// matchAll is the filter of requests without one.
+type matchAll struct{}
+
+func (matchAll) match(*User) bool { return true }
+
+type and struct{ left, right filter }
+
+func (f and) match(u *User) bool { return f.left.match(u) && f.right.match(u) }
+
+type or struct{ left, right filter }
+
+func (f or) match(u *User) bool { return f.left.match(u) || f.right.match(u) }
+
+type not struct{ filter filter }
+
+func (f not) match(u *User) bool { return !f.filter.match(u) }
+
+// comparison is an attribute expression: attr op value, or attr pr.
+type comparison struct {
+	attr  attribute
+	op    string
+	value any
+}
+
+func (c comparison) match(u *User) bool {
+	v := u.value(c.attr)
+	switch c.op {
+	case "pr":
+		return v != nil
+	case "eq":
+		return c.equal(v)
+	case "ne":
+		return !c.equal(v)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	want := c.value.(string)
+	if !c.attr.def.CaseExact {
+		s, want = strings.ToLower(s), strings.ToLower(want)
+	}
+	switch c.op {
+	case "co":
+		return strings.Contains(s, want)
+	case "sw":
+		return strings.HasPrefix(s, want)
+	case "ew":
+		return strings.HasSuffix(s, want)
+	case "gt":
+		return s > want
+	case "ge":
+		return s >= want
+	case "lt":
+		return s < want
+	case "le":
+		return s <= want
+	}
+	return false
+}
+
+func (c comparison) equal(v any) bool {
+	s, ok := v.(string)
+	want, wantString := c.value.(string)
+	if ok && wantString && !c.attr.def.CaseExact {
+		return strings.EqualFold(s, want)
+	}
+	return v == c.value
+}
+
+// pushDown sets the filters of q that select the users f may match, so
+// that fewer employees are read to evaluate it. It reports whether q
+// selects exactly those users, so that f need not be evaluated at all.
+func pushDown(f filter, q *database.ListQuery) bool {
+	switch f := f.(type) {
+	case matchAll:
+		return true
+	case and:
+		left := pushDown(f.left, q)
+		return pushDown(f.right, q) && left
+	case comparison:
+		s, ok := f.value.(string)
+		if f.op != "eq" || !ok {
+			return false
+		}
+		// A second value for the same filter replaces the first, so only
+		// the last is known to select the users.
+		switch f.attr.name {
+		case "id", "employeeNumber":
+			id, err := strconv.Atoi(s)
+			if err != nil || id <= 0 || strconv.Itoa(id) != s {
+				// No user has it; -1 matches no employee either.
+				id = -1
+			}
+			exact := q.ID == 0 || q.ID == id
+			q.ID = id
+			return exact
+		case "userName":
+			exact := q.UserName == "" || q.UserName == s
+			q.UserName = s
+			return exact
+		case "externalId":
+			exact := q.ExternalID == "" || q.ExternalID == s
+			q.ExternalID = s
+			return exact
+		}
+	}
+	return false
+}
+
+// parseFilter parses the filter of a list. An empty filter matches every
+// user.
+func parseFilter(s string) (filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return matchAll{}, nil
+	}
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	f, err := p.or("")
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s in filter", t)
+	}
+	return f, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// tokenize splits a filter into words, JSON strings and brackets.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			kind := map[byte]tokenKind{'(': tokenOpen, ')': tokenClose, '[': tokenOpenBracket, ']': tokenCloseBracket}[c]
+			tokens = append(tokens, token{kind, string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, errors.New("unterminated string in filter")
+			}
+			var text string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &text); err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter", s[i:end+1])
+			}
+			tokens = append(tokens, token{tokenString, text})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, s[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser of filters, where not binds
+// tighter than and, and and tighter than or.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokenEOF}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword reports whether the next token is the word kw, which is not
+// case sensitive, and consumes it if it is.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if t := p.next(); t.kind != kind {
+		return fmt.Errorf("expected %s, found %s in filter", what, t)
+	}
+	return nil
+}
+
+// or parses filters joined by or. Attribute paths are read relative to
+// parent, the attribute of a value path, if it is not empty.
+func (p *parser) or(parent string) (filter, error) {
+	left, err := p.and(parent)
+	for err == nil && p.keyword("or") {
+		var right filter
+		right, err = p.and(parent)
+		left = or{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) and(parent string) (filter, error) {
+	left, err := p.not(parent)
+	for err == nil && p.keyword("and") {
+		var right filter
+		right, err = p.not(parent)
+		left = and{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) not(parent string) (filter, error) {
+	if p.keyword("not") {
+		if err := p.expect(tokenOpen, "'('"); err != nil {
+			return nil, err
+		}
+		f, err := p.group(parent)
+		return not{f}, err
+	}
+	if p.peek().kind == tokenOpen {
+		p.next()
+		return p.group(parent)
+	}
+	return p.comparison(parent)
+}
+
+// group parses the rest of a parenthesized filter.
+func (p *parser) group(parent string) (filter, error) {
+	f, err := p.or(parent)
+	if err != nil {
+		return nil, err
+	}
+	return f, p.expect(tokenClose, "')'")
+}
+
+// comparison parses an attribute expression, or a value path such as
+// manager[value eq "1"], which filters on the sub-attributes of manager.
+func (p *parser) comparison(parent string) (filter, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, fmt.Errorf("expected an attribute, found %s in filter", t)
+	}
+	path := t.text
+	if parent != "" {
+		path = parent + "." + path
+	}
+	if p.peek().kind == tokenOpenBracket {
+		if parent != "" {
+			return nil, errors.New("value paths cannot be nested in filter")
+		}
+		p.next()
+		f, err := p.or(path)
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(tokenCloseBracket, "']'")
+	}
+	attr, ok := lookupAttribute(path)
+	if !ok || attr.def.Type == "complex" && attr.name != "manager" {
+		return nil, fmt.Errorf("unknown attribute %q in filter", path)
+	}
+	op := p.next()
+	if op.kind != tokenWord {
+		return nil, fmt.Errorf("expected an operator after %s, found %s in filter", path, op)
+	}
+	c := comparison{attr: attr, op: strings.ToLower(op.text)}
+	switch c.op {
+	case "pr":
+		return c, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unknown operator %s in filter", op)
+	}
+	v := p.next()
+	switch {
+	case v.kind == tokenString:
+		c.value = v.text
+	case v.kind == tokenWord && (v.text == "true" || v.text == "false"):
+		c.value = v.text == "true"
+	case v.kind == tokenWord && v.text == "null":
+	default:
+		return nil, fmt.Errorf("expected a string, true, false or null after %s %s, found %s in filter", path, op.text, v)
+	}
+	_, isString := c.value.(string)
+	switch {
+	case attr.def.Type == "boolean" && c.value != nil && isString,
+		attr.def.Type != "boolean" && c.value != nil && !isString:
+		return nil, fmt.Errorf("%s is compared with a value of the wrong type in filter", path)
+	case c.op != "eq" && c.op != "ne" && (attr.def.Type == "boolean" || c.value == nil):
+		return nil, fmt.Errorf("%s cannot be compared with %s in filter", path, op.text)
+	}
+	return c, nil
+}
@@ -0,0 +1,107 @@
+package scim
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+func TestParseFilter(t *testing.T) {
+	active, inactive := true, false
+	ada := &User{ID: "1", ExternalID: "idp-ada", UserName: "Ada@example.com", DisplayName: "Ada Lovelace", Title: "CTO", Active: &active,
+		Enterprise: &EnterpriseUser{EmployeeNumber: "1", Department: "Engineering"}}
+	grace := &User{ID: "2", UserName: "grace@example.com", DisplayName: "Grace Hopper", Title: "Engineer", Active: &inactive,
+		Enterprise: &EnterpriseUser{EmployeeNumber: "2", Manager: &Manager{Value: "1", DisplayName: "Ada Lovelace"}}}
+
+	tests := []struct {
+		filter  string
+		want    []string
+		wantErr bool
+	}{
+		{filter: "", want: []string{"1", "2"}},
+		{filter: `userName eq "ada@EXAMPLE.com"`, want: []string{"1"}},
+		{filter: `USERNAME Eq "ada@example.com"`, want: []string{"1"}},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "grace@example.com"`, want: []string{"2"}},
+		{filter: `externalId eq "IDP-ADA"`},
+		{filter: `externalId pr`, want: []string{"1"}},
+		{filter: `displayName co "love"`, want: []string{"1"}},
+		{filter: `title sw "eng" or title ew "o"`, want: []string{"1", "2"}},
+		{filter: `userName gt "b"`, want: []string{"2"}},
+		{filter: `active eq false`, want: []string{"2"}},
+		{filter: `active ne true`, want: []string{"2"}},
+		{filter: `department eq null`, want: []string{"2"}},
+		{filter: `manager.value eq "1"`, want: []string{"2"}},
+		{filter: `manager[value eq "1" and displayName sw "ada"]`, want: []string{"2"}},
+		{filter: `urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "2"`, want: []string{"2"}},
+		{filter: `not (active eq true) or (title eq "CTO" and displayName ew "lovelace")`, want: []string{"1", "2"}},
+		{filter: `title eq "CTO" and displayName sw "x" or userName pr and active eq false`, want: []string{"2"}},
+		{filter: `userName eq "a\"b"`},
+		{filter: `emails eq "x"`, wantErr: true},
+		{filter: `name eq "x"`, wantErr: true},
+		{filter: `userName eq`, wantErr: true},
+		{filter: `userName like "x"`, wantErr: true},
+		{filter: `userName eq true`, wantErr: true},
+		{filter: `active eq "true"`, wantErr: true},
+		{filter: `active gt false`, wantErr: true},
+		{filter: `userName eq "x" and`, wantErr: true},
+		{filter: `(userName eq "x"`, wantErr: true},
+		{filter: `userName eq "x")`, wantErr: true},
+		{filter: `userName eq "x`, wantErr: true},
+		{filter: `manager[value eq "1"`, wantErr: true},
+		{filter: `manager[value[value eq "1"]]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, u := range []*User{ada, grace} {
+				if f.match(u) {
+					got = append(got, u.ID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseFilter() matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushDown(t *testing.T) {
+	tests := []struct {
+		filter    string
+		want      database.ListQuery
+		wantExact bool
+	}{
+		{``, database.ListQuery{}, true},
+		{`userName eq "Ada@example.com"`, database.ListQuery{UserName: "Ada@example.com"}, true},
+		{`externalId eq "idp-ada" and title eq "CTO"`, database.ListQuery{ExternalID: "idp-ada"}, false},
+		{`externalId eq "idp-ada" and userName eq "ada"`, database.ListQuery{ExternalID: "idp-ada", UserName: "ada"}, true},
+		{`id eq "2"`, database.ListQuery{ID: 2}, true},
+		{`id eq "02"`, database.ListQuery{ID: -1}, true},
+		{`employeeNumber eq "x"`, database.ListQuery{ID: -1}, true},
+		{`userName eq "a" and userName eq "b"`, database.ListQuery{UserName: "b"}, false},
+		{`userName eq "a" or userName eq "b"`, database.ListQuery{}, false},
+		{`not (userName eq "a")`, database.ListQuery{}, false},
+		{`userName ne "a"`, database.ListQuery{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("parseFilter() error = %v", err)
+			}
+			var got database.ListQuery
+			exact := pushDown(f, &got)
+			if got.ID != tt.want.ID || got.UserName != tt.want.UserName || got.ExternalID != tt.want.ExternalID || exact != tt.wantExact {
+				t.Errorf("pushDown() = %+v, %v, want %+v, %v", got, exact, tt.want, tt.wantExact)
+			}
+		})
+	}
+}
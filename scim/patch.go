@@ -0,0 +1,236 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// PatchRequest is the body of a PATCH request, as defined in RFC 7644
+// section 3.5.2.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation adds, replaces or removes the value at Path. Without a
+// path, Value is an object of the attributes to add or replace.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// patcher applies operations to a user, keeping track of the names they
+// set.
+type patcher struct {
+	u *User
+	// fullName and nameParts report whether displayName or name.formatted,
+	// and name.givenName or name.familyName, were set.
+	fullName, nameParts bool
+}
+
+// applyPatch applies ops to u. Op names are not case sensitive, and
+// booleans may be given as strings, as some identity providers send them.
+// Operations on attributes the service does not keep are ignored, the way
+// such attributes are in creates.
+//
+// Only the full name of employees is kept, so given and family names only
+// change it when both are given and no full name is.
+func applyPatch(u *User, ops []PatchOperation) error {
+	p := &patcher{u: u}
+	for _, op := range ops {
+		name := strings.ToLower(op.Op)
+		if name != "add" && name != "replace" && name != "remove" {
+			return &badRequest{ScimTypeInvalidSyntax, "Unknown patch operation " + strconv.Quote(op.Op)}
+		}
+		remove := name == "remove"
+		switch {
+		case op.Path == "" && remove:
+			return &badRequest{ScimTypeNoTarget, "A remove operation needs a path"}
+		case op.Path == "":
+			if err := p.setObject("", op.Value); err != nil {
+				return err
+			}
+		default:
+			if err := p.set(op.Path, op.Value, remove); err != nil {
+				return err
+			}
+		}
+	}
+	if n := u.Name; p.nameParts && !p.fullName && n != nil && n.GivenName != "" && n.FamilyName != "" {
+		u.DisplayName = n.GivenName + " " + n.FamilyName
+		n.Formatted = u.DisplayName
+	}
+	return nil
+}
+
+// setObject sets the attributes of object, a JSON object, under prefix,
+// the schema or complex attribute they belong to if it is not empty.
+// Read-only attributes in it, such as id, are ignored: clients send them
+// back along with the others.
+func (p *patcher) setObject(prefix string, object json.RawMessage) error {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(object, &attrs); err != nil {
+		return invalidValue("The value of an operation without a path must be an object")
+	}
+	for name, value := range attrs {
+		path := name
+		if prefix != "" {
+			path = prefix + ":" + name
+			if !strings.HasPrefix(prefix, "urn:") {
+				path = prefix + "." + name
+			}
+		}
+		if attr, ok := lookupAttribute(path); ok && attr.def.Mutability == "readOnly" {
+			continue
+		}
+		if err := p.set(path, value, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set sets the attribute at path to value, or removes it.
+func (p *patcher) set(path string, value json.RawMessage, remove bool) error {
+	for _, s := range schemas {
+		if strings.EqualFold(path, s.ID) {
+			if remove {
+				return &badRequest{ScimTypeMutability, "The " + s.Name + " extension cannot be removed"}
+			}
+			return p.setObject(s.ID, value)
+		}
+	}
+	if strings.ContainsAny(path, "[]") {
+		// Value paths select values of multi-valued attributes, which
+		// are not kept.
+		attr, _, _ := strings.Cut(path, "[")
+		if _, ok := lookupAttribute(attr); ok {
+			return &badRequest{ScimTypeInvalidPath, "The attribute " + attr + " is not multi-valued"}
+		}
+		return nil
+	}
+	if strings.EqualFold(path, "schemas") || strings.EqualFold(path, "meta") || strings.HasPrefix(strings.ToLower(path), "meta.") {
+		return nil
+	}
+	attr, ok := lookupAttribute(path)
+	if !ok {
+		return nil
+	}
+	if attr.def.Mutability == "readOnly" {
+		return &badRequest{ScimTypeMutability, "The attribute " + attr.name + " is read-only"}
+	}
+	if attr.def.Type == "complex" {
+		if remove {
+			return p.setString(attr, "")
+		}
+		if attr.name == "manager" && !bytes.HasPrefix(bytes.TrimSpace(value), []byte("{")) {
+			// The manager is sent as its id on its own by some providers.
+			return p.setValue(attr, value)
+		}
+		return p.setObject(attr.name, value)
+	}
+	if remove {
+		if attr.name == "active" {
+			return &badRequest{ScimTypeMutability, "The attribute active cannot be removed"}
+		}
+		return p.setString(attr, "")
+	}
+	return p.setValue(attr, value)
+}
+
+// setValue sets attr to value, a JSON string, boolean or number.
+func (p *patcher) setValue(attr attribute, value json.RawMessage) error {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(value))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return invalidValue("The value of " + attr.name + " is not valid JSON")
+	}
+	if attr.def.Type == "boolean" {
+		switch v := v.(type) {
+		case bool:
+			p.u.Active = &v
+			return nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				p.u.Active = &b
+				return nil
+			}
+		}
+		return invalidValue("The value of " + attr.name + " must be a boolean")
+	}
+	switch v := v.(type) {
+	case string:
+		return p.setString(attr, v)
+	case json.Number:
+		if attr.def.Type == "decimal" || attr.name == "manager" || attr.name == "manager.value" {
+			return p.setString(attr, v.String())
+		}
+	case nil:
+		return p.setString(attr, "")
+	}
+	return invalidValue("The value of " + attr.name + " must be a string")
+}
+
+// setString sets the string attribute attr, or clears the complex
+// attribute attr if s is empty.
+func (p *patcher) setString(attr attribute, s string) error {
+	u := p.u
+	switch attr.name {
+	case "externalId":
+		u.ExternalID = s
+	case "userName":
+		u.UserName = s
+	case "displayName":
+		u.DisplayName, p.fullName = s, true
+	case "title":
+		u.Title = s
+	case "name":
+		u.Name, u.DisplayName, p.fullName = nil, "", true
+	case "name.formatted", "name.givenName", "name.familyName":
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		switch attr.name {
+		case "name.formatted":
+			u.Name.Formatted, p.fullName = s, true
+		case "name.givenName":
+			u.Name.GivenName, p.nameParts = s, true
+		case "name.familyName":
+			u.Name.FamilyName, p.nameParts = s, true
+		}
+	case "department", "manager", "manager.value":
+		if u.Enterprise == nil {
+			u.Enterprise = &EnterpriseUser{}
+		}
+		switch {
+		case attr.name == "department":
+			u.Enterprise.Department = s
+		case s == "":
+			u.Enterprise.Manager = nil
+		default:
+			u.Enterprise.Manager = &Manager{Value: s}
+		}
+	case "salary", "currency":
+		if u.Compensation == nil {
+			u.Compensation = &Compensation{}
+		}
+		if attr.name == "salary" {
+			u.Compensation.Salary = json.Number(s)
+		} else {
+			u.Compensation.Currency = s
+		}
+	}
+	// A new displayName or name.formatted replaces the other, since the
+	// employee has only one name.
+	if attr.name == "displayName" && u.Name != nil {
+		u.Name.Formatted = ""
+	}
+	if attr.name == "name.formatted" {
+		u.DisplayName = ""
+	}
+	return nil
+}
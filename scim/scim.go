@@ -0,0 +1,157 @@
+// Package scim lets an identity provider provision employees over SCIM 2.0
+// (RFC 7643 and RFC 7644). Employees with a user name are the SCIM users:
+// the core and enterprise attributes of a user map onto the employee as
+// described in user.go, and deleted employees are users that are not
+// active.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// MediaType is the media type of SCIM requests and responses. Requests sent
+// as application/json are accepted too.
+const MediaType = "application/scim+json"
+
+// Actor is who the writes of a request are attributed to when the request
+// does not name an actor of its own.
+const Actor = "scim"
+
+// maxBodySize bounds the requests read.
+const maxBodySize = 1 << 20
+
+type handler struct {
+	emp   database.EmployeeDB
+	token string
+	// salary is given to users created without one.
+	salary *database.Money
+}
+
+// Option configures a handler.
+type Option func(*handler)
+
+// WithToken requires requests to carry token as a bearer token, which is
+// how identity providers authenticate to SCIM services.
+func WithToken(token string) Option {
+	return func(h *handler) {
+		h.token = token
+	}
+}
+
+// WithDefaultSalary sets the salary of users created without one in the
+// compensation extension. Without it such creates fail, since every
+// employee has a salary.
+func WithDefaultSalary(salary database.Money) Option {
+	return func(h *handler) {
+		h.salary = &salary
+	}
+}
+
+func NewHandler(db database.EmployeeDB, opts ...Option) *handler {
+	h := &handler{emp: db}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Routes returns the SCIM endpoints, to be mounted at the base URL of the
+// service, such as /scim/v2.
+func (h *handler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(h.Authenticate)
+	r.Get("/ServiceProviderConfig", h.ServiceProviderConfigHandler)
+	r.Get("/ResourceTypes", h.ResourceTypesHandler)
+	r.Get("/ResourceTypes/{id}", h.ResourceTypeHandler)
+	r.Get("/Schemas", h.SchemasHandler)
+	r.Get("/Schemas/{id}", h.SchemaHandler)
+	r.Route("/Users", func(r chi.Router) {
+		r.Get("/", h.ListUsersHandler)
+		r.Post("/", h.CreateUserHandler)
+		r.Get("/{id}", h.GetUserHandler)
+		r.Put("/{id}", h.ReplaceUserHandler)
+		r.Patch("/{id}", h.PatchUserHandler)
+		r.Delete("/{id}", h.DeleteUserHandler)
+	})
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "", "There is no SCIM resource at "+r.URL.Path)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusMethodNotAllowed, "", r.Method+" is not supported on "+r.URL.Path)
+	})
+	return r
+}
+
+// Authenticate checks the bearer token of a request, if the handler has
+// one, and attributes the writes made while serving it to Actor unless an
+// actor was identified already.
+func (h *handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+				writeError(w, r, http.StatusUnauthorized, "", "The request needs a valid bearer token")
+				return
+			}
+		}
+		ctx := r.Context()
+		if database.ActorFrom(ctx) == "" {
+			ctx = database.WithActor(ctx, Actor)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// baseURL returns the absolute URL the SCIM endpoints are mounted at, as
+// the client sees it, for the locations of resources.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	path := r.URL.Path
+	for _, endpoint := range []string{"/Users", "/Schemas", "/ResourceTypes", "/ServiceProviderConfig"} {
+		if i := strings.Index(path, endpoint); i >= 0 {
+			path = path[:i]
+			break
+		}
+	}
+	return scheme + "://" + r.Host + strings.TrimSuffix(path, "/")
+}
+
+// decode reads the JSON body of r into v. When the body cannot be read it
+// writes the error response and returns false.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := strings.Cut(contentType, ";")
+		if mediaType = strings.TrimSpace(mediaType); mediaType != MediaType && mediaType != "application/json" {
+			writeError(w, r, http.StatusUnsupportedMediaType, "", "The request body must be "+MediaType)
+			return false
+		}
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidSyntax, "The request body is not a valid SCIM resource: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("request %s: %v", middleware.GetReqID(r.Context()), err)
+	}
+}
@@ -0,0 +1,226 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"strings"
+	"testing"
+
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+func newRouter(db database.EmployeeDB, opts ...Option) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(handlers.IdentifyActor)
+	r.Mount("/scim/v2", NewHandler(db, opts...).Routes())
+	return r
+}
+
+func dumpResponse(t *testing.T, r *http.Response) string {
+	t.Helper()
+	body, err := httputil.DumpResponse(r, true)
+	if err != nil {
+		t.Fatalf("failed to dump response: %v", err)
+	}
+	return string(body)
+}
+
+const (
+	ada = `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],
+		"externalId":"idp-ada","userName":"ada@example.com","name":{"givenName":"Ada","familyName":"Lovelace"},"title":"CTO","active":true,
+		"emails":[{"value":"ada@example.com","primary":true}],
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"department":"Engineering"},
+		"urn:employeemanager:params:scim:schemas:extension:compensation:2.0:User":{"salary":"150000.75","currency":"usd"}}`
+	grace = `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"externalId":"idp-grace","userName":"grace@example.com","displayName":"Grace Hopper","title":"Engineer",
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"department":"engineering","manager":{"value":"2"}}}`
+)
+
+func TestUserHandlers(t *testing.T) {
+	newDB := func(t *testing.T) (database.EmployeeDB, http.Handler) {
+		t.Helper()
+		db := database.NewMemoryEmployee()
+		// Employees hired through the REST API are not users.
+		if _, err := db.CreateEmployee(context.Background(), database.Employee{Name: "Alan Turing", Position: "Engineer", Salary: database.Money{Amount: 7000000, Currency: "USD"}}); err != nil {
+			t.Fatal(err)
+		}
+		return db, newRouter(db, WithDefaultSalary(database.Money{Amount: 5000000, Currency: "USD"}))
+	}
+	type step struct {
+		name           string
+		method         string
+		path           string
+		ifMatch        string
+		body           string
+		expectedStatus int
+	}
+	request := func(tt step) *http.Request {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set(middleware.RequestIDHeader, "scim")
+		req.Header.Set("Content-Type", MediaType)
+		if tt.name == "unsupported media type" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		return req
+	}
+
+	// The steps build on each other, so each runs on a store of its own
+	// after the steps before it.
+	tests := []step{
+		{"create", "POST", "/scim/v2/Users", "", ada, http.StatusCreated},
+		{"create with the default salary", "POST", "/scim/v2/Users", "", grace, http.StatusCreated},
+		{"create with a taken user name", "POST", "/scim/v2/Users", "", `{"userName":"ADA@example.com","displayName":"Ada","title":"CTO"}`, http.StatusConflict},
+		{"create without a user name", "POST", "/scim/v2/Users", "", `{"displayName":"Nobody","title":"CTO"}`, http.StatusBadRequest},
+		{"create invalid user", "POST", "/scim/v2/Users", "", `{"userName":"x","urn:employeemanager:params:scim:schemas:extension:compensation:2.0:User":{"salary":"1.234"}}`, http.StatusBadRequest},
+		{"create with an invalid manager", "POST", "/scim/v2/Users", "", `{"userName":"x","displayName":"X","title":"CTO","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"manager":{"value":"x"}}}`, http.StatusBadRequest},
+		{"create with a missing manager", "POST", "/scim/v2/Users", "", `{"userName":"x","displayName":"X","title":"CTO","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"manager":{"value":"42"}}}`, http.StatusBadRequest},
+		{"create malformed user", "POST", "/scim/v2/Users", "", `{"userName":`, http.StatusBadRequest},
+		{"get", "GET", "/scim/v2/Users/2", "", "", http.StatusOK},
+		{"get missing user", "GET", "/scim/v2/Users/42", "", "", http.StatusNotFound},
+		{"get employee that is not a user", "GET", "/scim/v2/Users/1", "", "", http.StatusNotFound},
+		{"list", "GET", "/scim/v2/Users", "", "", http.StatusOK},
+		{"list by user name", "GET", `/scim/v2/Users?filter=userName+eq+%22GRACE%40example.com%22`, "", "", http.StatusOK},
+		{"list by external id", "GET", `/scim/v2/Users?filter=externalId+eq+%22idp-ada%22`, "", "", http.StatusOK},
+		{"list by complex filter", "GET", `/scim/v2/Users?filter=title+eq+%22engineer%22+and+not+(displayName+sw+%22A%22)+or+urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager.value+eq+%222%22`, "", "", http.StatusOK},
+		{"list page", "GET", "/scim/v2/Users?startIndex=2&count=1", "", "", http.StatusOK},
+		{"list count only", "GET", "/scim/v2/Users?count=0", "", "", http.StatusOK},
+		{"list with an invalid filter", "GET", `/scim/v2/Users?filter=emails+eq+%22x%22`, "", "", http.StatusBadRequest},
+		{"list with an invalid count", "GET", "/scim/v2/Users?count=all", "", "", http.StatusBadRequest},
+		{"delete user with reports", "DELETE", "/scim/v2/Users/2", "", "", http.StatusConflict},
+		{"replace", "PUT", "/scim/v2/Users/3", `W/"1"`, `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"externalId":"idp-grace","userName":"grace@example.com",
+			"name":{"formatted":"Grace Brewster Hopper"},"title":"Rear Admiral","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"department":"Navy"}}`, http.StatusOK},
+		{"replace stale user", "PUT", "/scim/v2/Users/3", `W/"1"`, grace, http.StatusPreconditionFailed},
+		{"deactivate", "PATCH", "/scim/v2/Users/3", "", `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`, http.StatusOK},
+		{"patch inactive user", "PATCH", "/scim/v2/Users/3", "", `{"Operations":[{"op":"replace","path":"title","value":"Admiral"}]}`, http.StatusBadRequest},
+		{"list inactive users", "GET", `/scim/v2/Users?filter=active+eq+false`, "", "", http.StatusOK},
+		{"reactivate", "PATCH", "/scim/v2/Users/3", "", `{"Operations":[{"op":"replace","value":{"id":"3","active":true,"name.givenName":"Amazing","name.familyName":"Grace"}},
+			{"op":"add","path":"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:manager","value":"2"},
+			{"op":"remove","path":"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"},
+			{"op":"add","path":"phoneNumbers[type eq \"work\"].value","value":"555-0100"}]}`, http.StatusOK},
+		{"patch read-only attribute", "PATCH", "/scim/v2/Users/3", "", `{"Operations":[{"op":"replace","path":"id","value":"4"}]}`, http.StatusBadRequest},
+		{"patch with an unknown operation", "PATCH", "/scim/v2/Users/3", "", `{"Operations":[{"op":"move","path":"title"}]}`, http.StatusBadRequest},
+		{"patch stale user", "PATCH", "/scim/v2/Users/3", `W/"2"`, `{"Operations":[{"op":"replace","path":"title","value":"Admiral"}]}`, http.StatusPreconditionFailed},
+		{"delete report", "DELETE", "/scim/v2/Users/3", "", "", http.StatusNoContent},
+		{"get deleted user", "GET", "/scim/v2/Users/3", "", "", http.StatusNotFound},
+		{"reuse the user name of a deleted user", "POST", "/scim/v2/Users", "", grace, http.StatusCreated},
+		{"create inactive user", "POST", "/scim/v2/Users", "", `{"userName":"alan@example.com","displayName":"Alan Turing","title":"Engineer","active":false}`, http.StatusCreated},
+		{"delete inactive user", "DELETE", "/scim/v2/Users/5", "", "", http.StatusNoContent},
+		{"unsupported media type", "POST", "/scim/v2/Users", "", `userName=x`, http.StatusUnsupportedMediaType},
+		{"unknown endpoint", "GET", "/scim/v2/Groups", "", "", http.StatusNotFound},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, r := newDB(t)
+			for _, before := range tests[:i] {
+				r.ServeHTTP(httptest.NewRecorder(), request(before))
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, request(tt))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+
+	// Writes are attributed to the identity provider.
+	db, r := newDB(t)
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), request(tt))
+	}
+	page, err := db.ListAuditEntries(context.Background(), database.AuditQuery{EmployeeID: 2, Limit: 1})
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].Actor != Actor {
+		t.Errorf("ListAuditEntries() = %+v, %v, want an entry by %s", page.Entries, err, Actor)
+	}
+}
+
+// pagingDB fails to export employees, so that only lists the database can
+// page through succeed.
+type pagingDB struct{ database.EmployeeDB }
+
+func (pagingDB) ExportEmployees(context.Context, database.ListQuery, func(database.Employee) error) error {
+	return errors.New("export of every employee")
+}
+
+func TestListUsersReadsOnlyThePage(t *testing.T) {
+	db := database.NewMemoryEmployee()
+	for _, userName := range []string{"", "ada@example.com", "grace@example.com", "alan@example.com"} {
+		employee := database.Employee{Name: "Employee", Position: "Engineer", Salary: database.Money{Amount: 5000000, Currency: "USD"}, UserName: userName}
+		if _, err := db.CreateEmployee(context.Background(), employee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := newRouter(pagingDB{db})
+
+	for _, tt := range []struct {
+		query     string
+		wantTotal int
+		wantUsers string
+	}{
+		{"startIndex=2&count=1", 3, `"userName":"grace@example.com"`},
+		{"filter=userName+eq+%22ALAN%40example.com%22", 1, `"userName":"alan@example.com"`},
+	} {
+		req := httptest.NewRequest("GET", "/scim/v2/Users?"+tt.query, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		body := rr.Body.String()
+		if rr.Code != http.StatusOK || !strings.Contains(body, fmt.Sprintf(`"totalResults":%d`, tt.wantTotal)) || !strings.Contains(body, tt.wantUsers) {
+			t.Errorf("GET ?%s = %d %s, want %d users with %s", tt.query, rr.Code, body, tt.wantTotal, tt.wantUsers)
+		}
+	}
+}
+
+func TestDiscoveryHandlers(t *testing.T) {
+	r := newRouter(database.NewMemoryEmployee(), WithToken("secret"))
+
+	tests := []struct {
+		name           string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{"service provider config", "/scim/v2/ServiceProviderConfig", "secret", http.StatusOK},
+		{"resource types", "/scim/v2/ResourceTypes", "secret", http.StatusOK},
+		{"resource type", "/scim/v2/ResourceTypes/User", "secret", http.StatusOK},
+		{"missing resource type", "/scim/v2/ResourceTypes/Group", "secret", http.StatusNotFound},
+		{"schemas", "/scim/v2/Schemas", "secret", http.StatusOK},
+		{"schema", "/scim/v2/Schemas/urn:ietf:params:scim:schemas:extension:enterprise:2.0:User", "secret", http.StatusOK},
+		{"missing schema", "/scim/v2/Schemas/urn:ietf:params:scim:schemas:core:2.0:Group", "secret", http.StatusNotFound},
+		{"without a token", "/scim/v2/Users", "", http.StatusUnauthorized},
+		{"with the wrong token", "/scim/v2/Users", "guess", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", status, tt.expectedStatus, rr.Body)
+			}
+			res := rr.Result()
+			defer res.Body.Close()
+			cupaloy.SnapshotT(t, dumpResponse(t, res))
+		})
+	}
+}
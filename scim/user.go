@@ -0,0 +1,603 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/theluckiestsoul/employeemanager/database"
+	"github.com/theluckiestsoul/employeemanager/handlers"
+)
+
+// User is the SCIM representation of an employee:
+//
+//	id, employeeNumber       ID
+//	externalId               ExternalID
+//	userName                 UserName
+//	displayName, name        Name
+//	title                    Position
+//	active                   not deleted
+//	department               the name of the department
+//	manager                  ManagerID
+//	salary, currency         Salary
+//
+// Other attributes sent by the identity provider are ignored.
+type User struct {
+	Schemas      []string        `json:"schemas"`
+	ID           string          `json:"id,omitempty"`
+	ExternalID   string          `json:"externalId,omitempty"`
+	UserName     string          `json:"userName"`
+	Name         *Name           `json:"name,omitempty"`
+	DisplayName  string          `json:"displayName,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Active       *bool           `json:"active,omitempty"`
+	Enterprise   *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Compensation *Compensation   `json:"urn:employeemanager:params:scim:schemas:extension:compensation:2.0:User,omitempty"`
+	Meta         *Meta           `json:"meta,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+type EnterpriseUser struct {
+	EmployeeNumber string   `json:"employeeNumber,omitempty"`
+	Department     string   `json:"department,omitempty"`
+	Manager        *Manager `json:"manager,omitempty"`
+}
+
+type Manager struct {
+	Value       string `json:"value,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type Compensation struct {
+	Salary   json.Number `json:"salary,omitempty"`
+	Currency string      `json:"currency,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// fullName returns the name of the employee u describes: its displayName,
+// or else its formatted name, or else its given and family names.
+func (u *User) fullName() string {
+	if name := strings.TrimSpace(u.DisplayName); name != "" {
+		return name
+	}
+	if u.Name == nil {
+		return ""
+	}
+	if name := strings.TrimSpace(u.Name.Formatted); name != "" {
+		return name
+	}
+	return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+}
+
+func (u *User) department() string {
+	if u.Enterprise == nil {
+		return ""
+	}
+	return strings.TrimSpace(u.Enterprise.Department)
+}
+
+// managerID returns the ID of the manager of u, or zero if it has none.
+func (u *User) managerID() (int, error) {
+	if u.Enterprise == nil || u.Enterprise.Manager == nil || u.Enterprise.Manager.Value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(u.Enterprise.Manager.Value)
+	if err != nil || id <= 0 {
+		return 0, invalidValue("The manager value must be the id of a user")
+	}
+	return id, nil
+}
+
+// clone returns a copy of u that shares nothing with it.
+func (u User) clone() User {
+	if u.Name != nil {
+		name := *u.Name
+		u.Name = &name
+	}
+	if u.Active != nil {
+		active := *u.Active
+		u.Active = &active
+	}
+	if u.Enterprise != nil {
+		enterprise := *u.Enterprise
+		if enterprise.Manager != nil {
+			manager := *enterprise.Manager
+			enterprise.Manager = &manager
+		}
+		u.Enterprise = &enterprise
+	}
+	if u.Compensation != nil {
+		compensation := *u.Compensation
+		u.Compensation = &compensation
+	}
+	return u
+}
+
+// etag is the entity tag of a user. It is weak, as SCIM prefers, though
+// the version changes on every write.
+func etag(employee database.Employee) string {
+	return `W/"` + strconv.Itoa(employee.Version) + `"`
+}
+
+// ifMatch reports whether the If-Match header of r, if it has one, lists
+// the entity tag of employee. Tags are compared weakly.
+func ifMatch(r *http.Request, employee database.Employee) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	tag := strings.TrimPrefix(etag(employee), "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// users renders employees as users, with the names of their departments
+// and managers, which are read ahead for all of them at once.
+type users struct {
+	base        string
+	departments map[int]string
+	managers    map[int]database.Employee
+}
+
+// users returns the users of employees, whose managers are read in a
+// single batch.
+func (h *handler) users(ctx context.Context, base string, employees ...database.Employee) (*users, error) {
+	departments, err := h.emp.ListDepartments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u := &users{base: base, departments: make(map[int]string, len(departments)), managers: map[int]database.Employee{}}
+	for _, d := range departments {
+		u.departments[d.ID] = d.Name
+	}
+	return u, u.readManagers(ctx, h.emp, employees)
+}
+
+// readManagers reads the managers of employees.
+func (u *users) readManagers(ctx context.Context, db database.EmployeeDB, employees []database.Employee) error {
+	var ids []int
+	for _, e := range employees {
+		if _, ok := u.managers[e.ManagerID]; e.ManagerID != 0 && !ok {
+			ids = append(ids, e.ManagerID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	managers, err := db.GetEmployeesByIDs(ctx, ids)
+	for _, m := range managers {
+		u.managers[m.ID] = m
+	}
+	return err
+}
+
+func (u *users) user(e database.Employee) User {
+	id := strconv.Itoa(e.ID)
+	active := e.DeletedAt == nil
+	user := User{
+		Schemas:     []string{SchemaUser, SchemaEnterpriseUser},
+		ID:          id,
+		ExternalID:  e.ExternalID,
+		UserName:    e.UserName,
+		Name:        &Name{Formatted: e.Name},
+		DisplayName: e.Name,
+		Title:       e.Position,
+		Active:      &active,
+		Enterprise:  &EnterpriseUser{EmployeeNumber: id, Department: u.departments[e.DepartmentID]},
+		Meta:        &Meta{ResourceType: "User", Location: u.base + "/Users/" + id, Version: etag(e)},
+	}
+	if e.ManagerID != 0 {
+		manager := &Manager{Value: strconv.Itoa(e.ManagerID)}
+		if m, ok := u.managers[e.ManagerID]; ok {
+			manager.DisplayName = m.Name
+			if m.UserName != "" {
+				manager.Ref = u.base + "/Users/" + manager.Value
+			}
+		}
+		user.Enterprise.Manager = manager
+	}
+	return user
+}
+
+// errUserNotFound is returned for the users that do not exist, and for
+// the employees that are not users.
+var errUserNotFound = fmt.Errorf("user %w", database.ErrNotFound)
+
+// lookup returns the employee that is user id, active or not.
+func (h *handler) lookup(ctx context.Context, id string) (database.Employee, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return database.Employee{}, errUserNotFound
+	}
+	page, err := h.emp.ListEmployees(ctx, database.ListQuery{PerPage: 1, SkipTotal: true, IncludeDeleted: true, ID: n})
+	if err != nil {
+		return database.Employee{}, err
+	}
+	if len(page.Employees) == 0 || page.Employees[0].UserName == "" {
+		return database.Employee{}, errUserNotFound
+	}
+	return page.Employees[0], nil
+}
+
+func (h *handler) writeUser(w http.ResponseWriter, r *http.Request, status int, employee database.Employee) {
+	u, err := h.users(r.Context(), baseURL(r), employee)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	user := u.user(employee)
+	w.Header().Set("ETag", user.Meta.Version)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", user.Meta.Location)
+	}
+	writeJSON(w, r, status, user)
+}
+
+// attributeNames names the fields of handlers.EmployeeParams as the
+// attributes of a user they come from.
+var attributeNames = map[string]string{
+	"name":       "displayName",
+	"position":   "title",
+	"salary":     SchemaCompensation + ":salary",
+	"currency":   SchemaCompensation + ":currency",
+	"manager_id": SchemaEnterpriseUser + ":manager",
+}
+
+// params returns the employee u describes, validated as the REST API
+// validates employees. Its salary is salary unless u has one.
+func (h *handler) params(u *User, salary *database.Money) (handlers.EmployeeParams, error) {
+	if strings.TrimSpace(u.UserName) == "" {
+		return handlers.EmployeeParams{}, invalidValue("userName is required")
+	}
+	p := handlers.EmployeeParams{Name: u.fullName(), Position: strings.TrimSpace(u.Title)}
+	switch {
+	case u.Compensation != nil && u.Compensation.Salary != "":
+		p.Salary, p.Currency = u.Compensation.Salary, u.Compensation.Currency
+	case u.Compensation != nil && u.Compensation.Currency != "":
+		return p, invalidValue("A currency needs a salary in the " + SchemaCompensation + " extension")
+	case salary != nil:
+		p.Salary, p.Currency = json.Number(salary.String()), salary.Currency
+	default:
+		return p, invalidValue("salary is required in the " + SchemaCompensation + " extension")
+	}
+	var err error
+	if p.ManagerID, err = u.managerID(); err != nil {
+		return p, err
+	}
+	var errs handlers.ValidationError
+	if errors.As(p.Validate(), &errs) {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			if e.Field == "position" {
+				e.Message = "must not be empty"
+			}
+			msgs[i] = attributeNames[e.Field] + " " + e.Message
+		}
+		return p, invalidValue(strings.Join(msgs, "; "))
+	}
+	return p, nil
+}
+
+// departmentID returns the ID of the department named name, ignoring
+// case, creating it if there is none. An empty name is no department.
+func (h *handler) departmentID(ctx context.Context, name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for attempt := 0; ; attempt++ {
+		departments, err := h.emp.ListDepartments(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, d := range departments {
+			if strings.EqualFold(d.Name, name) {
+				return d.ID, nil
+			}
+		}
+		d, err := h.emp.CreateDepartment(ctx, database.Department{Name: name})
+		// Another request may have created it in between.
+		if errors.Is(err, database.ErrConflict) && attempt == 0 {
+			continue
+		}
+		return d.ID, err
+	}
+}
+
+// ListUsersHandler lists the users matching the filter query parameter, a
+// page of count users from the startIndex-th, counting from 1. Filters the
+// database can evaluate read only the page; the others read every user.
+func (h *handler) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startIndex, count := 1, maxResults
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"startIndex", &startIndex}, {"count", &count}} {
+		if s := query.Get(param.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, ScimTypeInvalidValue, param.name+" must be an integer")
+				return
+			}
+			*param.value = n
+		}
+	}
+	startIndex, count = max(startIndex, 1), min(max(count, 0), maxResults)
+	f, err := parseFilter(query.Get("filter"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ScimTypeInvalidFilter, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	q := database.ListQuery{IncludeDeleted: true, HasUserName: true}
+	exact := pushDown(f, &q)
+	// Filters only need the names of departments; managers are read for
+	// the page alone.
+	u, err := h.users(ctx, baseURL(r))
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	var page []database.Employee
+	total := 0
+	if exact {
+		q.Page, q.PerPage, q.Offset = 1, count, startIndex-1
+		var p database.EmployeePage
+		p, err = h.emp.ListEmployees(ctx, q)
+		page, total = p.Employees, p.Total
+	} else {
+		err = h.emp.ExportEmployees(ctx, q, func(e database.Employee) error {
+			if user := u.user(e); f.match(&user) {
+				total++
+				if total >= startIndex && len(page) < count {
+					page = append(page, e)
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if err := u.readManagers(ctx, h.emp, page); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	resources := make([]User, len(page))
+	for i, e := range page {
+		resources[i] = u.user(e)
+	}
+	writeJSON(w, r, http.StatusOK, ListResponse[User]{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *handler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	employee, err := h.lookup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	h.writeUser(w, r, http.StatusOK, employee)
+}
+
+// CreateUserHandler creates the employee a user describes. Users created
+// inactive are created deleted, so that the identity provider can activate
+// them later.
+func (h *handler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	var user User
+	if !decode(w, r, &user) {
+		return
+	}
+	ctx := r.Context()
+	p, err := h.params(&user, h.salary)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	employee := p.ToEmployee()
+	employee.UserName, employee.ExternalID = strings.TrimSpace(user.UserName), user.ExternalID
+	if employee.DepartmentID, err = h.departmentID(ctx, user.department()); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if user.Active != nil && !*user.Active {
+		deletedAt := time.Now()
+		employee.DeletedAt = &deletedAt
+	}
+	if employee, err = h.emp.CreateEmployee(ctx, employee); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	h.writeUser(w, r, http.StatusCreated, employee)
+}
+
+// ReplaceUserHandler replaces the attributes of a user with those of the
+// request. The salary is kept unless the request has one, and so is
+// whether the user is active.
+func (h *handler) ReplaceUserHandler(w http.ResponseWriter, r *http.Request) {
+	var user User
+	if !decode(w, r, &user) {
+		return
+	}
+	h.update(w, r, func(User) (User, error) { return user, nil })
+}
+
+// PatchUserHandler applies the operations of a PatchOp request to a user.
+func (h *handler) PatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	var req PatchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	h.update(w, r, func(current User) (User, error) {
+		user := current.clone()
+		return user, applyPatch(&user, req.Operations)
+	})
+}
+
+// update writes to the user of a request the user that change returns for
+// it. A user that is not active can be activated, but its other attributes
+// only change once it is; both are written in one go.
+func (h *handler) update(w http.ResponseWriter, r *http.Request, change func(User) (User, error)) {
+	ctx := r.Context()
+	employee, err := h.lookup(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if !ifMatch(r, employee) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	version := 0
+	if r.Header.Get("If-Match") != "" {
+		version = employee.Version
+	}
+	u, err := h.users(ctx, baseURL(r), employee)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	current := u.user(employee)
+	user, err := change(current.clone())
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	active := employee.DeletedAt == nil
+	wantActive := active
+	if user.Active != nil {
+		wantActive = *user.Active
+	}
+
+	if !active && !wantActive {
+		if !sameAttributes(&current, &user) {
+			writeError(w, r, http.StatusBadRequest, ScimTypeMutability, "The user is not active; activate it to change its attributes")
+			return
+		}
+		h.writeUser(w, r, http.StatusOK, employee)
+		return
+	}
+	changes, err := h.changes(ctx, employee, &current, &user)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	changes.Version = version
+	if active != wantActive {
+		deleted := !wantActive
+		changes.Deleted = &deleted
+	}
+	if employee, err = h.emp.PatchEmployee(ctx, employee.ID, changes); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	h.writeUser(w, r, http.StatusOK, employee)
+}
+
+// sameAttributes reports whether writing user over current would leave
+// the employee as it is, whether they are active aside.
+func sameAttributes(current, user *User) bool {
+	managerID, err := user.managerID()
+	currentManagerID, _ := current.managerID()
+	return err == nil && managerID == currentManagerID &&
+		user.fullName() == current.fullName() &&
+		strings.TrimSpace(user.Title) == current.Title &&
+		strings.TrimSpace(user.UserName) == current.UserName &&
+		user.ExternalID == current.ExternalID &&
+		strings.EqualFold(user.department(), current.department()) &&
+		(user.Compensation == nil || user.Compensation.Salary == "")
+}
+
+// changes returns the changes that make employee, rendered as current, the
+// employee user describes.
+func (h *handler) changes(ctx context.Context, employee database.Employee, current, user *User) (database.EmployeeChanges, error) {
+	p, err := h.params(user, &employee.Salary)
+	if err != nil {
+		return database.EmployeeChanges{}, err
+	}
+	var changes database.EmployeeChanges
+	target := p.ToEmployee()
+	if target.Name != employee.Name {
+		changes.Name = &target.Name
+	}
+	if target.Position != employee.Position {
+		changes.Position = &target.Position
+		// A title of its own takes the employee out of the catalog.
+		if employee.PositionID != 0 {
+			none := 0
+			changes.PositionID = &none
+		}
+	}
+	if target.Salary != employee.Salary {
+		changes.Salary = &target.Salary
+	}
+	if target.ManagerID != employee.ManagerID {
+		changes.ManagerID = &target.ManagerID
+	}
+	if department := user.department(); !strings.EqualFold(department, current.department()) {
+		id, err := h.departmentID(ctx, department)
+		if err != nil {
+			return database.EmployeeChanges{}, err
+		}
+		changes.DepartmentID = &id
+	}
+	if userName := strings.TrimSpace(user.UserName); userName != employee.UserName {
+		changes.UserName = &userName
+	}
+	if user.ExternalID != employee.ExternalID {
+		changes.ExternalID = &user.ExternalID
+	}
+	return changes, nil
+}
+
+// DeleteUserHandler deletes a user. The employee is deleted, as it is when
+// the user is deactivated, and also forgets its user name and external ID,
+// so that it is no longer a user and both can be given to someone else.
+func (h *handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	employee, err := h.lookup(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	if !ifMatch(r, employee) {
+		writePreconditionFailed(w, r)
+		return
+	}
+	none, deleted := "", true
+	changes := database.EmployeeChanges{UserName: &none, ExternalID: &none, Deleted: &deleted, Version: employee.Version}
+	if _, err := h.emp.PatchEmployee(ctx, employee.ID, changes); err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
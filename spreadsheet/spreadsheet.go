@@ -0,0 +1,39 @@
+// Package spreadsheet reads tables of text from CSV files and from the first
+// worksheet of XLSX workbooks, and streams tables out in both formats.
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Media types of the formats the package reads and writes.
+const (
+	CSVContentType  = "text/csv"
+	XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ErrMalformed is returned for a file that is not valid in its format.
+var ErrMalformed = errors.New("malformed spreadsheet")
+
+// ReadCSV reads every record of a CSV file. Records may have fewer fields
+// than the header, as spreadsheets export trailing blank cells that way,
+// and a leading byte order mark is skipped.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return records, err
+}
@@ -0,0 +1,158 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  [][]string
+		error error
+	}{
+		{name: "plain", in: "name,salary\nJohn,50000\n", want: [][]string{{"name", "salary"}, {"John", "50000"}}},
+		{name: "byte order mark", in: "\xef\xbb\xbfname\r\n\"Doe, John\"\r\n", want: [][]string{{"name"}, {"Doe, John"}}},
+		{name: "short records", in: "name,salary\nJohn\n", want: [][]string{{"name", "salary"}, {"John"}}},
+		{name: "malformed", in: "name\n\"John\n", error: ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.in))
+			if !errors.Is(err, tt.error) {
+				t.Fatalf("ReadCSV() error = %v, want %v", err, tt.error)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// workbook zips parts into an XLSX workbook whose first sheet is sheet.
+func workbook(t *testing.T, sheet, shared string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Staff" sheetId="1" r:id="rId7"/><sheet name="Other" sheetId="2" r:id="rId8"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId8" Type="worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId7" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>wrong sheet</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": sheet,
+	}
+	if shared != "" {
+		parts["xl/sharedStrings.xml"] = shared
+	}
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range parts {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	shared := `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>name</t></si><si><t>salary</t></si>` +
+		`<si><r><t>Ada </t></r><r><t>Lovelace</t></r></si></sst>`
+	tests := []struct {
+		name   string
+		sheet  string
+		shared string
+		want   [][]string
+		error  error
+	}{
+		{
+			name: "shared and inline strings",
+			sheet: `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+				`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>50000.75</v></c></row>` +
+				`<row r="4"><c r="B4" t="inlineStr"><is><t>60000</t></is></c><c r="D4" t="b"><v>1</v></c></row>` +
+				`</sheetData></worksheet>`,
+			shared: shared,
+			want:   [][]string{{"name", "salary"}, {"Ada Lovelace", "50000.75"}, nil, {"", "60000", "", "TRUE"}},
+		},
+		{
+			name:  "missing shared string",
+			sheet: `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>3</v></c></row></sheetData></worksheet>`,
+			error: ErrMalformed,
+		},
+		{
+			name:  "invalid reference",
+			sheet: `<worksheet><sheetData><row r="1"><c r="1A"><v>3</v></c></row></sheetData></worksheet>`,
+			error: ErrMalformed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := workbook(t, tt.sheet, tt.shared)
+			got, err := ReadXLSX(bytes.NewReader(doc), int64(len(doc)))
+			if !errors.Is(err, tt.error) {
+				t.Fatalf("ReadXLSX() error = %v, want %v", err, tt.error)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadXLSX() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadXLSX(strings.NewReader("name,salary"), 11); !errors.Is(err, ErrMalformed) {
+		t.Errorf("ReadXLSX() of a CSV file error = %v, want %v", err, ErrMalformed)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewCSVWriter(&b)
+	w.WriteRow([]any{"name", "salary", "manager_id"})
+	w.WriteRow([]any{"Doe, John", Number("-50000.75"), nil})
+	w.WriteRow([]any{"=HYPERLINK(\"x\")", Number("1"), 7})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,salary,manager_id\n\"Doe, John\",-50000.75,\n\"'=HYPERLINK(\"\"x\"\")\",1,7\n"
+	if got := b.String(); got != want {
+		t.Errorf("CSVWriter wrote %q, want %q", got, want)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewXLSXWriter(&b, "Staff & co")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]any{
+		{"name", "salary", "manager_id"},
+		{" Ada <Lovelace> ", Number("50000.75"), nil},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wide := make([]any, 28)
+	wide[27] = 1
+	w.WriteRow(wide)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadXLSX(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	want := [][]string{{"name", "salary", "manager_id"}, {" Ada <Lovelace> ", "50000.75"}, append(make([]string, 27), "1")}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadXLSX() of a written workbook = %q, %v, want %q", got, err, want)
+	}
+	if name := columnName(27); name != "AB" {
+		t.Errorf("columnName(27) = %s, want AB", name)
+	}
+}
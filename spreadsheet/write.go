@@ -0,0 +1,166 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writer writes a table a row at a time, so that it never holds more than
+// a row. Values are strings, ints, Numbers or nil for an empty cell.
+type Writer interface {
+	WriteRow(values []any) error
+	// Close ends the table. It does not close the underlying writer.
+	Close() error
+}
+
+// Number is a decimal number kept as text, such as an amount of money, so
+// that it is written exactly as given. Workbooks store it as a number.
+type Number string
+
+// NewCSVWriter returns a Writer of CSV. Text that a spreadsheet would take
+// for a formula, starting with =, +, -, @ or a control character, is
+// prefixed with an apostrophe so that opening the file cannot run it.
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	c.record = c.record[:0]
+	for _, v := range values {
+		text := cellText(v)
+		if s, ok := v.(string); ok && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+			text = "'" + s
+		}
+		c.record = append(c.record, text)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// cellText returns v as text.
+func cellText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case Number:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// Parts of the workbook written by XLSXWriter ahead of its worksheet.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// NewXLSXWriter returns a Writer of an XLSX workbook whose only worksheet
+// is named sheet. Text is written inline rather than to a shared string
+// table, which would have to be held until the end.
+func NewXLSXWriter(w io.Writer, sheet string) (Writer, error) {
+	x := &xlsxWriter{zw: zip.NewWriter(w)}
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheet))
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", xlsxSheetStart},
+	} {
+		f, err := x.zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+		// The worksheet is the last part, left open for the rows.
+		x.sheet = f
+	}
+	return x, nil
+}
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+	buf   strings.Builder
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	x.buf.Reset()
+	fmt.Fprintf(&x.buf, `<row r="%d">`, x.row)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch v := v.(type) {
+		case nil:
+		case int, int64, Number:
+			fmt.Fprintf(&x.buf, `<c r="%s"><v>%s</v></c>`, ref, cellText(v))
+		default:
+			fmt.Fprintf(&x.buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(&x.buf, []byte(cellText(v)))
+			x.buf.WriteString(`</t></is></c>`)
+		}
+	}
+	x.buf.WriteString(`</row>`)
+	_, err := io.WriteString(x.sheet, x.buf.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// columnName returns the letters of column i, A for 0, the inverse of
+// column.
+func columnName(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
@@ -0,0 +1,255 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize bounds the uncompressed size of each part of a workbook read,
+// so that a small upload cannot inflate into an unbounded one.
+const maxPartSize = 64 << 20
+
+// ReadXLSX reads the rows of the first worksheet of an XLSX workbook, as
+// the text of their cells. Rows and cells the worksheet skips are empty, so
+// that row i is row i+1 of the sheet as a spreadsheet shows it. Numbers are
+// read as stored, which is how spreadsheets show them unless formatted.
+func ReadXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	parts := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	sheet, err := firstSheet(parts)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := parts[sheet]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing worksheet %s", ErrMalformed, sheet)
+	}
+	return readSheet(f, shared)
+}
+
+// openPart opens f for reading, up to maxPartSize bytes.
+func openPart(f *zip.File) (*xml.Decoder, func() error, error) {
+	if f.UncompressedSize64 > maxPartSize {
+		return nil, nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrMalformed, f.Name, maxPartSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return xml.NewDecoder(io.LimitReader(rc, maxPartSize)), rc.Close, nil
+}
+
+// decodePart decodes the XML of f into v.
+func decodePart(f *zip.File, v any) error {
+	dec, closePart, err := openPart(f)
+	if err != nil {
+		return err
+	}
+	defer closePart()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrMalformed, f.Name, err)
+	}
+	return nil
+}
+
+// firstSheet returns the name of the part of the first worksheet of the
+// workbook, following the relationship the workbook refers to it by.
+func firstSheet(parts map[string]*zip.File) (string, error) {
+	f, ok := parts["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: not an XLSX workbook", ErrMalformed)
+	}
+	var workbook struct {
+		Sheets []struct {
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(f, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: the workbook has no worksheet", ErrMalformed)
+	}
+	f, ok = parts["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", fmt.Errorf("%w: missing workbook relationships", ErrMalformed)
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(f, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: the first worksheet has no relationship", ErrMalformed)
+}
+
+// readSharedStrings reads the shared string table. Rich text runs are
+// joined, and phonetic guides dropped.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodePart(f, &sst); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		var sb strings.Builder
+		sb.WriteString(si.Text)
+		for _, r := range si.Runs {
+			sb.WriteString(r.Text)
+		}
+		shared[i] = sb.String()
+	}
+	return shared, nil
+}
+
+// cell is a cell of a worksheet as stored.
+type cell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+// readSheet reads the rows of a worksheet a row at a time, rather than
+// decoding the whole sheet at once.
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	dec, closePart, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer closePart()
+	var rows [][]string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrMalformed, f.Name, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row struct {
+			Num   int    `xml:"r,attr"`
+			Cells []cell `xml:"c"`
+		}
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrMalformed, f.Name, err)
+		}
+		// Row numbers are optional, and count from 1.
+		if row.Num == 0 {
+			row.Num = len(rows) + 1
+		}
+		if row.Num < len(rows)+1 {
+			return nil, fmt.Errorf("%w: %s: row %d is out of order", ErrMalformed, f.Name, row.Num)
+		}
+		for len(rows) < row.Num-1 {
+			rows = append(rows, nil)
+		}
+		values, err := cellValues(row.Cells, shared)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: row %d: %w", ErrMalformed, f.Name, row.Num, err)
+		}
+		rows = append(rows, values)
+	}
+}
+
+// cellValues returns the text of cells, placed in their columns.
+func cellValues(cells []cell, shared []string) ([]string, error) {
+	var values []string
+	for _, c := range cells {
+		col := len(values)
+		if c.Ref != "" {
+			var err error
+			if col, err = column(c.Ref); err != nil {
+				return nil, err
+			}
+		}
+		if col < len(values) {
+			return nil, fmt.Errorf("cell %s is out of order", c.Ref)
+		}
+		for len(values) < col {
+			values = append(values, "")
+		}
+		var text string
+		switch c.Type {
+		case "s":
+			i, err := strconv.Atoi(c.Value)
+			if err != nil || i < 0 || i >= len(shared) {
+				return nil, fmt.Errorf("cell %s refers to a missing shared string", c.Ref)
+			}
+			text = shared[i]
+		case "inlineStr":
+			text = c.Inline.Text
+			for _, r := range c.Inline.Runs {
+				text += r.Text
+			}
+		case "b":
+			text = map[string]string{"0": "FALSE", "1": "TRUE"}[c.Value]
+		default:
+			text = c.Value
+		}
+		values = append(values, text)
+	}
+	return values, nil
+}
+
+// column returns the index of the column of a cell reference, 0 for A1.
+func column(ref string) (int, error) {
+	col := 0
+	letters := strings.TrimRight(ref, "0123456789")
+	if letters == "" || len(letters) > 3 {
+		return 0, errors.New("invalid cell reference " + strconv.Quote(ref))
+	}
+	for _, r := range letters {
+		if r < 'A' || r > 'Z' {
+			return 0, errors.New("invalid cell reference " + strconv.Quote(ref))
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1, nil
+}
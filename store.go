@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/theluckiestsoul/employeemanager/database"
+)
+
+// openEmployeeDB returns the EmployeeDB selected by the scheme of the
+// configured DB_URL along with a function that releases its resources. A
+// memory:// URL selects the in-memory store; everything else is handed to
+// database.NewDatabase and, if enabled, migrated to the latest schema.
+func openEmployeeDB(ctx context.Context, cfg config) (database.EmployeeDB, func() error, error) {
+	if strings.HasPrefix(cfg.DbURL, "memory:") {
+		return database.NewMemoryEmployee(), func() error { return nil }, nil
+	}
+
+	db, dialect, err := database.NewDatabase(cfg.DbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.MigrateOnStart {
+		m, err := database.NewMigrator(db, dialect)
+		if err == nil {
+			err = m.Up(ctx)
+		}
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+	}
+	opts := []database.Option{database.WithQueryTimeout(cfg.QueryTimeout)}
+	if dialect == database.SQLite {
+		return database.NewSQLiteEmployee(db, opts...), db.Close, nil
+	}
+	return database.NewEmployee(db, opts...), db.Close, nil
+}